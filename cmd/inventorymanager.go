@@ -1,9 +1,15 @@
 package main
 
 import (
+	"context"
 	dbsql "database/sql"
+	"flag"
+	"fmt"
 	"log"
 	"net/http"
+	"os"
+	"strconv"
+	"time"
 
 	"github.com/kijevigombooc/inventory-manager/internal/inventory/handler/rest"
 	"github.com/kijevigombooc/inventory-manager/internal/inventory/service"
@@ -11,20 +17,184 @@ import (
 )
 
 func main() {
-	db, err := dbsql.Open("sqlite3", ":memory:")
+	dsn := flag.String("db", envOrDefault("INVENTORY_DB_PATH", "inventory.db"), "SQLite database file path ("+sql.InMemoryPath+" for a temporary database) or postgres:// DSN (env INVENTORY_DB_PATH)")
+	snapshotInterval := flag.Duration("snapshot-interval", durationEnvOrDefault("INVENTORY_SNAPSHOT_INTERVAL", time.Hour), "how often serve snapshots stock for point-in-time queries, 0 disables it (env INVENTORY_SNAPSHOT_INTERVAL)")
+	quarantineInterval := flag.Duration("quarantine-interval", durationEnvOrDefault("INVENTORY_QUARANTINE_INTERVAL", time.Hour), "how often serve quarantines expired consumables, 0 disables it (env INVENTORY_QUARANTINE_INTERVAL)")
+	allocation := flag.String("allocation-strategy", envOrDefault("INVENTORY_ALLOCATION_STRATEGY", service.AllocationRequestedFirst), "where inserts put products that do not fit the requested warehouse: requested-first, most-free-capacity, balanced-utilization or local-only (env INVENTORY_ALLOCATION_STRATEGY)")
+	picking := flag.String("picking-strategy", envOrDefault("INVENTORY_PICKING_STRATEGY", service.PickingRequestedFirst), "which warehouses removals take products from: requested-first, fefo, fifo, fewest-warehouses or local-only (env INVENTORY_PICKING_STRATEGY)")
+	flag.Usage = usage
+	flag.Parse()
+
+	db, dialect, err := sql.Open(*dsn)
 	if err != nil {
-		panic(err)
+		log.Fatal(err)
 	}
 	defer db.Close()
 
-	store := sql.NewInventoryStore(db)
+	switch command := flag.Arg(0); command {
+	case "", "serve":
+		err = serve(db, dialect, *allocation, *picking, *snapshotInterval, *quarantineInterval)
+	case "snapshot":
+		err = snapshot(db, dialect)
+	case "prune":
+		err = prune(db, dialect)
+	case "expiring":
+		err = expiring(db, dialect, flag.Args()[1:])
+	case "quarantine":
+		err = quarantine(db, dialect)
+	case "migrate":
+		err = migrate(db, dialect, flag.Args()[1:])
+	default:
+		flag.Usage()
+		err = fmt.Errorf("unknown command %q", command)
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+}
 
-	service := service.NewInventoryService(store)
+func serve(db *dbsql.DB, dialect *sql.Dialect, allocation string, picking string, snapshotInterval time.Duration, quarantineInterval time.Duration) error {
+	if err := checkSchema(db, dialect); err != nil {
+		return err
+	}
+	allocationStrategy, err := service.ParseAllocationStrategy(allocation)
+	if err != nil {
+		return err
+	}
+	pickingStrategy, err := service.ParsePickingStrategy(picking)
+	if err != nil {
+		return err
+	}
+	store := sql.NewInventoryStore(db, dialect)
+
+	service := service.NewInventoryService(store, service.WithAllocationStrategy(allocationStrategy), service.WithPickingStrategy(pickingStrategy))
+	if snapshotInterval > 0 {
+		go takeSnapshots(service, snapshotInterval)
+	}
+	if quarantineInterval > 0 {
+		go quarantineExpired(service, quarantineInterval)
+	}
 
 	mux := http.NewServeMux()
 	handler := rest.NewInventoryHandler(service)
 	handler.RegisterRoutes(mux)
-	if err := http.ListenAndServe(":8080", mux); err != nil {
-		log.Fatal(err)
+	return http.ListenAndServe(":8080", rest.WithMiddleware(mux))
+}
+
+func takeSnapshots(service service.Service, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for range ticker.C {
+		if err := service.TakeSnapshot(context.Background()); err != nil {
+			log.Printf("taking stock snapshot: %v", err)
+		}
+	}
+}
+
+func quarantineExpired(service service.Service, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for range ticker.C {
+		quarantined, err := service.QuarantineExpired(context.Background())
+		if err != nil {
+			log.Printf("quarantining expired stock: %v", err)
+		} else if quarantined > 0 {
+			log.Printf("quarantined %d expired products", quarantined)
+		}
+	}
+}
+
+func snapshot(db *dbsql.DB, dialect *sql.Dialect) error {
+	if err := checkSchema(db, dialect); err != nil {
+		return err
+	}
+	return service.NewInventoryService(sql.NewInventoryStore(db, dialect)).TakeSnapshot(context.Background())
+}
+
+func prune(db *dbsql.DB, dialect *sql.Dialect) error {
+	if err := checkSchema(db, dialect); err != nil {
+		return err
+	}
+	result, err := service.NewInventoryService(sql.NewInventoryStore(db, dialect)).Prune(context.Background())
+	if err != nil {
+		return err
+	}
+	fmt.Printf("deleted %d products, %d product type rows and %d brands\n", result.Products, result.ProductAttributes, result.Brands)
+	return nil
+}
+
+func expiring(db *dbsql.DB, dialect *sql.Dialect, args []string) error {
+	days := 30
+	if len(args) > 0 {
+		var err error
+		if days, err = strconv.Atoi(args[0]); err != nil || days < 0 {
+			return fmt.Errorf("invalid number of days %q", args[0])
+		}
+	}
+	if err := checkSchema(db, dialect); err != nil {
+		return err
+	}
+	stock, err := service.NewInventoryService(sql.NewInventoryStore(db, dialect)).GetExpiringStock(context.Background(), days)
+	if err != nil {
+		return err
+	}
+	if len(stock) == 0 {
+		fmt.Printf("nothing expires within %d days\n", days)
+	}
+	for _, expiring := range stock {
+		state := ""
+		switch {
+		case expiring.Quarantined:
+			state = "quarantined"
+		case expiring.Expired:
+			state = "expired"
+		}
+		fmt.Printf("%-20s %-15s %-15s %s %6d %s\n", expiring.WarehouseName, expiring.Sku, expiring.LotNumber, expiring.ExpirationDate, expiring.Quantity, state)
+	}
+	return nil
+}
+
+func quarantine(db *dbsql.DB, dialect *sql.Dialect) error {
+	if err := checkSchema(db, dialect); err != nil {
+		return err
+	}
+	quarantined, err := service.NewInventoryService(sql.NewInventoryStore(db, dialect)).QuarantineExpired(context.Background())
+	if err != nil {
+		return err
+	}
+	fmt.Printf("quarantined %d expired products\n", quarantined)
+	return nil
+}
+
+func usage() {
+	fmt.Fprintf(flag.CommandLine.Output(), `Usage: %s [flags] [command]
+
+Commands:
+  serve                   start the HTTP server (default)
+  snapshot                store a stock snapshot for point-in-time queries
+  prune                   delete products without stock and the brands and type rows nobody uses
+  expiring [days]         list consumables expiring within the days, 30 by default
+  quarantine              quarantine expired consumables so they can no longer be removed
+  migrate status          list migrations and whether they are applied
+  migrate up [steps]      apply pending migrations, all of them by default
+  migrate down [steps]    roll back applied migrations, one by default
+
+Flags:
+`, os.Args[0])
+	flag.PrintDefaults()
+}
+
+func durationEnvOrDefault(key string, defaultValue time.Duration) time.Duration {
+	value, err := time.ParseDuration(envOrDefault(key, defaultValue.String()))
+	if err != nil {
+		log.Fatalf("parsing %s: %v", key, err)
+	}
+	return value
+}
+
+func envOrDefault(key string, defaultValue string) string {
+	if value, ok := os.LookupEnv(key); ok && value != "" {
+		return value
 	}
+	return defaultValue
 }
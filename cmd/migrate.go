@@ -0,0 +1,94 @@
+package main
+
+import (
+	dbsql "database/sql"
+	"fmt"
+	"strconv"
+
+	"github.com/kijevigombooc/inventory-manager/internal/inventory/store/sql"
+	"github.com/kijevigombooc/inventory-manager/internal/inventory/store/sql/migration"
+)
+
+func migrate(db *dbsql.DB, dialect *sql.Dialect, args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("migrate needs a subcommand: status, up or down")
+	}
+	migrator, err := sql.NewMigrator(db, dialect)
+	if err != nil {
+		return err
+	}
+	switch args[0] {
+	case "status":
+		return printMigrationStatus(migrator)
+	case "up":
+		steps, err := parseSteps(args[1:], 0)
+		if err != nil {
+			return err
+		}
+		applied, err := migrator.Up(steps)
+		printMigrations("applied", applied)
+		return err
+	case "down":
+		steps, err := parseSteps(args[1:], 1)
+		if err != nil {
+			return err
+		}
+		rolledBack, err := migrator.Down(steps)
+		printMigrations("rolled back", rolledBack)
+		return err
+	default:
+		return fmt.Errorf("unknown migrate subcommand %q", args[0])
+	}
+}
+
+func printMigrationStatus(migrator *migration.Migrator) error {
+	current, err := migrator.CurrentVersion()
+	if err != nil {
+		return err
+	}
+	statuses, err := migrator.Status()
+	if err != nil {
+		return err
+	}
+	fmt.Printf("current version: %d, latest version: %d\n", current, migrator.LatestVersion())
+	for _, status := range statuses {
+		state := "pending"
+		if status.Applied {
+			state = "applied " + status.AppliedAt.Format("2006-01-02 15:04:05")
+		}
+		fmt.Printf("%-44s %s\n", status.Migration, state)
+	}
+	if err := migrator.Check(); err != nil {
+		return err
+	}
+	return nil
+}
+
+func printMigrations(action string, migrations []migration.Migration) {
+	if len(migrations) == 0 {
+		fmt.Println("nothing to do")
+	}
+	for _, migration := range migrations {
+		fmt.Printf("%s %s\n", action, migration)
+	}
+}
+
+// checkSchema refuses to run against a database migrated by a newer binary.
+func checkSchema(db *dbsql.DB, dialect *sql.Dialect) error {
+	migrator, err := sql.NewMigrator(db, dialect)
+	if err != nil {
+		return err
+	}
+	return migrator.Check()
+}
+
+func parseSteps(args []string, defaultSteps int) (int, error) {
+	if len(args) == 0 {
+		return defaultSteps, nil
+	}
+	steps, err := strconv.Atoi(args[0])
+	if err != nil || steps < 0 {
+		return 0, fmt.Errorf("invalid number of steps %q", args[0])
+	}
+	return steps, nil
+}
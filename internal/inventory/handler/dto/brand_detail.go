@@ -0,0 +1,6 @@
+package dto
+
+type BrandDetail struct {
+	Brand    `json:",inline"`
+	Products []IProduct `json:"products"`
+}
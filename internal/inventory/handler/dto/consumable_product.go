@@ -2,5 +2,5 @@ package dto
 
 type ConsumableProduct struct {
 	Product        `json:",inline"`
-	ExpirationDate string `json:"expirationDate"`
+	ExpirationDate Date `json:"expirationDate"`
 }
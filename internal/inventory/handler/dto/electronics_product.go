@@ -2,5 +2,5 @@ package dto
 
 type ElectronicsProduct struct {
 	Product        `json:",inline"`
-	WarrantyPeriod string `json:"warrantyPeriod"`
+	WarrantyPeriod Period `json:"warrantyPeriod"`
 }
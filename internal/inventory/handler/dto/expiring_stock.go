@@ -0,0 +1,12 @@
+package dto
+
+type ExpiringStock struct {
+	WarehouseName  string `json:"warehouseName"`
+	Sku            string `json:"sku"`
+	LotNumber      string `json:"lotNumber,omitempty"`
+	ExpirationDate Date   `json:"expirationDate"`
+	Quantity       int    `json:"quantity"`
+	Expired        bool   `json:"expired"`
+	// Quarantined stock is expired and can no longer be removed or transferred
+	Quarantined bool `json:"quarantined"`
+}
@@ -0,0 +1,8 @@
+package dto
+
+// InsertOptions tune how InsertProducts places the products, zero values use the server defaults.
+type InsertOptions struct {
+	AllocationStrategy string
+	Lot                *Lot
+	Serials            []string
+}
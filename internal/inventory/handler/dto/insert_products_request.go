@@ -1,54 +1,57 @@
 package dto
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
 )
 
 type InsertProductsRequest struct {
-	WarehouseName string   `json:"warehouseName"`
-	Product       any      `json:"product"`
-	ParsedProduct IProduct `json:"-"`
-	Quantity      int      `json:"quantity"`
+	WarehouseName      string   `json:"warehouseName"`
+	Product            any      `json:"product"`
+	ParsedProduct      IProduct `json:"-"`
+	Quantity           int      `json:"quantity"`
+	AllocationStrategy string   `json:"allocationStrategy,omitempty"`
+	Lot                *Lot     `json:"lot,omitempty"`
+	Serials            []string `json:"serials,omitempty"`
 }
 
 func (ipr *InsertProductsRequest) ParseProduct() error {
-	productJson, ok := ipr.Product.(map[string]interface{})
+	product, err := ParseProduct(ipr.Product)
+	if err != nil {
+		return err
+	}
+	ipr.ParsedProduct = product
+	return nil
+}
+
+func ParseProduct(data any) (IProduct, error) {
+	productJson, ok := data.(map[string]interface{})
 	if !ok {
-		return fmt.Errorf("product is not a map")
+		return nil, fmt.Errorf("product is not a map")
 	}
 	typeString, ok := productJson["type"]
 	if !ok {
-		return fmt.Errorf("no type field")
+		return nil, fmt.Errorf("no type field")
 	}
 	productTypeStr, ok := typeString.(string)
 	if !ok {
-		return fmt.Errorf("type is not a string")
+		return nil, fmt.Errorf("type is not a string")
 	}
-	switch ProductType(productTypeStr) {
-	case Book:
-		if err := unmarshallProduct(ipr, &BookProduct{}); err != nil {
-			return err
-		}
-	case Consumable:
-		if err := unmarshallProduct(ipr, &ConsumableProduct{}); err != nil {
-			return err
-		}
-	case Electronics:
-		if err := unmarshallProduct(ipr, &ElectronicsProduct{}); err != nil {
-			return err
-		}
-	default:
-		return fmt.Errorf("unknown product type")
+	newProduct, ok := productTypes[ProductType(productTypeStr)]
+	if !ok {
+		return nil, fmt.Errorf("unknown product type")
 	}
-	return nil
+	return unmarshallProduct(data, newProduct())
 }
 
-func unmarshallProduct[T IProduct](ipr *InsertProductsRequest, product T) error {
-	productData, _ := json.Marshal(ipr.Product)
-	if err := json.Unmarshal(productData, &product); err != nil {
-		return err
+// unmarshallProduct rejects fields the product type does not have, so misspelled attributes are not silently dropped.
+func unmarshallProduct(data any, product IProduct) (IProduct, error) {
+	productData, _ := json.Marshal(data)
+	decoder := json.NewDecoder(bytes.NewReader(productData))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(product); err != nil {
+		return nil, err
 	}
-	ipr.ParsedProduct = product
-	return nil
+	return product, nil
 }
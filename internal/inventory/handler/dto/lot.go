@@ -0,0 +1,10 @@
+package dto
+
+import "time"
+
+type Lot struct {
+	LotNumber      string `json:"lotNumber"`
+	ExpirationDate Date   `json:"expirationDate"`
+	// ReceivedAt defaults to the time of the insert
+	ReceivedAt *time.Time `json:"receivedAt,omitempty"`
+}
@@ -0,0 +1,10 @@
+package dto
+
+import "time"
+
+type LotQuantity struct {
+	LotNumber      string    `json:"lotNumber"`
+	ExpirationDate Date      `json:"expirationDate"`
+	ReceivedAt     time.Time `json:"receivedAt"`
+	Quantity       int       `json:"quantity"`
+}
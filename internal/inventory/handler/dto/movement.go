@@ -0,0 +1,21 @@
+package dto
+
+import "time"
+
+type Movement struct {
+	ID            int64     `json:"id"`
+	Timestamp     time.Time `json:"timestamp"`
+	WarehouseName string    `json:"warehouseName"`
+	Sku           string    `json:"sku"`
+	Delta         int       `json:"delta"`
+	Reason        string    `json:"reason"`
+	CorrelationID string    `json:"correlationId,omitempty"`
+	Actor         string    `json:"actor,omitempty"`
+}
+
+type MovementFilter struct {
+	Sku           string
+	WarehouseName string
+	From          time.Time
+	To            time.Time
+}
@@ -0,0 +1,70 @@
+package dto
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+)
+
+// Period is an ISO 8601 duration of whole days such as P2Y or P1Y6M, weeks are counted as days.
+type Period struct {
+	Years  int
+	Months int
+	Days   int
+}
+
+var periodPattern = regexp.MustCompile(`^P(?:(\d+)Y)?(?:(\d+)M)?(?:(\d+)W)?(?:(\d+)D)?$`)
+
+func ParsePeriod(value string) (Period, error) {
+	match := periodPattern.FindStringSubmatch(value)
+	if match == nil || value == "P" {
+		return Period{}, fmt.Errorf("%q is not an ISO 8601 duration like P2Y or P1Y6M", value)
+	}
+	var units [4]int
+	for i, number := range match[1:] {
+		if number == "" {
+			continue
+		}
+		parsed, err := strconv.Atoi(number)
+		if err != nil {
+			return Period{}, fmt.Errorf("%q is not an ISO 8601 duration: %w", value, err)
+		}
+		units[i] = parsed
+	}
+	return Period{Years: units[0], Months: units[1], Days: units[2]*7 + units[3]}, nil
+}
+
+func (p Period) String() string {
+	if p == (Period{}) {
+		return "P0D"
+	}
+	result := "P"
+	if p.Years != 0 {
+		result += strconv.Itoa(p.Years) + "Y"
+	}
+	if p.Months != 0 {
+		result += strconv.Itoa(p.Months) + "M"
+	}
+	if p.Days != 0 {
+		result += strconv.Itoa(p.Days) + "D"
+	}
+	return result
+}
+
+func (p Period) MarshalJSON() ([]byte, error) {
+	return json.Marshal(p.String())
+}
+
+func (p *Period) UnmarshalJSON(data []byte) error {
+	var value string
+	if err := json.Unmarshal(data, &value); err != nil {
+		return fmt.Errorf("duration must be a string: %w", err)
+	}
+	parsed, err := ParsePeriod(value)
+	if err != nil {
+		return err
+	}
+	*p = parsed
+	return nil
+}
@@ -0,0 +1,14 @@
+package dto
+
+type Plan struct {
+	Quantity int `json:"quantity"`
+	// Shortfall is the quantity that could not be placed or picked, the operation fails unless it is 0
+	Shortfall  int               `json:"shortfall"`
+	Warehouses []PlannedQuantity `json:"warehouses"`
+}
+
+type PlannedQuantity struct {
+	WarehouseName     string `json:"warehouseName"`
+	Quantity          int    `json:"quantity"`
+	RemainingCapacity int    `json:"remainingCapacity"`
+}
@@ -2,8 +2,9 @@ package dto
 
 type ProductType string
 
-const (
-	Book        ProductType = "Book"
-	Consumable  ProductType = "Consumable"
-	Electronics ProductType = "Electronics"
-)
+var productTypes = map[ProductType]func() IProduct{}
+
+// RegisterProductType is called by producttype.Register, new types are registered there and not here.
+func RegisterProductType(productType ProductType, newProduct func() IProduct) {
+	productTypes[productType] = newProduct
+}
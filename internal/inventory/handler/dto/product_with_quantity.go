@@ -2,5 +2,6 @@ package dto
 
 type ProductWithQuantity struct {
 	IProduct `json:",inline"`
-	Quantity int `json:"quantity"`
+	Quantity int           `json:"quantity"`
+	Lots     []LotQuantity `json:"lots,omitempty"`
 }
@@ -0,0 +1,7 @@
+package dto
+
+type PruneResult struct {
+	Products          int `json:"products"`
+	Brands            int `json:"brands"`
+	ProductAttributes int `json:"productAttributes"`
+}
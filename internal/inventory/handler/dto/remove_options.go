@@ -0,0 +1,7 @@
+package dto
+
+type RemoveOptions struct {
+	PickingStrategy string
+	// Serials removes exactly these units instead of letting the picking strategy choose
+	Serials []string
+}
@@ -1,7 +1,9 @@
 package dto
 
 type RemoveProductsRequest struct {
-	WarehouseName string `json:"warehouseName"`
-	Sku           string `json:"sku"`
-	Quantity      int    `json:"quantity"`
+	WarehouseName   string   `json:"warehouseName"`
+	Sku             string   `json:"sku"`
+	Quantity        int      `json:"quantity"`
+	PickingStrategy string   `json:"pickingStrategy,omitempty"`
+	Serials         []string `json:"serials,omitempty"`
 }
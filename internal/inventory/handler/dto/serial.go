@@ -0,0 +1,22 @@
+package dto
+
+import "time"
+
+// Serial is one electronics unit, WarrantyEndsOn is left out when the product is no longer in the catalog.
+type Serial struct {
+	Serial         string        `json:"serial"`
+	Sku            string        `json:"sku"`
+	WarehouseName  string        `json:"warehouseName,omitempty"`
+	InStock        bool          `json:"inStock"`
+	ReceivedAt     time.Time     `json:"receivedAt"`
+	WarrantyEndsOn *Date         `json:"warrantyEndsOn,omitempty"`
+	History        []SerialEvent `json:"history"`
+}
+
+type SerialEvent struct {
+	Timestamp     time.Time `json:"timestamp"`
+	WarehouseName string    `json:"warehouseName"`
+	Reason        string    `json:"reason"`
+	CorrelationID string    `json:"correlationId,omitempty"`
+	Actor         string    `json:"actor,omitempty"`
+}
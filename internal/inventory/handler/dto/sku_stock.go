@@ -0,0 +1,12 @@
+package dto
+
+type SkuStock struct {
+	Sku        string              `json:"sku"`
+	Total      int                 `json:"total"`
+	Warehouses []WarehouseQuantity `json:"warehouses"`
+}
+
+type WarehouseQuantity struct {
+	WarehouseName string `json:"warehouseName"`
+	Quantity      int    `json:"quantity"`
+}
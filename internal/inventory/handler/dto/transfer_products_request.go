@@ -0,0 +1,8 @@
+package dto
+
+type TransferProductsRequest struct {
+	From     string `json:"from"`
+	To       string `json:"to"`
+	Sku      string `json:"sku"`
+	Quantity int    `json:"quantity"`
+}
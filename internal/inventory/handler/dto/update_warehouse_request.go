@@ -0,0 +1,10 @@
+package dto
+
+// UpdateWarehouseRequest only changes the fields that are present, Relocate allows moving stock out when the capacity shrinks below usage.
+type UpdateWarehouseRequest struct {
+	Name            *string `json:"name,omitempty"`
+	Address         *string `json:"address,omitempty"`
+	Capacity        *int    `json:"capacity,omitempty"`
+	MinBrandQuality *int    `json:"minBrandQuality,omitempty"`
+	Relocate        bool    `json:"relocate,omitempty"`
+}
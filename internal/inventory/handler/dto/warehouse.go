@@ -4,4 +4,6 @@ type Warehouse struct {
 	Name     string `json:"name"`
 	Address  string `json:"address"`
 	Capacity int    `json:"capacity"`
+	// MinBrandQuality keeps stock of lower quality brands out, 0 accepts every brand.
+	MinBrandQuality int `json:"minBrandQuality"`
 }
@@ -0,0 +1,62 @@
+package rest
+
+import (
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/kijevigombooc/inventory-manager/internal/inventory/service"
+	"github.com/kijevigombooc/inventory-manager/internal/requestctx"
+)
+
+const (
+	codeInvalidBody      = "invalid_body"
+	codeInvalidProduct   = "invalid_product"
+	codeInvalidQuery     = "invalid_query"
+	codeValidationFailed = "validation_failed"
+	codeInternalError    = "internal_error"
+)
+
+const internalErrorDetail = "The request failed on the server, report the request ID to find it in the server log"
+
+var serviceErrors = []struct {
+	err        error
+	statusCode int
+	code       string
+	title      string
+}{
+	{service.ErrUnknownAllocationStrategy, http.StatusBadRequest, "unknown_allocation_strategy", "Unknown allocation strategy"},
+	{service.ErrUnknownPickingStrategy, http.StatusBadRequest, "unknown_picking_strategy", "Unknown picking strategy"},
+	{service.ErrWarehouseNotFound, http.StatusNotFound, "warehouse_not_found", "Warehouse not found"},
+	{service.ErrDuplicateWarehouse, http.StatusConflict, "duplicate_warehouse", "Warehouse already exists"},
+	{service.ErrWarehouseNotEmpty, http.StatusConflict, "warehouse_not_empty", "Warehouse still holds products"},
+	{service.ErrCapacityBelowUsage, http.StatusConflict, "capacity_below_usage", "Capacity is below the used capacity"},
+	{service.ErrSkuTypeConflict, http.StatusConflict, "sku_type_conflict", "SKU exists with a different product type"},
+	{service.ErrProductNotFound, http.StatusNotFound, "product_not_found", "Product not found"},
+	{service.ErrDuplicateProduct, http.StatusConflict, "duplicate_product", "Product already exists"},
+	{service.ErrProductMismatch, http.StatusConflict, "product_mismatch", "Product does not match the catalog"},
+	{service.ErrProductInStock, http.StatusConflict, "product_in_stock", "Product is still in stock"},
+	{service.ErrBrandNotFound, http.StatusNotFound, "brand_not_found", "Brand not found"},
+	{service.ErrDuplicateBrand, http.StatusConflict, "duplicate_brand", "Brand already exists"},
+	{service.ErrBrandMismatch, http.StatusConflict, "brand_mismatch", "Brand quality does not match the stored brand"},
+	{service.ErrBrandInUse, http.StatusConflict, "brand_in_use", "Brand still has products"},
+	{service.ErrBrandQualityTooLow, http.StatusUnprocessableEntity, "brand_quality_too_low", "Brand quality is below the warehouse minimum"},
+	{service.ErrInvalidLot, http.StatusUnprocessableEntity, "invalid_lot", "Invalid lot"},
+	{service.ErrLotMismatch, http.StatusConflict, "lot_mismatch", "Lot does not match the stored lot"},
+	{service.ErrInvalidSerial, http.StatusUnprocessableEntity, "invalid_serial", "Invalid serial numbers"},
+	{service.ErrDuplicateSerial, http.StatusConflict, "duplicate_serial", "Serial number is already in use"},
+	{service.ErrSerialNotFound, http.StatusNotFound, "serial_not_found", "Serial number not found"},
+	{service.ErrInsufficientCapacity, http.StatusUnprocessableEntity, "insufficient_capacity", "Not enough capacity in warehouses"},
+	{service.ErrInsufficientStock, http.StatusUnprocessableEntity, "insufficient_stock", "Not enough product in warehouses"},
+}
+
+func writeServiceError(w http.ResponseWriter, r *http.Request, err error) {
+	for _, serviceError := range serviceErrors {
+		if errors.Is(err, serviceError.err) {
+			writeProblem(w, newProblem(r, serviceError.statusCode, serviceError.code, serviceError.title, err.Error()))
+			return
+		}
+	}
+	log.Printf("error serving %s %s (request %s): %v", r.Method, r.URL.Path, requestctx.RequestID(r.Context()), err)
+	writeProblem(w, newProblem(r, http.StatusInternalServerError, codeInternalError, http.StatusText(http.StatusInternalServerError), internalErrorDetail))
+}
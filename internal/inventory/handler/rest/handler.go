@@ -2,9 +2,13 @@ package rest
 
 import (
 	"encoding/json"
+	"fmt"
 	"net/http"
+	"strconv"
+	"time"
 
 	"github.com/kijevigombooc/inventory-manager/internal/inventory/handler/dto"
+	"github.com/kijevigombooc/inventory-manager/internal/inventory/handler/validation"
 	"github.com/kijevigombooc/inventory-manager/internal/inventory/service"
 )
 
@@ -19,14 +23,45 @@ type inventoryHandler struct {
 func (h *inventoryHandler) RegisterRoutes(serveMux *http.ServeMux) {
 	serveMux.HandleFunc("GET /warehouses", h.getWarehouses)
 	serveMux.HandleFunc("POST /warehouses", h.createWarehouse)
+	serveMux.HandleFunc("GET /warehouses/{name}", h.getWarehouse)
+	serveMux.HandleFunc("PATCH /warehouses/{name}", h.updateWarehouse)
+	serveMux.HandleFunc("DELETE /warehouses/{name}", h.deleteWarehouse)
+	serveMux.HandleFunc("DELETE /warehouses/{name}/quarantine", h.discardQuarantined)
 	serveMux.HandleFunc("POST /insertProducts", h.insertProducts)
 	serveMux.HandleFunc("POST /removeProducts", h.removeProducts)
+	serveMux.HandleFunc("POST /plans/insert", h.planInsert)
+	serveMux.HandleFunc("POST /plans/remove", h.planRemove)
+	serveMux.HandleFunc("POST /transfers", h.transferProducts)
+	serveMux.HandleFunc("GET /movements", h.getMovements)
+	serveMux.HandleFunc("GET /stock/{sku}", h.getStock)
+	serveMux.HandleFunc("GET /reports/expiring", h.getExpiringStock)
+	serveMux.HandleFunc("GET /serials/{serial}", h.getSerial)
+	serveMux.HandleFunc("GET /products", h.getProducts)
+	serveMux.HandleFunc("POST /products", h.createProduct)
+	serveMux.HandleFunc("GET /products/{sku}", h.getProduct)
+	serveMux.HandleFunc("PUT /products/{sku}", h.updateProduct)
+	serveMux.HandleFunc("DELETE /products/{sku}", h.deleteProduct)
+	serveMux.HandleFunc("GET /brands", h.getBrands)
+	serveMux.HandleFunc("POST /brands", h.createBrand)
+	serveMux.HandleFunc("GET /brands/{name}", h.getBrand)
+	serveMux.HandleFunc("PUT /brands/{name}", h.updateBrand)
+	serveMux.HandleFunc("DELETE /brands/{name}", h.deleteBrand)
 }
 
 func (h *inventoryHandler) getWarehouses(w http.ResponseWriter, r *http.Request) {
-	warehouses, err := h.service.GetWarehouses()
+	asOf, err := parseTimeQuery(r, "asOf")
 	if err != nil {
-		writeErrorMessageJSON(w, err.Error(), http.StatusInternalServerError)
+		writeBadRequest(w, r, codeInvalidQuery, err)
+		return
+	}
+	var warehouses []dto.WarehouseDetail
+	if asOf.IsZero() {
+		warehouses, err = h.service.GetWarehouses(r.Context())
+	} else {
+		warehouses, err = h.service.GetWarehousesAsOf(r.Context(), asOf)
+	}
+	if err != nil {
+		writeServiceError(w, r, err)
 		return
 	}
 	writeJSON(w, warehouses, http.StatusOK)
@@ -34,32 +69,87 @@ func (h *inventoryHandler) getWarehouses(w http.ResponseWriter, r *http.Request)
 
 func (h *inventoryHandler) createWarehouse(w http.ResponseWriter, r *http.Request) {
 	warehouse := dto.Warehouse{}
-	if err := json.NewDecoder(r.Body).Decode(&warehouse); err != nil {
-		writeErrorMessageJSON(w, err.Error(), http.StatusBadRequest)
+	if err := decodeJSON(r, &warehouse); err != nil {
+		writeBadRequest(w, r, codeInvalidBody, err)
+		return
+	}
+	if err := validation.ValidateWarehouse(warehouse); err != nil {
+		writeValidationError(w, r, err)
 		return
 	}
-	if err := h.service.CreateWarehouse(warehouse); err != nil {
-		// TODO: return different error message if warehouse already exists
-		writeErrorMessageJSON(w, err.Error(), http.StatusInternalServerError)
+	if err := h.service.CreateWarehouse(r.Context(), warehouse); err != nil {
+		writeServiceError(w, r, err)
 		return
 	}
 	writeJSON(w, warehouse, http.StatusCreated)
 }
 
+func (h *inventoryHandler) getWarehouse(w http.ResponseWriter, r *http.Request) {
+	warehouse, err := h.service.GetWarehouse(r.Context(), r.PathValue("name"))
+	if err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+	writeJSON(w, warehouse, http.StatusOK)
+}
+
+func (h *inventoryHandler) updateWarehouse(w http.ResponseWriter, r *http.Request) {
+	var req dto.UpdateWarehouseRequest
+	if err := decodeJSON(r, &req); err != nil {
+		writeBadRequest(w, r, codeInvalidBody, err)
+		return
+	}
+	if err := validation.ValidateUpdateWarehouseRequest(req); err != nil {
+		writeValidationError(w, r, err)
+		return
+	}
+	warehouse, err := h.service.UpdateWarehouse(r.Context(), r.PathValue("name"), req)
+	if err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+	writeJSON(w, warehouse, http.StatusOK)
+}
+
+func (h *inventoryHandler) deleteWarehouse(w http.ResponseWriter, r *http.Request) {
+	relocate, err := parseBoolQuery(r, "relocate")
+	if err != nil {
+		writeBadRequest(w, r, codeInvalidQuery, err)
+		return
+	}
+	if err := h.service.DeleteWarehouse(r.Context(), r.PathValue("name"), relocate); err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *inventoryHandler) discardQuarantined(w http.ResponseWriter, r *http.Request) {
+	if err := h.service.DiscardQuarantined(r.Context(), r.PathValue("name")); err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
 func (h *inventoryHandler) insertProducts(w http.ResponseWriter, r *http.Request) {
 	var req dto.InsertProductsRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		writeErrorMessageJSON(w, err.Error(), http.StatusBadRequest)
+	if err := decodeJSON(r, &req); err != nil {
+		writeBadRequest(w, r, codeInvalidBody, err)
 		return
 	}
 
 	if err := req.ParseProduct(); err != nil {
-		writeErrorMessageJSON(w, err.Error(), http.StatusBadRequest)
+		writeBadRequest(w, r, codeInvalidProduct, err)
+		return
+	}
+	if err := validation.ValidateInsertProductsRequest(req); err != nil {
+		writeValidationError(w, r, err)
 		return
 	}
 
-	if err := h.service.InsertProducts(req.WarehouseName, req.ParsedProduct, req.Quantity); err != nil {
-		writeErrorMessageJSON(w, err.Error(), http.StatusInternalServerError)
+	if err := h.service.InsertProducts(r.Context(), req.WarehouseName, req.ParsedProduct, req.Quantity, dto.InsertOptions{AllocationStrategy: req.AllocationStrategy, Lot: req.Lot, Serials: req.Serials}); err != nil {
+		writeServiceError(w, r, err)
 		return
 	}
 	writeJSON(w, req, http.StatusOK)
@@ -67,21 +157,325 @@ func (h *inventoryHandler) insertProducts(w http.ResponseWriter, r *http.Request
 
 func (h *inventoryHandler) removeProducts(w http.ResponseWriter, r *http.Request) {
 	var req dto.RemoveProductsRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		writeErrorMessageJSON(w, err.Error(), http.StatusBadRequest)
+	if err := decodeJSON(r, &req); err != nil {
+		writeBadRequest(w, r, codeInvalidBody, err)
+		return
+	}
+	if err := validation.ValidateRemoveProductsRequest(req); err != nil {
+		writeValidationError(w, r, err)
 		return
 	}
-	if err := h.service.RemoveProducts(req.WarehouseName, req.Sku, req.Quantity); err != nil {
-		writeErrorMessageJSON(w, err.Error(), http.StatusInternalServerError)
+	if err := h.service.RemoveProducts(r.Context(), req.WarehouseName, req.Sku, req.Quantity, dto.RemoveOptions{PickingStrategy: req.PickingStrategy, Serials: req.Serials}); err != nil {
+		writeServiceError(w, r, err)
 		return
 	}
 	writeJSON(w, req, http.StatusOK)
 }
 
-func writeErrorMessageJSON(w http.ResponseWriter, message string, statusCode int) {
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(statusCode)
-	w.Write([]byte(`{"error": "` + message + `"}`))
+func (h *inventoryHandler) planInsert(w http.ResponseWriter, r *http.Request) {
+	var req dto.InsertProductsRequest
+	if err := decodeJSON(r, &req); err != nil {
+		writeBadRequest(w, r, codeInvalidBody, err)
+		return
+	}
+	if err := req.ParseProduct(); err != nil {
+		writeBadRequest(w, r, codeInvalidProduct, err)
+		return
+	}
+	if err := validation.ValidateInsertProductsRequest(req); err != nil {
+		writeValidationError(w, r, err)
+		return
+	}
+	plan, err := h.service.PlanInsert(r.Context(), req.WarehouseName, req.ParsedProduct, req.Quantity, dto.InsertOptions{AllocationStrategy: req.AllocationStrategy, Lot: req.Lot, Serials: req.Serials})
+	if err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+	writeJSON(w, plan, http.StatusOK)
+}
+
+func (h *inventoryHandler) planRemove(w http.ResponseWriter, r *http.Request) {
+	var req dto.RemoveProductsRequest
+	if err := decodeJSON(r, &req); err != nil {
+		writeBadRequest(w, r, codeInvalidBody, err)
+		return
+	}
+	if err := validation.ValidateRemoveProductsRequest(req); err != nil {
+		writeValidationError(w, r, err)
+		return
+	}
+	plan, err := h.service.PlanRemove(r.Context(), req.WarehouseName, req.Sku, req.Quantity, dto.RemoveOptions{PickingStrategy: req.PickingStrategy, Serials: req.Serials})
+	if err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+	writeJSON(w, plan, http.StatusOK)
+}
+
+func (h *inventoryHandler) transferProducts(w http.ResponseWriter, r *http.Request) {
+	var req dto.TransferProductsRequest
+	if err := decodeJSON(r, &req); err != nil {
+		writeBadRequest(w, r, codeInvalidBody, err)
+		return
+	}
+	if err := validation.ValidateTransferProductsRequest(req); err != nil {
+		writeValidationError(w, r, err)
+		return
+	}
+	if err := h.service.TransferProducts(r.Context(), req.From, req.To, req.Sku, req.Quantity); err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+	writeJSON(w, req, http.StatusOK)
+}
+
+func (h *inventoryHandler) getMovements(w http.ResponseWriter, r *http.Request) {
+	from, err := parseTimeQuery(r, "from")
+	if err != nil {
+		writeBadRequest(w, r, codeInvalidQuery, err)
+		return
+	}
+	to, err := parseTimeQuery(r, "to")
+	if err != nil {
+		writeBadRequest(w, r, codeInvalidQuery, err)
+		return
+	}
+	filter := dto.MovementFilter{
+		Sku:           r.URL.Query().Get("sku"),
+		WarehouseName: r.URL.Query().Get("warehouse"),
+		From:          from,
+		To:            to,
+	}
+	movements, err := h.service.GetMovements(r.Context(), filter)
+	if err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+	writeJSON(w, movements, http.StatusOK)
+}
+
+func (h *inventoryHandler) getStock(w http.ResponseWriter, r *http.Request) {
+	asOf, err := parseTimeQuery(r, "asOf")
+	if err != nil {
+		writeBadRequest(w, r, codeInvalidQuery, err)
+		return
+	}
+	stock, err := h.service.GetStock(r.Context(), r.PathValue("sku"), asOf)
+	if err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+	writeJSON(w, stock, http.StatusOK)
+}
+
+const defaultExpiringDays = 30
+
+func (h *inventoryHandler) getExpiringStock(w http.ResponseWriter, r *http.Request) {
+	days, err := parseDaysQuery(r, "days", defaultExpiringDays)
+	if err != nil {
+		writeBadRequest(w, r, codeInvalidQuery, err)
+		return
+	}
+	stock, err := h.service.GetExpiringStock(r.Context(), days)
+	if err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+	writeJSON(w, stock, http.StatusOK)
+}
+
+func (h *inventoryHandler) getSerial(w http.ResponseWriter, r *http.Request) {
+	serial, err := h.service.GetSerial(r.Context(), r.PathValue("serial"))
+	if err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+	writeJSON(w, serial, http.StatusOK)
+}
+
+func (h *inventoryHandler) getProducts(w http.ResponseWriter, r *http.Request) {
+	products, err := h.service.GetProducts(r.Context())
+	if err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+	writeJSON(w, products, http.StatusOK)
+}
+
+func (h *inventoryHandler) createProduct(w http.ResponseWriter, r *http.Request) {
+	product, ok := decodeProduct(w, r)
+	if !ok {
+		return
+	}
+	if err := validation.ValidateProduct(product); err != nil {
+		writeValidationError(w, r, err)
+		return
+	}
+	if err := h.service.CreateProduct(r.Context(), product); err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+	writeJSON(w, product, http.StatusCreated)
+}
+
+func (h *inventoryHandler) getProduct(w http.ResponseWriter, r *http.Request) {
+	product, err := h.service.GetProduct(r.Context(), r.PathValue("sku"))
+	if err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+	writeJSON(w, product, http.StatusOK)
+}
+
+func (h *inventoryHandler) updateProduct(w http.ResponseWriter, r *http.Request) {
+	product, ok := decodeProduct(w, r)
+	if !ok {
+		return
+	}
+	sku := r.PathValue("sku")
+	// the SKU may be left out of the body, the path already names the product
+	if baseProduct := product.GetBaseProduct(); baseProduct.SKU == "" {
+		baseProduct.SKU = sku
+		product.SetBaseProduct(baseProduct)
+	}
+	if err := validation.ValidateUpdateProductRequest(sku, product); err != nil {
+		writeValidationError(w, r, err)
+		return
+	}
+	if err := h.service.UpdateProduct(r.Context(), product); err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+	writeJSON(w, product, http.StatusOK)
+}
+
+func (h *inventoryHandler) deleteProduct(w http.ResponseWriter, r *http.Request) {
+	if err := h.service.DeleteProduct(r.Context(), r.PathValue("sku")); err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *inventoryHandler) getBrands(w http.ResponseWriter, r *http.Request) {
+	brands, err := h.service.GetBrands(r.Context())
+	if err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+	writeJSON(w, brands, http.StatusOK)
+}
+
+func (h *inventoryHandler) createBrand(w http.ResponseWriter, r *http.Request) {
+	var brand dto.Brand
+	if err := decodeJSON(r, &brand); err != nil {
+		writeBadRequest(w, r, codeInvalidBody, err)
+		return
+	}
+	if err := validation.ValidateBrand(brand); err != nil {
+		writeValidationError(w, r, err)
+		return
+	}
+	if err := h.service.CreateBrand(r.Context(), brand); err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+	writeJSON(w, brand, http.StatusCreated)
+}
+
+func (h *inventoryHandler) getBrand(w http.ResponseWriter, r *http.Request) {
+	brand, err := h.service.GetBrand(r.Context(), r.PathValue("name"))
+	if err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+	writeJSON(w, brand, http.StatusOK)
+}
+
+func (h *inventoryHandler) updateBrand(w http.ResponseWriter, r *http.Request) {
+	var brand dto.Brand
+	if err := decodeJSON(r, &brand); err != nil {
+		writeBadRequest(w, r, codeInvalidBody, err)
+		return
+	}
+	name := r.PathValue("name")
+	if brand.Name == "" {
+		brand.Name = name
+	}
+	if err := validation.ValidateUpdateBrandRequest(name, brand); err != nil {
+		writeValidationError(w, r, err)
+		return
+	}
+	if err := h.service.UpdateBrand(r.Context(), brand); err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+	writeJSON(w, brand, http.StatusOK)
+}
+
+func (h *inventoryHandler) deleteBrand(w http.ResponseWriter, r *http.Request) {
+	if err := h.service.DeleteBrand(r.Context(), r.PathValue("name")); err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// decodeJSON rejects unknown fields, so misspelled fields fail instead of being silently dropped.
+func decodeJSON(r *http.Request, v any) error {
+	decoder := json.NewDecoder(r.Body)
+	decoder.DisallowUnknownFields()
+	return decoder.Decode(v)
+}
+
+func decodeProduct(w http.ResponseWriter, r *http.Request) (dto.IProduct, bool) {
+	var body any
+	if err := decodeJSON(r, &body); err != nil {
+		writeBadRequest(w, r, codeInvalidBody, err)
+		return nil, false
+	}
+	product, err := dto.ParseProduct(body)
+	if err != nil {
+		writeBadRequest(w, r, codeInvalidProduct, err)
+		return nil, false
+	}
+	return product, true
+}
+
+func parseBoolQuery(r *http.Request, name string) (bool, error) {
+	value := r.URL.Query().Get(name)
+	if value == "" {
+		return false, nil
+	}
+	parsed, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, fmt.Errorf("query parameter %s must be a boolean, got %q", name, value)
+	}
+	return parsed, nil
+}
+
+func parseDaysQuery(r *http.Request, name string, defaultDays int) (int, error) {
+	value := r.URL.Query().Get(name)
+	if value == "" {
+		return defaultDays, nil
+	}
+	parsed, err := strconv.Atoi(value)
+	if err != nil || parsed < 0 {
+		return 0, fmt.Errorf("query parameter %s must be a non-negative number of days, got %q", name, value)
+	}
+	return parsed, nil
+}
+
+func parseTimeQuery(r *http.Request, name string) (time.Time, error) {
+	value := r.URL.Query().Get(name)
+	if value == "" {
+		return time.Time{}, nil
+	}
+	parsed, err := time.Parse(time.RFC3339Nano, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("query parameter %s must be an RFC 3339 timestamp, got %q", name, value)
+	}
+	return parsed, nil
 }
 
 func writeJSON(w http.ResponseWriter, data interface{}, statusCode int) error {
@@ -0,0 +1,143 @@
+package rest
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/kijevigombooc/inventory-manager/internal/inventory/service"
+	"github.com/kijevigombooc/inventory-manager/internal/inventory/store/memory"
+)
+
+func newTestServer() http.Handler {
+	mux := http.NewServeMux()
+	NewInventoryHandler(service.NewInventoryService(memory.NewInventoryStore())).RegisterRoutes(mux)
+	return WithMiddleware(mux)
+}
+
+func doRequest(handler http.Handler, method string, target string, body string, headers ...string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(method, target, strings.NewReader(body))
+	for i := 0; i+1 < len(headers); i += 2 {
+		r.Header.Set(headers[i], headers[i+1])
+	}
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	return w
+}
+
+func decodeProblem(t *testing.T, w *httptest.ResponseRecorder) problem {
+	t.Helper()
+	if contentType := w.Header().Get("Content-Type"); contentType != problemContentType {
+		t.Fatalf("Content type should be %s, got %s", problemContentType, contentType)
+	}
+	var p problem
+	if err := json.Unmarshal(w.Body.Bytes(), &p); err != nil {
+		t.Fatalf("Error response should be valid JSON: %v\n%s", err, w.Body.String())
+	}
+	return p
+}
+
+func TestProblemEscapesDetail(t *testing.T) {
+	handler := newTestServer()
+	body := `{"name": "Quoted \"warehouse\"", "address": "Address", "capacity": 1}`
+	if w := doRequest(handler, "POST", "/warehouses", body); w.Code != http.StatusCreated {
+		t.Fatalf("Status should be %d, got %d", http.StatusCreated, w.Code)
+	}
+	w := doRequest(handler, "POST", "/warehouses", body, requestIDHeader, "request-1")
+	p := decodeProblem(t, w)
+	if p.Status != http.StatusConflict || p.Code != "duplicate_warehouse" {
+		t.Fatalf("Problem should be a duplicate warehouse conflict, got %+v", p)
+	}
+	if !strings.Contains(p.Detail, `Quoted "warehouse"`) {
+		t.Fatalf("Problem detail should contain the warehouse name, got %s", p.Detail)
+	}
+	if p.RequestID != "request-1" || p.Instance != "/warehouses" {
+		t.Fatalf("Problem should identify the request, got %+v", p)
+	}
+}
+
+func TestProblemHidesInternalErrors(t *testing.T) {
+	w := httptest.NewRecorder()
+	writeServiceError(w, httptest.NewRequest("GET", "/warehouses", nil), errors.New("pq: relation \"warehouses\" does not exist"))
+	if p := decodeProblem(t, w); p.Status != http.StatusInternalServerError || p.Detail != internalErrorDetail {
+		t.Fatalf("Problem should hide the internal error, got %+v", p)
+	}
+}
+
+func TestProblemForUnknownRoute(t *testing.T) {
+	w := doRequest(newTestServer(), "GET", "/unknown", "")
+	if p := decodeProblem(t, w); p.Status != http.StatusNotFound || p.RequestID == "" {
+		t.Fatalf("Problem should be a not found with a request id, got %+v", p)
+	}
+	if nosniff := w.Header().Get("X-Content-Type-Options"); nosniff != "nosniff" {
+		t.Fatalf("Problem should keep X-Content-Type-Options: nosniff, got %q", nosniff)
+	}
+}
+
+func TestProblemForInvalidBody(t *testing.T) {
+	w := doRequest(newTestServer(), "POST", "/removeProducts", `{"sku": "a"`)
+	if p := decodeProblem(t, w); p.Status != http.StatusBadRequest || p.Code != codeInvalidBody {
+		t.Fatalf("Problem should be an invalid body, got %+v", p)
+	}
+}
+
+func TestProblemForUnknownOrMistypedFields(t *testing.T) {
+	tests := []struct {
+		path string
+		body string
+		code string
+	}{
+		{"/warehouses", `{"name": "A", "capacity": 5, "capacty": 6}`, codeInvalidBody},
+		{"/products", `{"type": "Electronics", "sku": "E", "name": "Phone", "brand": {"name": "Brand", "quality": 3}, "warranty": "P2Y"}`, codeInvalidProduct},
+		{"/products", `{"type": "Electronics", "sku": "E", "name": "Phone", "brand": {"name": "Brand", "quality": 3}, "warrantyPeriod": "2 years"}`, codeInvalidProduct},
+		{"/products", `{"type": "Consumable", "sku": "C", "name": "Milk", "brand": {"name": "Brand", "quality": 3}, "expirationDate": "2024.12.12"}`, codeInvalidProduct},
+	}
+	for _, test := range tests {
+		p := decodeProblem(t, doRequest(newTestServer(), "POST", test.path, test.body))
+		if p.Status != http.StatusBadRequest || p.Code != test.code {
+			t.Fatalf("Problem for %s should be %s, got %+v", test.body, test.code, p)
+		}
+	}
+}
+
+func TestProblemListsAllValidationErrors(t *testing.T) {
+	body := `{"warehouseName": "", "quantity": 0, "product": {"type": "Book", "sku": "", "name": "Book", "price": -1, "brand": {"name": "Brand", "quality": 9}}}`
+	p := decodeProblem(t, doRequest(newTestServer(), "POST", "/insertProducts", body))
+	if p.Status != http.StatusBadRequest || p.Code != codeValidationFailed {
+		t.Fatalf("Problem should be a validation failure, got %+v", p)
+	}
+	fields := []string{}
+	for _, fieldError := range p.Errors {
+		fields = append(fields, fieldError.Field)
+	}
+	expected := []string{"warehouseName", "quantity", "product.sku", "product.price", "product.brand.quality"}
+	if !reflect.DeepEqual(fields, expected) {
+		t.Fatalf("Invalid fields should be %v, got %v", expected, fields)
+	}
+}
+
+func TestMovementsRecordRequestIDAndActor(t *testing.T) {
+	handler := newTestServer()
+	if w := doRequest(handler, "POST", "/warehouses", `{"name": "A", "address": "Address", "capacity": 5}`); w.Code != http.StatusCreated {
+		t.Fatalf("Status should be %d, got %d", http.StatusCreated, w.Code)
+	}
+	body := `{"warehouseName": "A", "quantity": 2, "product": {"type": "Book", "sku": "BOOK-A", "name": "Book", "price": 1, "brand": {"name": "Brand", "quality": 3}, "author": "Author"}}`
+	if w := doRequest(handler, "POST", "/insertProducts", body, requestIDHeader, "request-1", actorHeader, "alice"); w.Code != http.StatusOK {
+		t.Fatalf("Status should be %d, got %d", http.StatusOK, w.Code)
+	}
+	w := doRequest(handler, "GET", "/movements?sku=BOOK-A&warehouse=A", "")
+	var movements []map[string]any
+	if err := json.Unmarshal(w.Body.Bytes(), &movements); err != nil {
+		t.Fatalf("Error decoding movements: %v\n%s", err, w.Body.String())
+	}
+	if len(movements) != 1 || movements[0]["correlationId"] != "request-1" || movements[0]["actor"] != "alice" || movements[0]["delta"] != 2.0 {
+		t.Fatalf("Movement should carry the request id and actor, got %v", movements)
+	}
+	if p := decodeProblem(t, doRequest(handler, "GET", "/movements?from=yesterday", "")); p.Status != http.StatusBadRequest || p.Code != codeInvalidQuery {
+		t.Fatalf("Problem should be an invalid query, got %+v", p)
+	}
+}
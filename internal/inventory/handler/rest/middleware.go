@@ -0,0 +1,111 @@
+package rest
+
+import (
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/kijevigombooc/inventory-manager/internal/requestctx"
+)
+
+const (
+	requestIDHeader = "X-Request-ID"
+	actorHeader     = "X-Actor"
+)
+
+func WithMiddleware(next http.Handler) http.Handler {
+	return withRequestID(withActor(withProblemFallback(withRecover(next))))
+}
+
+func withRequestID(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestID := r.Header.Get(requestIDHeader)
+		if !validHeaderToken(requestID) {
+			requestID = requestctx.NewID()
+		}
+		w.Header().Set(requestIDHeader, requestID)
+		next.ServeHTTP(w, r.WithContext(requestctx.WithRequestID(r.Context(), requestID)))
+	})
+}
+
+func withActor(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if actor := r.Header.Get(actorHeader); validHeaderToken(actor) {
+			r = r.WithContext(requestctx.WithActor(r.Context(), actor))
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func withRecover(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		defer func() {
+			p := recover()
+			if p == nil || p == http.ErrAbortHandler {
+				if p != nil {
+					panic(p)
+				}
+				return
+			}
+			log.Printf("panic serving %s %s (request %s): %v", r.Method, r.URL.Path, requestctx.RequestID(r.Context()), p)
+			writeProblem(w, newProblem(r, http.StatusInternalServerError, codeInternalError, http.StatusText(http.StatusInternalServerError), internalErrorDetail))
+		}()
+		next.ServeHTTP(w, r)
+	})
+}
+
+// withProblemFallback turns the plain text errors written by http.Error, e.g. the ServeMux 404 and 405 responses,
+// into problem details so that clients only ever have to parse one error format.
+func withProblemFallback(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		next.ServeHTTP(&problemFallbackWriter{ResponseWriter: w, r: r}, r)
+	})
+}
+
+type problemFallbackWriter struct {
+	http.ResponseWriter
+	r           *http.Request
+	wroteHeader bool
+	replaced    bool
+}
+
+func (w *problemFallbackWriter) WriteHeader(statusCode int) {
+	if w.wroteHeader {
+		return
+	}
+	w.wroteHeader = true
+	if statusCode >= http.StatusBadRequest && strings.HasPrefix(w.Header().Get("Content-Type"), "text/plain") {
+		w.replaced = true
+		title := http.StatusText(statusCode)
+		code := strings.ToLower(strings.ReplaceAll(title, " ", "_"))
+		writeProblem(w.ResponseWriter, newProblem(w.r, statusCode, code, title, ""))
+		return
+	}
+	w.ResponseWriter.WriteHeader(statusCode)
+}
+
+func (w *problemFallbackWriter) Write(body []byte) (int, error) {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	if w.replaced {
+		return len(body), nil
+	}
+	return w.ResponseWriter.Write(body)
+}
+
+func (w *problemFallbackWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+func validHeaderToken(value string) bool {
+	if value == "" || len(value) > 128 {
+		return false
+	}
+	for _, r := range value {
+		if r < '!' || r > '~' {
+			return false
+		}
+	}
+	return true
+}
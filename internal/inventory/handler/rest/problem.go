@@ -0,0 +1,67 @@
+package rest
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/kijevigombooc/inventory-manager/internal/inventory/handler/validation"
+	"github.com/kijevigombooc/inventory-manager/internal/requestctx"
+)
+
+const problemContentType = "application/problem+json"
+
+// problem is an RFC 7807 problem details object, Code repeats the last segment of Type for clients that switch on it.
+type problem struct {
+	Type      string       `json:"type"`
+	Title     string       `json:"title"`
+	Status    int          `json:"status"`
+	Detail    string       `json:"detail,omitempty"`
+	Instance  string       `json:"instance,omitempty"`
+	Code      string       `json:"code"`
+	RequestID string       `json:"requestId,omitempty"`
+	Errors    []fieldError `json:"errors,omitempty"`
+}
+
+type fieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+func newProblem(r *http.Request, statusCode int, code string, title string, detail string) problem {
+	return problem{
+		Type:      "/problems/" + code,
+		Title:     title,
+		Status:    statusCode,
+		Detail:    detail,
+		Instance:  r.URL.Path,
+		Code:      code,
+		RequestID: requestctx.RequestID(r.Context()),
+	}
+}
+
+func writeProblem(w http.ResponseWriter, p problem) {
+	body, err := json.Marshal(p)
+	if err != nil {
+		p.Status = http.StatusInternalServerError
+		body = []byte(`{"type":"about:blank","title":"Internal Server Error","status":500,"code":"internal_error"}`)
+	}
+	w.Header().Set("Content-Type", problemContentType)
+	w.WriteHeader(p.Status)
+	w.Write(body)
+}
+
+func writeBadRequest(w http.ResponseWriter, r *http.Request, code string, err error) {
+	writeProblem(w, newProblem(r, http.StatusBadRequest, code, http.StatusText(http.StatusBadRequest), err.Error()))
+}
+
+func writeValidationError(w http.ResponseWriter, r *http.Request, err error) {
+	p := newProblem(r, http.StatusBadRequest, codeValidationFailed, "Request validation failed", err.Error())
+	var validationErrors validation.Errors
+	if errors.As(err, &validationErrors) {
+		for _, validationError := range validationErrors {
+			p.Errors = append(p.Errors, fieldError{Field: validationError.Field, Message: validationError.Message})
+		}
+	}
+	writeProblem(w, p)
+}
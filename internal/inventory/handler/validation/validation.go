@@ -0,0 +1,181 @@
+package validation
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/kijevigombooc/inventory-manager/internal/inventory/handler/dto"
+	"github.com/kijevigombooc/inventory-manager/internal/inventory/producttype"
+)
+
+const (
+	MinBrandQuality = 1
+	MaxBrandQuality = 5
+)
+
+type FieldError struct {
+	Field   string
+	Message string
+}
+
+type Errors []FieldError
+
+func (e Errors) Error() string {
+	messages := make([]string, len(e))
+	for i, fieldError := range e {
+		messages[i] = fieldError.Field + ": " + fieldError.Message
+	}
+	return "invalid request: " + strings.Join(messages, "; ")
+}
+
+func ValidateWarehouse(warehouse dto.Warehouse) error {
+	v := validator{}
+	v.notBlank("name", warehouse.Name)
+	v.check(warehouse.Capacity >= 0, "capacity", "must not be negative")
+	v.minBrandQuality("minBrandQuality", warehouse.MinBrandQuality)
+	return v.result()
+}
+
+func ValidateUpdateWarehouseRequest(req dto.UpdateWarehouseRequest) error {
+	v := validator{}
+	if req.Name != nil {
+		v.notBlank("name", *req.Name)
+	}
+	if req.Capacity != nil {
+		v.check(*req.Capacity >= 0, "capacity", "must not be negative")
+	}
+	if req.MinBrandQuality != nil {
+		v.minBrandQuality("minBrandQuality", *req.MinBrandQuality)
+	}
+	return v.result()
+}
+
+func ValidateInsertProductsRequest(req dto.InsertProductsRequest) error {
+	v := validator{}
+	v.notBlank("warehouseName", req.WarehouseName)
+	v.check(req.Quantity > 0, "quantity", "must be positive")
+	if req.ParsedProduct != nil {
+		v.product("product", req.ParsedProduct)
+	}
+	if req.Lot != nil {
+		v.lot("lot", *req.Lot)
+		v.check(req.ParsedProduct == nil || producttype.HasLots(req.ParsedProduct.GetType()), "lot", "the product type is not tracked in lots")
+	}
+	if req.Serials != nil {
+		v.serials("serials", req.Serials, req.Quantity)
+		v.check(req.ParsedProduct == nil || producttype.HasSerials(req.ParsedProduct.GetType()), "serials", "the product type has no serial numbers")
+	}
+	return v.result()
+}
+
+func ValidateRemoveProductsRequest(req dto.RemoveProductsRequest) error {
+	v := validator{}
+	v.notBlank("warehouseName", req.WarehouseName)
+	v.notBlank("sku", req.Sku)
+	v.check(req.Quantity > 0, "quantity", "must be positive")
+	if req.Serials != nil {
+		v.serials("serials", req.Serials, req.Quantity)
+	}
+	return v.result()
+}
+
+func ValidateTransferProductsRequest(req dto.TransferProductsRequest) error {
+	v := validator{}
+	v.notBlank("from", req.From)
+	v.notBlank("to", req.To)
+	v.check(req.From != req.To, "to", "must differ from the source warehouse")
+	v.notBlank("sku", req.Sku)
+	v.check(req.Quantity > 0, "quantity", "must be positive")
+	return v.result()
+}
+
+func ValidateProduct(product dto.IProduct) error {
+	v := validator{}
+	v.product("", product)
+	return v.result()
+}
+
+// ValidateUpdateProductRequest also checks that the body describes the product named in the path.
+func ValidateUpdateProductRequest(sku string, product dto.IProduct) error {
+	v := validator{}
+	v.product("", product)
+	v.check(product.GetBaseProduct().SKU == sku, "sku", "must match the SKU in the path")
+	return v.result()
+}
+
+func ValidateBrand(brand dto.Brand) error {
+	v := validator{}
+	v.brand("", brand)
+	return v.result()
+}
+
+func ValidateUpdateBrandRequest(name string, brand dto.Brand) error {
+	v := validator{}
+	v.brand("", brand)
+	v.check(brand.Name == name, "name", "must match the brand name in the path")
+	return v.result()
+}
+
+type validator struct {
+	errors Errors
+}
+
+func (v *validator) check(ok bool, field string, message string) {
+	if !ok {
+		v.errors = append(v.errors, FieldError{Field: field, Message: message})
+	}
+}
+
+func (v *validator) notBlank(field string, value string) {
+	v.check(strings.TrimSpace(value) != "", field, "must not be empty")
+}
+
+func (v *validator) product(prefix string, product dto.IProduct) {
+	baseProduct := product.GetBaseProduct()
+	v.notBlank(field(prefix, "sku"), baseProduct.SKU)
+	v.notBlank(field(prefix, "name"), baseProduct.Name)
+	v.check(baseProduct.Price >= 0, field(prefix, "price"), "must not be negative")
+	v.brand(field(prefix, "brand"), baseProduct.Brand)
+	if productType, err := producttype.Lookup(product.GetType()); err == nil && productType.Validate != nil {
+		productType.Validate(product, func(ok bool, name string, message string) {
+			v.check(ok, field(prefix, name), message)
+		})
+	}
+}
+
+func (v *validator) brand(prefix string, brand dto.Brand) {
+	v.notBlank(field(prefix, "name"), brand.Name)
+	v.check(brand.Quality >= MinBrandQuality && brand.Quality <= MaxBrandQuality, field(prefix, "quality"), "must be between 1 and 5")
+}
+
+func (v *validator) lot(prefix string, lot dto.Lot) {
+	v.notBlank(field(prefix, "lotNumber"), lot.LotNumber)
+	v.check(!lot.ExpirationDate.IsZero(), field(prefix, "expirationDate"), "must be set")
+}
+
+func (v *validator) serials(field string, serials []string, quantity int) {
+	v.check(len(serials) == quantity, field, "must have one serial number per unit")
+	for i, serial := range serials {
+		v.notBlank(fmt.Sprintf("%s[%d]", field, i), serial)
+		v.check(!slices.Contains(serials[:i], serial), fmt.Sprintf("%s[%d]", field, i), "must not repeat a serial number")
+	}
+}
+
+func (v *validator) minBrandQuality(field string, quality int) {
+	v.check(quality >= 0 && quality <= MaxBrandQuality, field, "must be between 0 and 5")
+}
+
+func field(prefix string, name string) string {
+	if prefix == "" {
+		return name
+	}
+	return prefix + "." + name
+}
+
+func (v *validator) result() error {
+	if len(v.errors) == 0 {
+		return nil
+	}
+	return v.errors
+}
@@ -0,0 +1,113 @@
+package validation
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/kijevigombooc/inventory-manager/internal/inventory/handler/dto"
+)
+
+func fields(t *testing.T, err error) []string {
+	t.Helper()
+	if err == nil {
+		return nil
+	}
+	var validationErrors Errors
+	if !errors.As(err, &validationErrors) {
+		t.Fatalf("Error should be validation errors, got %v", err)
+	}
+	result := []string{}
+	for _, fieldError := range validationErrors {
+		result = append(result, fieldError.Field)
+	}
+	return result
+}
+
+func TestValidateWarehouse(t *testing.T) {
+	if err := ValidateWarehouse(dto.Warehouse{Name: "Warehouse", Address: "Address", Capacity: 0}); err != nil {
+		t.Fatalf("Warehouse should be valid: %v", err)
+	}
+	got := fields(t, ValidateWarehouse(dto.Warehouse{Name: " ", Capacity: -1, MinBrandQuality: 6}))
+	if expected := []string{"name", "capacity", "minBrandQuality"}; !reflect.DeepEqual(got, expected) {
+		t.Fatalf("Invalid fields should be %v, got %v", expected, got)
+	}
+}
+
+func TestValidateInsertProductsRequest(t *testing.T) {
+	valid := dto.InsertProductsRequest{
+		WarehouseName: "Warehouse",
+		Quantity:      1,
+		ParsedProduct: &dto.BookProduct{Product: dto.Product{SKU: "SKU", Name: "Book", Price: 0, Brand: dto.Brand{Name: "Brand", Quality: 5}, Type: "Book"}},
+	}
+	if err := ValidateInsertProductsRequest(valid); err != nil {
+		t.Fatalf("Request should be valid: %v", err)
+	}
+	invalid := dto.InsertProductsRequest{
+		Quantity:      0,
+		ParsedProduct: &dto.BookProduct{Product: dto.Product{Price: -1, Brand: dto.Brand{Quality: 6}, Type: "Book"}},
+	}
+	got := fields(t, ValidateInsertProductsRequest(invalid))
+	expected := []string{"warehouseName", "quantity", "product.sku", "product.name", "product.price", "product.brand.name", "product.brand.quality"}
+	if !reflect.DeepEqual(got, expected) {
+		t.Fatalf("Invalid fields should be %v, got %v", expected, got)
+	}
+}
+
+func TestValidateInsertProductsRequestLot(t *testing.T) {
+	req := dto.InsertProductsRequest{
+		WarehouseName: "Warehouse",
+		Quantity:      1,
+		ParsedProduct: &dto.BookProduct{Product: dto.Product{SKU: "SKU", Name: "Book", Brand: dto.Brand{Name: "Brand", Quality: 5}, Type: "Book"}},
+		Lot:           &dto.Lot{LotNumber: " "},
+	}
+	got := fields(t, ValidateInsertProductsRequest(req))
+	if expected := []string{"lot.lotNumber", "lot.expirationDate", "lot"}; !reflect.DeepEqual(got, expected) {
+		t.Fatalf("Invalid fields should be %v, got %v", expected, got)
+	}
+}
+
+func TestValidateInsertProductsRequestSerials(t *testing.T) {
+	req := dto.InsertProductsRequest{
+		WarehouseName: "Warehouse",
+		Quantity:      3,
+		ParsedProduct: &dto.BookProduct{Product: dto.Product{SKU: "SKU", Name: "Book", Brand: dto.Brand{Name: "Brand", Quality: 5}, Type: "Book"}},
+		Serials:       []string{"SN-1", "SN-1"},
+	}
+	got := fields(t, ValidateInsertProductsRequest(req))
+	if expected := []string{"serials", "serials[1]", "serials"}; !reflect.DeepEqual(got, expected) {
+		t.Fatalf("Invalid fields should be %v, got %v", expected, got)
+	}
+}
+
+func TestValidateRemoveProductsRequest(t *testing.T) {
+	if err := ValidateRemoveProductsRequest(dto.RemoveProductsRequest{WarehouseName: "Warehouse", Sku: "SKU", Quantity: 1}); err != nil {
+		t.Fatalf("Request should be valid: %v", err)
+	}
+	got := fields(t, ValidateRemoveProductsRequest(dto.RemoveProductsRequest{Quantity: -1}))
+	if expected := []string{"warehouseName", "sku", "quantity"}; !reflect.DeepEqual(got, expected) {
+		t.Fatalf("Invalid fields should be %v, got %v", expected, got)
+	}
+}
+
+func TestValidateUpdateProductRequest(t *testing.T) {
+	product := &dto.ConsumableProduct{Product: dto.Product{SKU: "SKU", Name: "Milk", Price: 10, Brand: dto.Brand{Name: "Brand", Quality: 1}, Type: "Consumable"}, ExpirationDate: dto.NewDate(2024, 12, 31)}
+	if err := ValidateUpdateProductRequest("SKU", product); err != nil {
+		t.Fatalf("Request should be valid: %v", err)
+	}
+	product.Brand.Quality = 0
+	got := fields(t, ValidateUpdateProductRequest("OTHER", product))
+	if expected := []string{"brand.quality", "sku"}; !reflect.DeepEqual(got, expected) {
+		t.Fatalf("Invalid fields should be %v, got %v", expected, got)
+	}
+}
+
+func TestValidateTransferProductsRequest(t *testing.T) {
+	if err := ValidateTransferProductsRequest(dto.TransferProductsRequest{From: "A", To: "B", Sku: "SKU", Quantity: 1}); err != nil {
+		t.Fatalf("Request should be valid: %v", err)
+	}
+	got := fields(t, ValidateTransferProductsRequest(dto.TransferProductsRequest{From: "A", To: "A", Quantity: -1}))
+	if expected := []string{"to", "sku", "quantity"}; !reflect.DeepEqual(got, expected) {
+		t.Fatalf("Invalid fields should be %v, got %v", expected, got)
+	}
+}
@@ -0,0 +1,36 @@
+package producttype
+
+import (
+	"github.com/kijevigombooc/inventory-manager/internal/inventory/handler/dto"
+	"github.com/kijevigombooc/inventory-manager/internal/inventory/store/domain"
+)
+
+func init() {
+	Register(Type{
+		Name:      "Book",
+		NewDto:    func() dto.IProduct { return &dto.BookProduct{} },
+		NewEntity: func() domain.IProduct { return &domain.BookProduct{} },
+		ToEntity: func(product dto.IProduct) (domain.IProduct, error) {
+			book, err := As[*dto.BookProduct](product)
+			if err != nil {
+				return nil, err
+			}
+			return &domain.BookProduct{Author: book.Author}, nil
+		},
+		ToDto: func(product domain.IProduct) (dto.IProduct, error) {
+			book, err := As[*domain.BookProduct](product)
+			if err != nil {
+				return nil, err
+			}
+			return &dto.BookProduct{Author: book.Author}, nil
+		},
+		Table:   "book_products",
+		Columns: []string{"author"},
+		Values: func(product domain.IProduct) []any {
+			return []any{product.(*domain.BookProduct).Author}
+		},
+		Scan: func(product domain.IProduct, scan func(dest ...any) error) error {
+			return scan(&product.(*domain.BookProduct).Author)
+		},
+	})
+}
@@ -0,0 +1,57 @@
+package producttype
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/kijevigombooc/inventory-manager/internal/inventory/handler/dto"
+	"github.com/kijevigombooc/inventory-manager/internal/inventory/store/domain"
+)
+
+const expirationDateLayout = "2006-01-02"
+
+func init() {
+	Register(Type{
+		Name:      "Consumable",
+		NewDto:    func() dto.IProduct { return &dto.ConsumableProduct{} },
+		NewEntity: func() domain.IProduct { return &domain.ConsumableProduct{} },
+		ToEntity: func(product dto.IProduct) (domain.IProduct, error) {
+			consumable, err := As[*dto.ConsumableProduct](product)
+			if err != nil {
+				return nil, err
+			}
+			return &domain.ConsumableProduct{ExpirationDate: consumable.ExpirationDate.Time}, nil
+		},
+		ToDto: func(product domain.IProduct) (dto.IProduct, error) {
+			consumable, err := As[*domain.ConsumableProduct](product)
+			if err != nil {
+				return nil, err
+			}
+			return &dto.ConsumableProduct{ExpirationDate: dto.Date{Time: consumable.ExpirationDate}}, nil
+		},
+		Validate: func(product dto.IProduct, check Check) {
+			check(!product.(*dto.ConsumableProduct).ExpirationDate.IsZero(), "expirationDate", "must be set")
+		},
+		HasLots: true,
+		ExpirationDate: func(product domain.IProduct) time.Time {
+			return product.(*domain.ConsumableProduct).ExpirationDate
+		},
+		Table:   "consumable_products",
+		Columns: []string{"expiration_date"},
+		Values: func(product domain.IProduct) []any {
+			return []any{product.(*domain.ConsumableProduct).ExpirationDate.Format(expirationDateLayout)}
+		},
+		Scan: func(product domain.IProduct, scan func(dest ...any) error) error {
+			consumable := product.(*domain.ConsumableProduct)
+			var expirationDate string
+			if err := scan(&expirationDate); err != nil {
+				return err
+			}
+			var err error
+			if consumable.ExpirationDate, err = time.Parse(expirationDateLayout, expirationDate); err != nil {
+				return fmt.Errorf("expiration date of %s: %w", consumable.SKU, err)
+			}
+			return nil
+		},
+	})
+}
@@ -0,0 +1,50 @@
+package producttype
+
+import (
+	"github.com/kijevigombooc/inventory-manager/internal/inventory/handler/dto"
+	"github.com/kijevigombooc/inventory-manager/internal/inventory/store/domain"
+)
+
+func init() {
+	Register(Type{
+		Name:      "Electronics",
+		NewDto:    func() dto.IProduct { return &dto.ElectronicsProduct{} },
+		NewEntity: func() domain.IProduct { return &domain.ElectronicsProduct{} },
+		ToEntity: func(product dto.IProduct) (domain.IProduct, error) {
+			electronics, err := As[*dto.ElectronicsProduct](product)
+			if err != nil {
+				return nil, err
+			}
+			return &domain.ElectronicsProduct{WarrantyPeriod: periodDtoToEntity(electronics.WarrantyPeriod)}, nil
+		},
+		ToDto: func(product domain.IProduct) (dto.IProduct, error) {
+			electronics, err := As[*domain.ElectronicsProduct](product)
+			if err != nil {
+				return nil, err
+			}
+			return &dto.ElectronicsProduct{WarrantyPeriod: periodEntityToDto(electronics.WarrantyPeriod)}, nil
+		},
+		HasSerials: true,
+		Warranty: func(product domain.IProduct) domain.Period {
+			return product.(*domain.ElectronicsProduct).WarrantyPeriod
+		},
+		Table:   "electronics_products",
+		Columns: []string{"warranty_months", "warranty_days"},
+		Values: func(product domain.IProduct) []any {
+			warrantyPeriod := product.(*domain.ElectronicsProduct).WarrantyPeriod
+			return []any{warrantyPeriod.Months, warrantyPeriod.Days}
+		},
+		Scan: func(product domain.IProduct, scan func(dest ...any) error) error {
+			warrantyPeriod := &product.(*domain.ElectronicsProduct).WarrantyPeriod
+			return scan(&warrantyPeriod.Months, &warrantyPeriod.Days)
+		},
+	})
+}
+
+func periodDtoToEntity(period dto.Period) domain.Period {
+	return domain.Period{Months: period.Years*12 + period.Months, Days: period.Days}
+}
+
+func periodEntityToDto(period domain.Period) dto.Period {
+	return dto.Period{Years: period.Months / 12, Months: period.Months % 12, Days: period.Days}
+}
@@ -0,0 +1,128 @@
+// Package producttype keeps what differs between product types in one place. A type registers
+// its DTO, its conversion to and from the entity, the table its attributes are stored in with
+// the migrations creating it, its validation and whether its stock is tracked in lots, by serial
+// numbers, expires or has a warranty. The API, the service and the stores find everything else
+// through the registry.
+package producttype
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/kijevigombooc/inventory-manager/internal/inventory/handler/dto"
+	"github.com/kijevigombooc/inventory-manager/internal/inventory/store/domain"
+)
+
+// Type describes one product type. The attribute functions only handle the type specific
+// attributes, the base product is copied by the caller.
+type Type struct {
+	Name      domain.ProductType
+	NewDto    func() dto.IProduct
+	NewEntity func() domain.IProduct
+	ToEntity  func(product dto.IProduct) (domain.IProduct, error)
+	ToDto     func(product domain.IProduct) (dto.IProduct, error)
+	// Validate reports invalid attributes through check, it may be nil
+	Validate func(product dto.IProduct, check Check)
+	// HasLots and HasSerials tell whether stock of the type is tracked in lots and by serial numbers
+	HasLots    bool
+	HasSerials bool
+	// ExpirationDate and Warranty are nil for types that do not expire or have no warranty
+	ExpirationDate func(product domain.IProduct) time.Time
+	Warranty       func(product domain.IProduct) domain.Period
+	// Table keeps one row of attributes per SKU, Columns lists the attributes
+	Table   string
+	Columns []string
+	// Migrations create and change Table, their versions are numbered apart from the store and the other types.
+	// The tables of the built-in types predate the registry and are created by the store migrations.
+	Migrations []Migration
+	// Values and Scan handle the attributes in the order of Columns
+	Values func(product domain.IProduct) []any
+	Scan   func(product domain.IProduct, scan func(dest ...any) error) error
+}
+
+// Migration holds statements that every supported database understands, Down undoes Up.
+type Migration struct {
+	Version int
+	Name    string
+	Up      []string
+	Down    []string
+}
+
+// Check reports an invalid field when ok is false, the field is relative to the product.
+type Check func(ok bool, field string, message string)
+
+// types is only written at startup, so it is read without locking.
+var types = map[domain.ProductType]Type{}
+
+// Register adds a product type, it is meant to be called from init and panics on duplicates.
+func Register(t Type) {
+	if _, ok := types[t.Name]; ok {
+		panic(fmt.Sprintf("product type %s is registered twice", t.Name))
+	}
+	types[t.Name] = t
+	dto.RegisterProductType(dto.ProductType(t.Name), t.NewDto)
+}
+
+func Lookup[T ~string](name T) (Type, error) {
+	t, ok := types[domain.ProductType(name)]
+	if !ok {
+		return Type{}, fmt.Errorf("unknown product type: %s", name)
+	}
+	return t, nil
+}
+
+func All() []Type {
+	var result []Type
+	for _, t := range types {
+		result = append(result, t)
+	}
+	slices.SortFunc(result, func(a, b Type) int {
+		return cmp.Compare(a.Name, b.Name)
+	})
+	return result
+}
+
+// As asserts the concrete product a type converts, for ToEntity and ToDto to fail on a mismatch instead of panicking.
+func As[T any](product any) (T, error) {
+	result, ok := product.(T)
+	if !ok {
+		return result, fmt.Errorf("product is a %T instead of a %T", product, result)
+	}
+	return result, nil
+}
+
+// HasLots and HasSerials are false for unknown types.
+func HasLots[T ~string](name T) bool {
+	t, err := Lookup(name)
+	return err == nil && t.HasLots
+}
+
+func HasSerials[T ~string](name T) bool {
+	t, err := Lookup(name)
+	return err == nil && t.HasSerials
+}
+
+func Expires[T ~string](name T) bool {
+	t, err := Lookup(name)
+	return err == nil && t.ExpirationDate != nil
+}
+
+// ExpirationDate is zero for products that do not expire.
+func ExpirationDate(product domain.IProduct) time.Time {
+	t, err := Lookup(product.GetType())
+	if err != nil || t.ExpirationDate == nil {
+		return time.Time{}
+	}
+	return t.ExpirationDate(product)
+}
+
+// Warranty reports false for products without a warranty.
+func Warranty(product domain.IProduct) (domain.Period, bool) {
+	t, err := Lookup(product.GetType())
+	if err != nil || t.Warranty == nil {
+		return domain.Period{}, false
+	}
+	return t.Warranty(product), true
+}
@@ -0,0 +1,188 @@
+package service
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"sort"
+)
+
+type WarehouseSpace struct {
+	Name     string
+	Capacity int
+	Used     int
+}
+
+func (ws WarehouseSpace) Free() int {
+	return max(ws.Capacity-ws.Used, 0)
+}
+
+type Allocation struct {
+	WarehouseName string
+	Quantity      int
+}
+
+// AllocationStrategy decides how many products InsertProducts puts into each warehouse.
+// Warehouses arrive with the requested one first and the rest in name order,
+// allocating less than the quantity means there is not enough capacity.
+type AllocationStrategy interface {
+	Name() string
+	Allocate(requested string, warehouses []WarehouseSpace, quantity int) []Allocation
+}
+
+const (
+	AllocationRequestedFirst      = "requested-first"
+	AllocationMostFreeCapacity    = "most-free-capacity"
+	AllocationBalancedUtilization = "balanced-utilization"
+	AllocationLocalOnly           = "local-only"
+)
+
+var allocationStrategies = map[string]AllocationStrategy{
+	AllocationRequestedFirst:      requestedFirstAllocation{},
+	AllocationMostFreeCapacity:    mostFreeCapacityAllocation{},
+	AllocationBalancedUtilization: balancedUtilizationAllocation{},
+	AllocationLocalOnly:           localOnlyAllocation{},
+}
+
+func ParseAllocationStrategy(name string) (AllocationStrategy, error) {
+	strategy, ok := allocationStrategies[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrUnknownAllocationStrategy, name)
+	}
+	return strategy, nil
+}
+
+func fillInOrder(warehouses []WarehouseSpace, quantity int) []Allocation {
+	var result []Allocation
+	for _, warehouse := range warehouses {
+		if quantity == 0 {
+			break
+		}
+		toAllocate := min(warehouse.Free(), quantity)
+		if toAllocate == 0 {
+			continue
+		}
+		result = append(result, Allocation{WarehouseName: warehouse.Name, Quantity: toAllocate})
+		quantity -= toAllocate
+	}
+	return result
+}
+
+type requestedFirstAllocation struct{}
+
+func (requestedFirstAllocation) Name() string {
+	return AllocationRequestedFirst
+}
+
+func (requestedFirstAllocation) Allocate(requested string, warehouses []WarehouseSpace, quantity int) []Allocation {
+	return fillInOrder(warehouses, quantity)
+}
+
+type mostFreeCapacityAllocation struct{}
+
+func (mostFreeCapacityAllocation) Name() string {
+	return AllocationMostFreeCapacity
+}
+
+func (mostFreeCapacityAllocation) Allocate(requested string, warehouses []WarehouseSpace, quantity int) []Allocation {
+	sorted := slices.Clone(warehouses)
+	slices.SortStableFunc(sorted, func(a, b WarehouseSpace) int {
+		return cmp.Compare(b.Free(), a.Free())
+	})
+	return fillInOrder(sorted, quantity)
+}
+
+type balancedUtilizationAllocation struct{}
+
+func (balancedUtilizationAllocation) Name() string {
+	return AllocationBalancedUtilization
+}
+
+// Allocate fills the warehouses up to the highest common utilization the quantity reaches, then places the rest
+// one by one into the warehouse that would be the least utilized afterwards.
+func (balancedUtilizationAllocation) Allocate(requested string, warehouses []WarehouseSpace, quantity int) []Allocation {
+	level := utilization{0, 1}
+	for _, warehouse := range warehouses {
+		if warehouse.Capacity <= 0 {
+			continue
+		}
+		k := sort.Search(warehouse.Capacity+1, func(k int) bool {
+			return fillAllTo(warehouses, utilization{k, warehouse.Capacity}) > quantity
+		}) - 1
+		if candidate := (utilization{k, warehouse.Capacity}); candidate.above(level) {
+			level = candidate
+		}
+	}
+	used := make([]int, len(warehouses))
+	allocated := make([]int, len(warehouses))
+	for i, warehouse := range warehouses {
+		allocated[i] = fillTo(warehouse, level)
+		used[i] = warehouse.Used + allocated[i]
+		quantity -= allocated[i]
+	}
+	for ; quantity > 0; quantity-- {
+		best := -1
+		for i, warehouse := range warehouses {
+			if used[i] >= warehouse.Capacity {
+				continue
+			}
+			if best == -1 || (utilization{used[i] + 1, warehouse.Capacity}).below(utilization{used[best] + 1, warehouses[best].Capacity}) {
+				best = i
+			}
+		}
+		if best == -1 {
+			break
+		}
+		used[best]++
+		allocated[best]++
+	}
+	var result []Allocation
+	for i, warehouse := range warehouses {
+		if allocated[i] > 0 {
+			result = append(result, Allocation{WarehouseName: warehouse.Name, Quantity: allocated[i]})
+		}
+	}
+	return result
+}
+
+// utilization is the fraction used/capacity, compared without division.
+type utilization struct {
+	used     int
+	capacity int
+}
+
+func (u utilization) below(other utilization) bool {
+	return u.used*other.capacity < other.used*u.capacity
+}
+
+func (u utilization) above(other utilization) bool {
+	return other.below(u)
+}
+
+// fillTo is how many products the warehouse takes until it reaches the utilization.
+func fillTo(warehouse WarehouseSpace, level utilization) int {
+	return min(max(level.used*warehouse.Capacity/level.capacity-warehouse.Used, 0), warehouse.Free())
+}
+
+func fillAllTo(warehouses []WarehouseSpace, level utilization) int {
+	total := 0
+	for _, warehouse := range warehouses {
+		total += fillTo(warehouse, level)
+	}
+	return total
+}
+
+type localOnlyAllocation struct{}
+
+func (localOnlyAllocation) Name() string {
+	return AllocationLocalOnly
+}
+
+func (localOnlyAllocation) Allocate(requested string, warehouses []WarehouseSpace, quantity int) []Allocation {
+	for _, warehouse := range warehouses {
+		if warehouse.Name == requested {
+			return fillInOrder([]WarehouseSpace{warehouse}, quantity)
+		}
+	}
+	return nil
+}
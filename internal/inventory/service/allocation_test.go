@@ -0,0 +1,94 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"reflect"
+	"testing"
+)
+
+func TestAllocationStrategies(t *testing.T) {
+	warehouses := []WarehouseSpace{
+		{Name: "A", Capacity: 4, Used: 2},
+		{Name: "B", Capacity: 10, Used: 0},
+		{Name: "C", Capacity: 6, Used: 1},
+	}
+	tests := []struct {
+		strategy string
+		quantity int
+		expected []Allocation
+	}{
+		{AllocationRequestedFirst, 5, []Allocation{{"A", 2}, {"B", 3}}},
+		{AllocationMostFreeCapacity, 12, []Allocation{{"B", 10}, {"C", 2}}},
+		{AllocationBalancedUtilization, 6, []Allocation{{"B", 5}, {"C", 1}}},
+		{AllocationLocalOnly, 5, []Allocation{{"A", 2}}},
+		{AllocationRequestedFirst, 20, []Allocation{{"A", 2}, {"B", 10}, {"C", 5}}},
+	}
+	for _, test := range tests {
+		strategy, err := ParseAllocationStrategy(test.strategy)
+		if err != nil {
+			t.Fatalf("Error parsing allocation strategy: %v", err)
+		}
+		allocations := strategy.Allocate("A", warehouses, test.quantity)
+		if !reflect.DeepEqual(allocations, test.expected) {
+			t.Fatalf("%s allocation of %d should be %v, got %v", test.strategy, test.quantity, test.expected, allocations)
+		}
+	}
+}
+
+func TestBalancedUtilizationAllocation(t *testing.T) {
+	random := rand.New(rand.NewSource(1))
+	for range 200 {
+		var warehouses []WarehouseSpace
+		for i := range random.Intn(5) + 1 {
+			capacity := random.Intn(12)
+			warehouses = append(warehouses, WarehouseSpace{Name: fmt.Sprint(i), Capacity: capacity, Used: random.Intn(capacity + 2)})
+		}
+		quantity := random.Intn(40) + 1
+		expected := allocateOneByOne(warehouses, quantity)
+		if allocations := (balancedUtilizationAllocation{}).Allocate("0", warehouses, quantity); !reflect.DeepEqual(allocations, expected) {
+			t.Fatalf("Balanced allocation of %d into %v should be %v, got %v", quantity, warehouses, expected, allocations)
+		}
+	}
+	warehouses := []WarehouseSpace{{Name: "A", Capacity: 3_000_000_000}, {Name: "B", Capacity: 1_000_000_000, Used: 500_000_000}}
+	expected := []Allocation{{"A", 1_875_000_000}, {"B", 125_000_000}}
+	if allocations := (balancedUtilizationAllocation{}).Allocate("A", warehouses, 2_000_000_000); !reflect.DeepEqual(allocations, expected) {
+		t.Fatalf("Balanced allocation should be %v, got %v", expected, allocations)
+	}
+}
+
+// allocateOneByOne is the balanced utilization allocation spelled out one product at a time.
+func allocateOneByOne(warehouses []WarehouseSpace, quantity int) []Allocation {
+	used := make([]int, len(warehouses))
+	allocated := make([]int, len(warehouses))
+	for i, warehouse := range warehouses {
+		used[i] = warehouse.Used
+	}
+	for ; quantity > 0; quantity-- {
+		best := -1
+		for i, warehouse := range warehouses {
+			if used[i] < warehouse.Capacity && (best == -1 || (used[i]+1)*warehouses[best].Capacity < (used[best]+1)*warehouse.Capacity) {
+				best = i
+			}
+		}
+		if best == -1 {
+			break
+		}
+		used[best]++
+		allocated[best]++
+	}
+	var result []Allocation
+	for i, warehouse := range warehouses {
+		if allocated[i] > 0 {
+			result = append(result, Allocation{WarehouseName: warehouse.Name, Quantity: allocated[i]})
+		}
+	}
+	return result
+}
+
+func TestParseAllocationStrategyErrorUnknown(t *testing.T) {
+	if _, err := ParseAllocationStrategy("random"); !errors.Is(err, ErrUnknownAllocationStrategy) {
+		t.Fatalf("Expected ErrUnknownAllocationStrategy, got %v", err)
+	}
+}
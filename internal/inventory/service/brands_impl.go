@@ -0,0 +1,116 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/kijevigombooc/inventory-manager/internal/inventory/handler/dto"
+	"github.com/kijevigombooc/inventory-manager/internal/inventory/store"
+	"github.com/kijevigombooc/inventory-manager/internal/inventory/store/domain"
+	"github.com/kijevigombooc/inventory-manager/internal/utils"
+)
+
+func (s *inventoryService) GetBrands(ctx context.Context) ([]dto.BrandDetail, error) {
+	var result []dto.BrandDetail
+	err := s.runner.Run(ctx, func(trx store.Transaction) error {
+		brands, err := trx.GetBrands()
+		if err != nil {
+			return err
+		}
+		result, err = utils.MapErrored(brands, func(brand domain.Brand) (dto.BrandDetail, error) {
+			return brandDetail(trx, brand)
+		})
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (s *inventoryService) CreateBrand(ctx context.Context, brand dto.Brand) error {
+	return s.runner.Run(ctx, func(trx store.Transaction) error {
+		err := trx.InsertBrand(domain.Brand(brand))
+		if errors.Is(err, store.ErrAlreadyExists) {
+			return fmt.Errorf("%w: %s", ErrDuplicateBrand, brand.Name)
+		}
+		return err
+	})
+}
+
+func (s *inventoryService) GetBrand(ctx context.Context, name string) (dto.BrandDetail, error) {
+	var result dto.BrandDetail
+	err := s.runner.Run(ctx, func(trx store.Transaction) error {
+		brand, err := getBrand(trx, name)
+		if err != nil {
+			return err
+		}
+		result, err = brandDetail(trx, brand)
+		return err
+	})
+	return result, err
+}
+
+func (s *inventoryService) UpdateBrand(ctx context.Context, brand dto.Brand) error {
+	return s.runner.Run(ctx, func(trx store.Transaction) error {
+		if _, err := getBrand(trx, brand.Name); err != nil {
+			return err
+		}
+		return trx.UpdateBrand(domain.Brand(brand))
+	})
+}
+
+func (s *inventoryService) DeleteBrand(ctx context.Context, name string) error {
+	return s.runner.Run(ctx, func(trx store.Transaction) error {
+		if _, err := getBrand(trx, name); err != nil {
+			return err
+		}
+		products, err := trx.GetCatalogProductsByBrand(name)
+		if err != nil {
+			return err
+		}
+		if len(products) > 0 {
+			return fmt.Errorf("%w: %s has %d products", ErrBrandInUse, name, len(products))
+		}
+		return trx.DeleteBrand(name)
+	})
+}
+
+func getBrand(trx store.Transaction, name string) (domain.Brand, error) {
+	brand, err := trx.GetBrand(name)
+	if errors.Is(err, store.ErrNotFound) {
+		return domain.Brand{}, fmt.Errorf("%w: %s", ErrBrandNotFound, name)
+	}
+	return brand, err
+}
+
+// checkMatchesBrand rejects a quality that differs from the stored brand, brands are changed through the brand endpoints.
+func checkMatchesBrand(trx store.Transaction, brand domain.Brand) error {
+	stored, err := trx.GetBrand(brand.Name)
+	if errors.Is(err, store.ErrNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if stored.Quality != brand.Quality {
+		return fmt.Errorf("%w: %s has quality %d, got %d", ErrBrandMismatch, brand.Name, stored.Quality, brand.Quality)
+	}
+	return nil
+}
+
+func brandDetail(trx store.Transaction, brand domain.Brand) (dto.BrandDetail, error) {
+	productEntities, err := trx.GetCatalogProductsByBrand(brand.Name)
+	if err != nil {
+		return dto.BrandDetail{}, err
+	}
+	productDtos, err := utils.MapErrored(productEntities, productEntityToDto)
+	if err != nil {
+		return dto.BrandDetail{}, err
+	}
+	return dto.BrandDetail{
+		Brand:    dto.Brand(brand),
+		Products: productDtos,
+	}, nil
+}
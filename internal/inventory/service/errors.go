@@ -0,0 +1,30 @@
+package service
+
+import "errors"
+
+var (
+	ErrWarehouseNotFound    = errors.New("warehouse not found")
+	ErrDuplicateWarehouse   = errors.New("warehouse already exists")
+	ErrWarehouseNotEmpty    = errors.New("warehouse still holds products")
+	ErrCapacityBelowUsage   = errors.New("capacity is below the used capacity")
+	ErrInsufficientCapacity = errors.New("not enough capacity in warehouses")
+	ErrInsufficientStock    = errors.New("not enough product in warehouses")
+	ErrSkuTypeConflict      = errors.New("product with sku already exists with different type")
+	ErrProductNotFound      = errors.New("product not found")
+	ErrDuplicateProduct     = errors.New("product already exists")
+	ErrProductMismatch      = errors.New("product does not match the catalog")
+	ErrProductInStock       = errors.New("product is still in stock")
+	ErrBrandNotFound        = errors.New("brand not found")
+	ErrDuplicateBrand       = errors.New("brand already exists")
+	ErrBrandMismatch        = errors.New("brand quality does not match the stored brand")
+	ErrBrandInUse           = errors.New("brand still has products")
+	ErrBrandQualityTooLow   = errors.New("brand quality is below the warehouse minimum")
+	ErrInvalidLot           = errors.New("invalid lot")
+	ErrLotMismatch          = errors.New("lot does not match the stored lot")
+	ErrInvalidSerial        = errors.New("invalid serial numbers")
+	ErrDuplicateSerial      = errors.New("serial number is already in use")
+	ErrSerialNotFound       = errors.New("serial number not found")
+
+	ErrUnknownAllocationStrategy = errors.New("unknown allocation strategy")
+	ErrUnknownPickingStrategy    = errors.New("unknown picking strategy")
+)
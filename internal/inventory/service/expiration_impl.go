@@ -0,0 +1,167 @@
+package service
+
+import (
+	"cmp"
+	"context"
+	"slices"
+	"time"
+
+	"github.com/kijevigombooc/inventory-manager/internal/inventory/handler/dto"
+	"github.com/kijevigombooc/inventory-manager/internal/inventory/producttype"
+	"github.com/kijevigombooc/inventory-manager/internal/inventory/store"
+	"github.com/kijevigombooc/inventory-manager/internal/inventory/store/domain"
+)
+
+// GetExpiringStock lists the consumables expiring within the given days from today by warehouse,
+// expired and quarantined stock included.
+func (s *inventoryService) GetExpiringStock(ctx context.Context, days int) ([]dto.ExpiringStock, error) {
+	today := startOfDay(s.now())
+	var result []dto.ExpiringStock
+	err := s.runner.Run(ctx, func(trx store.Transaction) error {
+		expiring, err := expiringSources(trx, today.AddDate(0, 0, days+1))
+		if err != nil {
+			return err
+		}
+		quarantined, err := trx.GetQuarantinedStock("", "")
+		if err != nil {
+			return err
+		}
+		result = []dto.ExpiringStock{}
+		for _, source := range expiring {
+			result = append(result, dto.ExpiringStock{
+				WarehouseName:  source.WarehouseName,
+				Sku:            source.sku,
+				LotNumber:      source.LotNumber,
+				ExpirationDate: dto.Date{Time: source.ExpiresAt},
+				Quantity:       source.Quantity,
+				Expired:        source.ExpiresAt.Before(today),
+			})
+		}
+		for _, stock := range quarantined {
+			result = append(result, dto.ExpiringStock{
+				WarehouseName:  stock.WarehouseName,
+				Sku:            stock.Sku,
+				LotNumber:      stock.LotNumber,
+				ExpirationDate: dto.Date{Time: stock.ExpirationDate},
+				Quantity:       stock.Quantity,
+				Expired:        true,
+				Quarantined:    true,
+			})
+		}
+		slices.SortStableFunc(result, func(a, b dto.ExpiringStock) int {
+			return cmp.Or(
+				cmp.Compare(a.WarehouseName, b.WarehouseName),
+				a.ExpirationDate.Compare(b.ExpirationDate.Time),
+				cmp.Compare(a.Sku, b.Sku),
+				cmp.Compare(a.LotNumber, b.LotNumber),
+			)
+		})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// QuarantineExpired takes the stock that expired before today out of reach of removals and transfers,
+// it returns the quarantined quantity.
+func (s *inventoryService) QuarantineExpired(ctx context.Context) (int, error) {
+	today := startOfDay(s.now())
+	quarantined := 0
+	err := s.runner.Run(ctx, func(trx store.Transaction) error {
+		quarantined = 0
+		expired, err := expiringSources(trx, today)
+		if err != nil {
+			return err
+		}
+		for _, source := range expired {
+			if source.LotNumber != "" {
+				if err := trx.ChangeLotQuantity(source.WarehouseName, source.sku, source.LotNumber, -source.Quantity); err != nil {
+					return err
+				}
+			}
+			err := trx.QuarantineStock(domain.QuarantinedStock{
+				WarehouseName:  source.WarehouseName,
+				Sku:            source.sku,
+				LotNumber:      source.LotNumber,
+				ExpirationDate: source.ExpiresAt,
+				Quantity:       source.Quantity,
+			})
+			if err != nil {
+				return err
+			}
+			quarantined += source.Quantity
+		}
+		return nil
+	})
+	return quarantined, err
+}
+
+func (s *inventoryService) DiscardQuarantined(ctx context.Context, warehouseName string) error {
+	j := s.newJournal(ctx)
+	return s.runner.Run(ctx, func(trx store.Transaction) error {
+		if err := checkWarehouseExists(trx, warehouseName); err != nil {
+			return err
+		}
+		quarantined, err := trx.GetQuarantinedStock(warehouseName, "")
+		if err != nil {
+			return err
+		}
+		for _, stock := range quarantined {
+			removedQuantity, err := trx.RemoveProduct(warehouseName, stock.Sku, stock.Quantity)
+			if err != nil {
+				return err
+			}
+			if err := j.record(trx, warehouseName, stock.Sku, -removedQuantity, domain.MovementDiscard); err != nil {
+				return err
+			}
+		}
+		return trx.DeleteQuarantinedStock(warehouseName)
+	})
+}
+
+type skuSource struct {
+	StockSource
+	sku string
+}
+
+func expiringSources(trx store.Transaction, before time.Time) ([]skuSource, error) {
+	warehouses, err := trx.GetWarehouses()
+	if err != nil {
+		return nil, err
+	}
+	sourcesBySku := map[string][]StockSource{}
+	var result []skuSource
+	for _, warehouse := range warehouses {
+		products, err := trx.GetProductsByWarehouse(warehouse.Name)
+		if err != nil {
+			return nil, err
+		}
+		for _, product := range products {
+			if !producttype.Expires(product.Product.GetType()) {
+				continue
+			}
+			sku := product.Product.GetBaseProduct().SKU
+			sources, ok := sourcesBySku[sku]
+			if !ok {
+				if sources, err = stockSources(trx, "", sku, false); err != nil {
+					return nil, err
+				}
+				sourcesBySku[sku] = sources
+			}
+			for _, source := range sourcesOf(sources, warehouse.Name) {
+				if !source.ExpiresAt.IsZero() && source.ExpiresAt.Before(before) {
+					result = append(result, skuSource{StockSource: source, sku: sku})
+				}
+			}
+		}
+	}
+	return result, nil
+}
+
+// startOfDay is midnight UTC, products expire at the end of their expiration date.
+func startOfDay(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
@@ -0,0 +1,110 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/kijevigombooc/inventory-manager/internal/inventory/handler/dto"
+	"github.com/kijevigombooc/inventory-manager/internal/inventory/store"
+	"github.com/kijevigombooc/inventory-manager/internal/inventory/store/domain"
+)
+
+// GetWarehousesAsOf lists the warehouses holding stock as of asOf under the name they had then, warehouses
+// that no longer exist under that name are listed by name only.
+func (s *inventoryService) GetWarehousesAsOf(ctx context.Context, asOf time.Time) ([]dto.WarehouseDetail, error) {
+	var result []dto.WarehouseDetail
+	err := s.runner.Run(ctx, func(trx store.Transaction) error {
+		stock, err := trx.GetStockAsOf(asOf, "", "")
+		if err != nil {
+			return err
+		}
+		result = []dto.WarehouseDetail{}
+		for _, warehouseProduct := range stock {
+			if len(result) == 0 || result[len(result)-1].Name != warehouseProduct.WarehouseName {
+				warehouse, err := historicWarehouse(trx, warehouseProduct.WarehouseName)
+				if err != nil {
+					return err
+				}
+				result = append(result, dto.WarehouseDetail{Warehouse: warehouse, Products: []dto.ProductWithQuantity{}})
+			}
+			product, err := historicProduct(trx, warehouseProduct.Sku)
+			if err != nil {
+				return err
+			}
+			detail := &result[len(result)-1]
+			detail.Products = append(detail.Products, dto.ProductWithQuantity{IProduct: product, Quantity: warehouseProduct.Quantity})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (s *inventoryService) GetStock(ctx context.Context, sku string, asOf time.Time) (dto.SkuStock, error) {
+	result := dto.SkuStock{Sku: sku, Warehouses: []dto.WarehouseQuantity{}}
+	err := s.runner.Run(ctx, func(trx store.Transaction) error {
+		var warehouseProducts []domain.WarehouseProduct
+		var err error
+		if asOf.IsZero() {
+			warehouseProducts, err = trx.GetWarehouseProductsBySkuOrderedFirstWithName("", sku)
+		} else {
+			warehouseProducts, err = trx.GetStockAsOf(asOf, "", sku)
+		}
+		if err != nil {
+			return err
+		}
+		result.Total = 0
+		result.Warehouses = []dto.WarehouseQuantity{}
+		for _, warehouseProduct := range warehouseProducts {
+			if warehouseProduct.Quantity == 0 {
+				continue
+			}
+			result.Total += warehouseProduct.Quantity
+			result.Warehouses = append(result.Warehouses, dto.WarehouseQuantity{
+				WarehouseName: warehouseProduct.WarehouseName,
+				Quantity:      warehouseProduct.Quantity,
+			})
+		}
+		return nil
+	})
+	return result, err
+}
+
+// TakeSnapshot stores the current stock, taking it again for the same moment is a no-op.
+func (s *inventoryService) TakeSnapshot(ctx context.Context) error {
+	takenAt := s.now().UTC()
+	return s.runner.Run(ctx, func(trx store.Transaction) error {
+		err := trx.TakeSnapshot(takenAt)
+		if errors.Is(err, store.ErrAlreadyExists) {
+			return nil
+		}
+		return err
+	})
+}
+
+// historicWarehouse falls back to the bare name for warehouses that were deleted or renamed since.
+func historicWarehouse(trx store.Transaction, name string) (dto.Warehouse, error) {
+	warehouse, err := trx.GetWarehouse(name)
+	if errors.Is(err, store.ErrNotFound) {
+		return dto.Warehouse{Name: name}, nil
+	}
+	if err != nil {
+		return dto.Warehouse{}, err
+	}
+	return warehouseEntityToDto(warehouse), nil
+}
+
+// historicProduct falls back to the bare SKU for products that were deleted from the catalog since.
+func historicProduct(trx store.Transaction, sku string) (dto.IProduct, error) {
+	product, err := trx.GetCatalogProduct(sku)
+	if errors.Is(err, store.ErrNotFound) {
+		return &dto.Product{SKU: sku}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return productEntityToDto(product)
+}
@@ -0,0 +1,84 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/kijevigombooc/inventory-manager/internal/inventory/handler/dto"
+	"github.com/kijevigombooc/inventory-manager/internal/inventory/producttype"
+	"github.com/kijevigombooc/inventory-manager/internal/inventory/store"
+	"github.com/kijevigombooc/inventory-manager/internal/inventory/store/domain"
+)
+
+// lotDtoToEntity returns nil when the products are not inserted into a lot, lots received at an unknown time are received now.
+func lotDtoToEntity(lot *dto.Lot, sku string, j journal) (*domain.Lot, error) {
+	if lot == nil {
+		return nil, nil
+	}
+	if lot.ExpirationDate.IsZero() {
+		return nil, fmt.Errorf("%w: lot %s has no expiration date", ErrInvalidLot, lot.LotNumber)
+	}
+	receivedAt := j.timestamp
+	if lot.ReceivedAt != nil {
+		receivedAt = lot.ReceivedAt.UTC()
+	}
+	return &domain.Lot{Sku: sku, Number: lot.LotNumber, ExpirationDate: lot.ExpirationDate.Time, ReceivedAt: receivedAt}, nil
+}
+
+// checkLot reports whether the lot is new, a known lot has to keep its expiration date.
+func checkLot(trx store.Transaction, product domain.IProduct, lot domain.Lot) (bool, error) {
+	if !producttype.HasLots(product.GetType()) {
+		return false, fmt.Errorf("%w: %s is not tracked in lots", ErrInvalidLot, lot.Sku)
+	}
+	stored, err := trx.GetLot(lot.Sku, lot.Number)
+	if errors.Is(err, store.ErrNotFound) {
+		return true, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	if !stored.ExpirationDate.Equal(lot.ExpirationDate) {
+		return false, fmt.Errorf("%w: lot %s of %s expires on %s", ErrLotMismatch, lot.Number, lot.Sku, stored.ExpirationDate.Format(dto.DateLayout))
+	}
+	return false, nil
+}
+
+// moveLots moves the lots along with quantity products of a SKU, taking the first expiring stock of the warehouse.
+// It returns how much pickable stock it found, the warehouse quantities are left to the caller.
+func moveLots(trx store.Transaction, from string, to string, sku string, quantity int) (int, error) {
+	sources, err := stockSources(trx, from, sku, false)
+	if err != nil {
+		return 0, err
+	}
+	picked := 0
+	for _, pick := range pickInOrder(sourcesOf(sources, from), quantity) {
+		picked += pick.Quantity
+		if pick.LotNumber == "" {
+			continue
+		}
+		if err := trx.ChangeLotQuantity(from, sku, pick.LotNumber, -pick.Quantity); err != nil {
+			return 0, err
+		}
+		if err := trx.ChangeLotQuantity(to, sku, pick.LotNumber, pick.Quantity); err != nil {
+			return 0, err
+		}
+	}
+	return picked, nil
+}
+
+func lotQuantitiesBySku(trx store.Transaction, warehouseName string) (map[string][]dto.LotQuantity, error) {
+	lots, err := trx.GetWarehouseLots(warehouseName, "")
+	if err != nil {
+		return nil, err
+	}
+	result := map[string][]dto.LotQuantity{}
+	for _, lot := range lots {
+		result[lot.Lot.Sku] = append(result[lot.Lot.Sku], dto.LotQuantity{
+			LotNumber:      lot.Lot.Number,
+			ExpirationDate: dto.Date{Time: lot.Lot.ExpirationDate},
+			ReceivedAt:     lot.Lot.ReceivedAt,
+			Quantity:       lot.Quantity,
+		})
+	}
+	return result, nil
+}
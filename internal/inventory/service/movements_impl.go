@@ -0,0 +1,87 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/kijevigombooc/inventory-manager/internal/inventory/handler/dto"
+	"github.com/kijevigombooc/inventory-manager/internal/inventory/store"
+	"github.com/kijevigombooc/inventory-manager/internal/inventory/store/domain"
+	"github.com/kijevigombooc/inventory-manager/internal/requestctx"
+	"github.com/kijevigombooc/inventory-manager/internal/utils"
+)
+
+func (s *inventoryService) GetMovements(ctx context.Context, filter dto.MovementFilter) ([]dto.Movement, error) {
+	var result []dto.Movement
+	err := s.runner.Run(ctx, func(trx store.Transaction) error {
+		movements, err := trx.GetMovements(domain.MovementFilter(filter))
+		if err != nil {
+			return err
+		}
+		result = utils.Map(movements, movementEntityToDto)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// journal stamps the movements written by one service call with the same time, correlation ID and actor.
+// It is created outside of the transaction so that retries write identical movements.
+type journal struct {
+	timestamp     time.Time
+	correlationID string
+	actor         string
+}
+
+func (s *inventoryService) newJournal(ctx context.Context) journal {
+	correlationID := requestctx.RequestID(ctx)
+	if correlationID == "" {
+		correlationID = requestctx.NewID()
+	}
+	return journal{
+		timestamp:     s.now().UTC(),
+		correlationID: correlationID,
+		actor:         requestctx.Actor(ctx),
+	}
+}
+
+func (j journal) record(trx store.Transaction, warehouseName string, sku string, delta int, reason domain.MovementReason) error {
+	if delta == 0 {
+		return nil
+	}
+	return trx.InsertMovement(domain.Movement{
+		Timestamp:     j.timestamp,
+		WarehouseName: warehouseName,
+		Sku:           sku,
+		Delta:         delta,
+		Reason:        reason,
+		CorrelationID: j.correlationID,
+		Actor:         j.actor,
+	})
+}
+
+func (j journal) recordSerial(trx store.Transaction, number string, warehouseName string, reason domain.MovementReason) error {
+	return trx.InsertSerialEvent(domain.SerialEvent{
+		Serial:        number,
+		Timestamp:     j.timestamp,
+		WarehouseName: warehouseName,
+		Reason:        reason,
+		CorrelationID: j.correlationID,
+		Actor:         j.actor,
+	})
+}
+
+func movementEntityToDto(movement domain.Movement) dto.Movement {
+	return dto.Movement{
+		ID:            movement.ID,
+		Timestamp:     movement.Timestamp,
+		WarehouseName: movement.WarehouseName,
+		Sku:           movement.Sku,
+		Delta:         movement.Delta,
+		Reason:        string(movement.Reason),
+		CorrelationID: movement.CorrelationID,
+		Actor:         movement.Actor,
+	}
+}
@@ -0,0 +1,348 @@
+package service
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/kijevigombooc/inventory-manager/internal/inventory/producttype"
+	"github.com/kijevigombooc/inventory-manager/internal/inventory/store"
+	"github.com/kijevigombooc/inventory-manager/internal/inventory/store/domain"
+)
+
+// StockSource is stock of the removed SKU that can be picked from, either a lot or the stock outside of lots.
+type StockSource struct {
+	WarehouseName string
+	// LotNumber is empty for the stock that is not tracked in lots
+	LotNumber  string
+	Quantity   int
+	ReceivedAt time.Time
+	// ExpiresAt is zero for products without an expiration date
+	ExpiresAt time.Time
+}
+
+type Pick struct {
+	WarehouseName string
+	LotNumber     string
+	Quantity      int
+}
+
+// PickingStrategy decides how many products RemoveProducts takes from each warehouse and lot.
+// Sources arrive with the requested warehouse first and the rest in name order, the sources of
+// one warehouse first expiring first, picking less than the quantity means there is not enough stock.
+// Only strategies that order by receipt get the ReceivedAt of stock outside of lots, it is replayed from the ledger.
+type PickingStrategy interface {
+	Name() string
+	OrdersByReceipt() bool
+	Pick(requested string, sources []StockSource, quantity int) []Pick
+}
+
+const (
+	PickingRequestedFirst   = "requested-first"
+	PickingFirstExpiring    = "fefo"
+	PickingFirstReceived    = "fifo"
+	PickingFewestWarehouses = "fewest-warehouses"
+	PickingLocalOnly        = "local-only"
+)
+
+var pickingStrategies = map[string]PickingStrategy{
+	PickingRequestedFirst:   requestedFirstPicking{},
+	PickingFirstExpiring:    firstExpiringPicking{},
+	PickingFirstReceived:    firstReceivedPicking{},
+	PickingFewestWarehouses: fewestWarehousesPicking{},
+	PickingLocalOnly:        localOnlyPicking{},
+}
+
+func ParsePickingStrategy(name string) (PickingStrategy, error) {
+	strategy, ok := pickingStrategies[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrUnknownPickingStrategy, name)
+	}
+	return strategy, nil
+}
+
+func pickInOrder(sources []StockSource, quantity int) []Pick {
+	var result []Pick
+	for _, source := range sources {
+		if quantity == 0 {
+			break
+		}
+		toPick := min(source.Quantity, quantity)
+		if toPick <= 0 {
+			continue
+		}
+		result = append(result, Pick{WarehouseName: source.WarehouseName, LotNumber: source.LotNumber, Quantity: toPick})
+		quantity -= toPick
+	}
+	return result
+}
+
+type requestedFirstPicking struct{}
+
+func (requestedFirstPicking) Name() string {
+	return PickingRequestedFirst
+}
+
+func (requestedFirstPicking) OrdersByReceipt() bool {
+	return false
+}
+
+func (requestedFirstPicking) Pick(requested string, sources []StockSource, quantity int) []Pick {
+	return pickInOrder(sources, quantity)
+}
+
+type firstExpiringPicking struct{}
+
+func (firstExpiringPicking) Name() string {
+	return PickingFirstExpiring
+}
+
+func (firstExpiringPicking) OrdersByReceipt() bool {
+	return true
+}
+
+// Pick takes the stock that expires first, stock without an expiration date goes last and ties go to the oldest stock.
+func (firstExpiringPicking) Pick(requested string, sources []StockSource, quantity int) []Pick {
+	sorted := slices.Clone(sources)
+	slices.SortStableFunc(sorted, compareExpiring)
+	return pickInOrder(sorted, quantity)
+}
+
+func compareExpiring(a, b StockSource) int {
+	if a.ExpiresAt.IsZero() != b.ExpiresAt.IsZero() {
+		if a.ExpiresAt.IsZero() {
+			return 1
+		}
+		return -1
+	}
+	return cmp.Or(a.ExpiresAt.Compare(b.ExpiresAt), a.ReceivedAt.Compare(b.ReceivedAt))
+}
+
+type firstReceivedPicking struct{}
+
+func (firstReceivedPicking) Name() string {
+	return PickingFirstReceived
+}
+
+func (firstReceivedPicking) OrdersByReceipt() bool {
+	return true
+}
+
+func (firstReceivedPicking) Pick(requested string, sources []StockSource, quantity int) []Pick {
+	sorted := slices.Clone(sources)
+	slices.SortStableFunc(sorted, func(a, b StockSource) int {
+		return a.ReceivedAt.Compare(b.ReceivedAt)
+	})
+	return pickInOrder(sorted, quantity)
+}
+
+type fewestWarehousesPicking struct{}
+
+func (fewestWarehousesPicking) Name() string {
+	return PickingFewestWarehouses
+}
+
+func (fewestWarehousesPicking) OrdersByReceipt() bool {
+	return false
+}
+
+// Pick drains the warehouses holding the most first, which touches the fewest of them, ties go to the requested warehouse.
+func (fewestWarehousesPicking) Pick(requested string, sources []StockSource, quantity int) []Pick {
+	totals := map[string]int{}
+	var warehouseNames []string
+	for _, source := range sources {
+		if _, ok := totals[source.WarehouseName]; !ok {
+			warehouseNames = append(warehouseNames, source.WarehouseName)
+		}
+		totals[source.WarehouseName] += source.Quantity
+	}
+	if totals[requested] >= quantity {
+		return pickInOrder(sourcesOf(sources, requested), quantity)
+	}
+	slices.SortStableFunc(warehouseNames, func(a, b string) int {
+		return cmp.Compare(totals[b], totals[a])
+	})
+	var sorted []StockSource
+	for _, warehouseName := range warehouseNames {
+		sorted = append(sorted, sourcesOf(sources, warehouseName)...)
+	}
+	return pickInOrder(sorted, quantity)
+}
+
+type localOnlyPicking struct{}
+
+func (localOnlyPicking) Name() string {
+	return PickingLocalOnly
+}
+
+func (localOnlyPicking) OrdersByReceipt() bool {
+	return false
+}
+
+func (localOnlyPicking) Pick(requested string, sources []StockSource, quantity int) []Pick {
+	return pickInOrder(sourcesOf(sources, requested), quantity)
+}
+
+func sourcesOf(sources []StockSource, warehouseName string) []StockSource {
+	var result []StockSource
+	for _, source := range sources {
+		if source.WarehouseName == warehouseName {
+			result = append(result, source)
+		}
+	}
+	return result
+}
+
+// stockSources lists the pickable stock of a SKU with the requested warehouse first, quarantined stock is left out.
+// The receipt times of stock outside of lots are only looked up withReceipts.
+func stockSources(trx store.Transaction, requested string, sku string, withReceipts bool) ([]StockSource, error) {
+	warehouseProducts, err := trx.GetWarehouseProductsBySkuOrderedFirstWithName(requested, sku)
+	if err != nil {
+		return nil, err
+	}
+	var receivedAt map[string]time.Time
+	if withReceipts {
+		if receivedAt, err = receiptTimes(trx, sku, warehouseProducts); err != nil {
+			return nil, err
+		}
+	}
+	expiresAt, err := expirationOf(trx, sku)
+	if err != nil {
+		return nil, err
+	}
+	lots, err := trx.GetWarehouseLots("", sku)
+	if err != nil {
+		return nil, err
+	}
+	quarantined, err := trx.GetQuarantinedStock("", sku)
+	if err != nil {
+		return nil, err
+	}
+	var result []StockSource
+	for _, warehouseProduct := range warehouseProducts {
+		var warehouseSources []StockSource
+		untracked := warehouseProduct.Quantity
+		for _, lot := range lots {
+			if lot.WarehouseName != warehouseProduct.WarehouseName {
+				continue
+			}
+			warehouseSources = append(warehouseSources, StockSource{
+				WarehouseName: lot.WarehouseName,
+				LotNumber:     lot.Lot.Number,
+				Quantity:      lot.Quantity,
+				ReceivedAt:    lot.Lot.ReceivedAt,
+				ExpiresAt:     lot.Lot.ExpirationDate,
+			})
+			untracked -= lot.Quantity
+		}
+		for _, stock := range quarantined {
+			if stock.WarehouseName == warehouseProduct.WarehouseName {
+				untracked -= stock.Quantity
+			}
+		}
+		if untracked > 0 {
+			warehouseSources = append(warehouseSources, StockSource{
+				WarehouseName: warehouseProduct.WarehouseName,
+				Quantity:      untracked,
+				ReceivedAt:    receivedAt[warehouseProduct.WarehouseName],
+				ExpiresAt:     expiresAt,
+			})
+		}
+		slices.SortStableFunc(warehouseSources, compareExpiring)
+		result = append(result, warehouseSources...)
+	}
+	return result, nil
+}
+
+// receiptTimes replays the ledger assuming every warehouse ships its oldest units first, so the stock left is the
+// last units received. The ledger is walked back from the latest snapshot first and further only when needed.
+func receiptTimes(trx store.Transaction, sku string, warehouseProducts []domain.WarehouseProduct) (map[string]time.Time, error) {
+	since, err := trx.GetLatestSnapshotTime()
+	if err != nil {
+		return nil, err
+	}
+	walk := newReceiptWalk(warehouseProducts)
+	if !since.IsZero() {
+		movements, err := trx.GetMovements(domain.MovementFilter{Sku: sku, From: since})
+		if err != nil {
+			return nil, err
+		}
+		if walk.back(movements) {
+			return walk.receivedAt, nil
+		}
+	}
+	movements, err := trx.GetMovements(domain.MovementFilter{Sku: sku, To: since})
+	if err != nil {
+		return nil, err
+	}
+	walk.back(movements)
+	return walk.receivedAt, nil
+}
+
+// receiptWalk goes back through the receipts of a SKU until it has found the units each warehouse holds.
+// Renames are followed to the old name, so stock keeps its age.
+type receiptWalk struct {
+	// missing counts the units not found yet by the name the warehouse had at that point of the ledger
+	missing map[string]int
+	// current maps those names to the name the warehouse has now
+	current    map[string]string
+	renamedTo  string
+	receivedAt map[string]time.Time
+}
+
+func newReceiptWalk(warehouseProducts []domain.WarehouseProduct) *receiptWalk {
+	walk := &receiptWalk{missing: map[string]int{}, current: map[string]string{}, receivedAt: map[string]time.Time{}}
+	for _, warehouseProduct := range warehouseProducts {
+		if warehouseProduct.Quantity > 0 {
+			walk.missing[warehouseProduct.WarehouseName] = warehouseProduct.Quantity
+			walk.current[warehouseProduct.WarehouseName] = warehouseProduct.WarehouseName
+		}
+	}
+	return walk
+}
+
+// back walks movements in reverse order and reports whether all units were found. Until then receivedAt holds the
+// oldest receipt seen.
+func (w *receiptWalk) back(movements []domain.Movement) bool {
+	for i := len(movements) - 1; i >= 0 && len(w.missing) > 0; i-- {
+		movement := movements[i]
+		name := movement.WarehouseName
+		switch {
+		case movement.Reason == domain.MovementRenameIn:
+			w.renamedTo = name
+		case movement.Reason == domain.MovementRenameOut:
+			if missing, ok := w.missing[w.renamedTo]; ok {
+				w.missing[name], w.current[name] = missing, w.current[w.renamedTo]
+				delete(w.missing, w.renamedTo)
+				delete(w.current, w.renamedTo)
+			}
+			w.renamedTo = ""
+		case movement.Delta > 0:
+			missing, ok := w.missing[name]
+			if !ok {
+				continue
+			}
+			w.receivedAt[w.current[name]] = movement.Timestamp
+			if missing -= movement.Delta; missing > 0 {
+				w.missing[name] = missing
+			} else {
+				delete(w.missing, name)
+				delete(w.current, name)
+			}
+		}
+	}
+	return len(w.missing) == 0
+}
+
+func expirationOf(trx store.Transaction, sku string) (time.Time, error) {
+	product, err := trx.GetCatalogProduct(sku)
+	if errors.Is(err, store.ErrNotFound) {
+		return time.Time{}, nil
+	}
+	if err != nil {
+		return time.Time{}, err
+	}
+	return producttype.ExpirationDate(product), nil
+}
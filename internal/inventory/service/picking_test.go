@@ -0,0 +1,71 @@
+package service
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/kijevigombooc/inventory-manager/internal/inventory/store/domain"
+)
+
+func TestPickingStrategies(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2024, 1, d, 0, 0, 0, 0, time.UTC) }
+	sources := []StockSource{
+		{WarehouseName: "A", Quantity: 2, ReceivedAt: day(3), ExpiresAt: day(20)},
+		{WarehouseName: "B", Quantity: 6, ReceivedAt: day(2)},
+		{WarehouseName: "C", Quantity: 3, ReceivedAt: day(1), ExpiresAt: day(10)},
+	}
+	tests := []struct {
+		strategy string
+		quantity int
+		expected []Pick
+	}{
+		{PickingRequestedFirst, 4, []Pick{{"A", "", 2}, {"B", "", 2}}},
+		{PickingFirstExpiring, 4, []Pick{{"C", "", 3}, {"A", "", 1}}},
+		{PickingFirstReceived, 4, []Pick{{"C", "", 3}, {"B", "", 1}}},
+		{PickingFewestWarehouses, 4, []Pick{{"B", "", 4}}},
+		{PickingFewestWarehouses, 2, []Pick{{"A", "", 2}}},
+		{PickingLocalOnly, 4, []Pick{{"A", "", 2}}},
+	}
+	for _, test := range tests {
+		strategy, err := ParsePickingStrategy(test.strategy)
+		if err != nil {
+			t.Fatalf("Error parsing picking strategy: %v", err)
+		}
+		picks := strategy.Pick("A", sources, test.quantity)
+		if !reflect.DeepEqual(picks, test.expected) {
+			t.Fatalf("%s picking of %d should be %v, got %v", test.strategy, test.quantity, test.expected, picks)
+		}
+	}
+}
+
+func TestParsePickingStrategyErrorUnknown(t *testing.T) {
+	if _, err := ParsePickingStrategy("random"); !errors.Is(err, ErrUnknownPickingStrategy) {
+		t.Fatalf("Expected ErrUnknownPickingStrategy, got %v", err)
+	}
+}
+
+func TestReceiptWalk(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2024, 1, d, 0, 0, 0, 0, time.UTC) }
+	movements := []domain.Movement{
+		{Timestamp: day(1), WarehouseName: "A", Delta: 2, Reason: domain.MovementInsert},
+		{Timestamp: day(2), WarehouseName: "A", Delta: 3, Reason: domain.MovementInsert},
+		{Timestamp: day(3), WarehouseName: "B", Delta: 1, Reason: domain.MovementInsert},
+		{Timestamp: day(4), WarehouseName: "A", Delta: -3, Reason: domain.MovementRemove},
+		{Timestamp: day(5), WarehouseName: "B", Delta: -1, Reason: domain.MovementRemove},
+		{Timestamp: day(6), WarehouseName: "A", Delta: -2, Reason: domain.MovementRenameOut},
+		{Timestamp: day(6), WarehouseName: "C", Delta: 2, Reason: domain.MovementRenameIn},
+	}
+	walk := newReceiptWalk([]domain.WarehouseProduct{{WarehouseName: "C", Quantity: 2}})
+	if walk.back(movements[4:]) {
+		t.Fatalf("Walking back the later movements should not find the receipts")
+	}
+	if !walk.back(movements[:4]) {
+		t.Fatalf("Walking back the whole ledger should find the receipts")
+	}
+	expected := map[string]time.Time{"C": day(2)}
+	if !reflect.DeepEqual(walk.receivedAt, expected) {
+		t.Fatalf("Oldest receipts should be %v, got %v", expected, walk.receivedAt)
+	}
+}
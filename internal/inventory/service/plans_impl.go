@@ -0,0 +1,85 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"slices"
+
+	"github.com/kijevigombooc/inventory-manager/internal/inventory/handler/dto"
+	"github.com/kijevigombooc/inventory-manager/internal/inventory/store"
+)
+
+// PlanInsert runs InsertProducts in a transaction that is rolled back, a lack of capacity is reported as a shortfall.
+func (s *inventoryService) PlanInsert(ctx context.Context, warehouse string, product dto.IProduct, quantity int, options dto.InsertOptions) (dto.Plan, error) {
+	allocation, err := s.allocationStrategy(options.AllocationStrategy)
+	if err != nil {
+		return dto.Plan{}, err
+	}
+	j := s.newJournal(ctx)
+	lot, err := lotDtoToEntity(options.Lot, product.GetBaseProduct().SKU, j)
+	if err != nil {
+		return dto.Plan{}, err
+	}
+	var plan dto.Plan
+	err = s.runner.RunRolledBack(ctx, func(trx store.Transaction) error {
+		placed, err := insertProducts(trx, j, allocation, warehouse, product, quantity, lot, options.Serials)
+		if err != nil && !errors.Is(err, ErrInsufficientCapacity) {
+			return err
+		}
+		plan, err = newPlan(trx, quantity, placed)
+		return err
+	})
+	return plan, err
+}
+
+// PlanRemove runs RemoveProducts in a transaction that is rolled back, missing stock is reported as a shortfall.
+func (s *inventoryService) PlanRemove(ctx context.Context, warehouseName string, sku string, quantity int, options dto.RemoveOptions) (dto.Plan, error) {
+	picking, err := s.pickingStrategy(options.PickingStrategy)
+	if err != nil {
+		return dto.Plan{}, err
+	}
+	j := s.newJournal(ctx)
+	var plan dto.Plan
+	err = s.runner.RunRolledBack(ctx, func(trx store.Transaction) error {
+		picked, err := removeProducts(trx, j, picking, warehouseName, sku, quantity, options.Serials)
+		if err != nil && !errors.Is(err, ErrInsufficientStock) {
+			return err
+		}
+		// picks from several lots of a warehouse are planned as one quantity
+		var allocations []Allocation
+		for _, pick := range picked {
+			i := slices.IndexFunc(allocations, func(allocation Allocation) bool {
+				return allocation.WarehouseName == pick.WarehouseName
+			})
+			if i == -1 {
+				allocations = append(allocations, Allocation{WarehouseName: pick.WarehouseName})
+				i = len(allocations) - 1
+			}
+			allocations[i].Quantity += pick.Quantity
+		}
+		plan, err = newPlan(trx, quantity, allocations)
+		return err
+	})
+	return plan, err
+}
+
+func newPlan(trx store.Transaction, quantity int, allocations []Allocation) (dto.Plan, error) {
+	plan := dto.Plan{Quantity: quantity, Shortfall: quantity, Warehouses: []dto.PlannedQuantity{}}
+	for _, allocation := range allocations {
+		warehouse, err := getWarehouse(trx, allocation.WarehouseName)
+		if err != nil {
+			return dto.Plan{}, err
+		}
+		usedCapacity, err := trx.GetUsedCapacity(warehouse.Name)
+		if err != nil {
+			return dto.Plan{}, err
+		}
+		plan.Shortfall -= allocation.Quantity
+		plan.Warehouses = append(plan.Warehouses, dto.PlannedQuantity{
+			WarehouseName:     warehouse.Name,
+			Quantity:          allocation.Quantity,
+			RemainingCapacity: warehouse.Capacity - usedCapacity,
+		})
+	}
+	return plan, nil
+}
@@ -0,0 +1,121 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+
+	"github.com/kijevigombooc/inventory-manager/internal/inventory/handler/dto"
+	"github.com/kijevigombooc/inventory-manager/internal/inventory/store"
+	"github.com/kijevigombooc/inventory-manager/internal/inventory/store/domain"
+	"github.com/kijevigombooc/inventory-manager/internal/utils"
+)
+
+func (s *inventoryService) GetProducts(ctx context.Context) ([]dto.IProduct, error) {
+	var result []dto.IProduct
+	err := s.runner.Run(ctx, func(trx store.Transaction) error {
+		products, err := trx.GetCatalogProducts()
+		if err != nil {
+			return err
+		}
+		result, err = utils.MapErrored(products, productEntityToDto)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (s *inventoryService) CreateProduct(ctx context.Context, product dto.IProduct) error {
+	productEntity, err := productDtoToEntity(product)
+	if err != nil {
+		return err
+	}
+	return s.runner.Run(ctx, func(trx store.Transaction) error {
+		if err := checkMatchesBrand(trx, productEntity.GetBaseProduct().Brand); err != nil {
+			return err
+		}
+		err := trx.InsertCatalogProduct(productEntity)
+		if errors.Is(err, store.ErrAlreadyExists) {
+			return fmt.Errorf("%w: %s", ErrDuplicateProduct, productEntity.GetBaseProduct().SKU)
+		}
+		return err
+	})
+}
+
+func (s *inventoryService) GetProduct(ctx context.Context, sku string) (dto.IProduct, error) {
+	var result dto.IProduct
+	err := s.runner.Run(ctx, func(trx store.Transaction) error {
+		product, err := getCatalogProduct(trx, sku)
+		if err != nil {
+			return err
+		}
+		result, err = productEntityToDto(product)
+		return err
+	})
+	return result, err
+}
+
+// UpdateProduct replaces the catalog record, the product type of a SKU cannot change.
+func (s *inventoryService) UpdateProduct(ctx context.Context, product dto.IProduct) error {
+	productEntity, err := productDtoToEntity(product)
+	if err != nil {
+		return err
+	}
+	sku := productEntity.GetBaseProduct().SKU
+	return s.runner.Run(ctx, func(trx store.Transaction) error {
+		existing, err := getCatalogProduct(trx, sku)
+		if err != nil {
+			return err
+		}
+		if existing.GetType() != productEntity.GetType() {
+			return fmt.Errorf("%w: %s is %s", ErrSkuTypeConflict, sku, existing.GetType())
+		}
+		if err := checkMatchesBrand(trx, productEntity.GetBaseProduct().Brand); err != nil {
+			return err
+		}
+		return trx.UpdateCatalogProduct(productEntity)
+	})
+}
+
+func (s *inventoryService) DeleteProduct(ctx context.Context, sku string) error {
+	return s.runner.Run(ctx, func(trx store.Transaction) error {
+		if _, err := getCatalogProduct(trx, sku); err != nil {
+			return err
+		}
+		warehouseProducts, err := trx.GetWarehouseProductsBySkuOrderedFirstWithName("", sku)
+		if err != nil {
+			return err
+		}
+		quantity := utils.Reduce(warehouseProducts, 0, func(sum int, warehouseProduct domain.WarehouseProduct) int {
+			return sum + warehouseProduct.Quantity
+		})
+		if quantity > 0 {
+			return fmt.Errorf("%w: %d of %s in warehouses", ErrProductInStock, quantity, sku)
+		}
+		return trx.DeleteCatalogProduct(sku)
+	})
+}
+
+func getCatalogProduct(trx store.Transaction, sku string) (domain.IProduct, error) {
+	product, err := trx.GetCatalogProduct(sku)
+	if errors.Is(err, store.ErrNotFound) {
+		return nil, fmt.Errorf("%w: %s", ErrProductNotFound, sku)
+	}
+	return product, err
+}
+
+// checkMatchesCatalog rejects stock whose attributes differ from the catalog record instead of silently keeping the old ones.
+func checkMatchesCatalog(trx store.Transaction, product domain.IProduct) error {
+	sku := product.GetBaseProduct().SKU
+	existing, err := getCatalogProduct(trx, sku)
+	if err != nil {
+		return err
+	}
+	if !reflect.DeepEqual(existing, product) {
+		return fmt.Errorf("%w: %s is stored with different attributes, update it through the product catalog", ErrProductMismatch, sku)
+	}
+	return nil
+}
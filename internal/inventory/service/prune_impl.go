@@ -0,0 +1,28 @@
+package service
+
+import (
+	"context"
+
+	"github.com/kijevigombooc/inventory-manager/internal/inventory/handler/dto"
+	"github.com/kijevigombooc/inventory-manager/internal/inventory/store"
+)
+
+// Prune deletes products without stock, then the type rows and brands nothing refers to any more.
+func (s *inventoryService) Prune(ctx context.Context) (dto.PruneResult, error) {
+	var result dto.PruneResult
+	err := s.runner.Run(ctx, func(trx store.Transaction) error {
+		var err error
+		if result.Products, err = trx.DeleteUnusedProducts(); err != nil {
+			return err
+		}
+		if result.ProductAttributes, err = trx.DeleteOrphanedProductAttributes(); err != nil {
+			return err
+		}
+		result.Brands, err = trx.DeleteUnusedBrands()
+		return err
+	})
+	if err != nil {
+		return dto.PruneResult{}, err
+	}
+	return result, nil
+}
@@ -0,0 +1,239 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/kijevigombooc/inventory-manager/internal/inventory/handler/dto"
+	"github.com/kijevigombooc/inventory-manager/internal/inventory/producttype"
+	"github.com/kijevigombooc/inventory-manager/internal/inventory/store"
+	"github.com/kijevigombooc/inventory-manager/internal/inventory/store/domain"
+)
+
+// GetSerial shows where a unit is and where it has been, the warranty runs from the day the unit was first received.
+func (s *inventoryService) GetSerial(ctx context.Context, number string) (dto.Serial, error) {
+	var result dto.Serial
+	err := s.runner.Run(ctx, func(trx store.Transaction) error {
+		serial, err := trx.GetSerial(number)
+		if errors.Is(err, store.ErrNotFound) {
+			return fmt.Errorf("%w: %s", ErrSerialNotFound, number)
+		}
+		if err != nil {
+			return err
+		}
+		events, err := trx.GetSerialEvents(number)
+		if err != nil {
+			return err
+		}
+		result = dto.Serial{
+			Serial:        serial.Number,
+			Sku:           serial.Sku,
+			WarehouseName: serial.WarehouseName,
+			InStock:       serial.WarehouseName != "",
+			ReceivedAt:    serial.ReceivedAt,
+			History:       []dto.SerialEvent{},
+		}
+		for _, event := range events {
+			result.History = append(result.History, dto.SerialEvent{
+				Timestamp:     event.Timestamp,
+				WarehouseName: event.WarehouseName,
+				Reason:        string(event.Reason),
+				CorrelationID: event.CorrelationID,
+				Actor:         event.Actor,
+			})
+		}
+		// the warranty is unknown once the product was pruned from the catalog
+		product, err := trx.GetCatalogProduct(serial.Sku)
+		if errors.Is(err, store.ErrNotFound) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if warranty, ok := producttype.Warranty(product); ok {
+			result.WarrantyEndsOn = &dto.Date{Time: warranty.AddTo(startOfDay(serial.ReceivedAt))}
+		}
+		return nil
+	})
+	return result, err
+}
+
+func checkSerialNumbers(numbers []string, quantity int) error {
+	if len(numbers) != quantity {
+		return fmt.Errorf("%w: %d serial numbers for %d units", ErrInvalidSerial, len(numbers), quantity)
+	}
+	for i, number := range numbers {
+		if number == "" {
+			return fmt.Errorf("%w: serial number %d is empty", ErrInvalidSerial, i)
+		}
+		if slices.Contains(numbers[:i], number) {
+			return fmt.Errorf("%w: %s is listed twice", ErrInvalidSerial, number)
+		}
+	}
+	return nil
+}
+
+// checkNewSerials rejects units that are already in stock, a removed unit can come back as the same SKU.
+func checkNewSerials(trx store.Transaction, product domain.IProduct, numbers []string, quantity int) error {
+	sku := product.GetBaseProduct().SKU
+	if !producttype.HasSerials(product.GetType()) {
+		return fmt.Errorf("%w: %s has no serial numbers", ErrInvalidSerial, sku)
+	}
+	if err := checkSerialNumbers(numbers, quantity); err != nil {
+		return err
+	}
+	for _, number := range numbers {
+		serial, err := trx.GetSerial(number)
+		if errors.Is(err, store.ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		if serial.WarehouseName != "" {
+			return fmt.Errorf("%w: %s is in %s", ErrDuplicateSerial, number, serial.WarehouseName)
+		}
+		if serial.Sku != sku {
+			return fmt.Errorf("%w: %s belongs to %s", ErrDuplicateSerial, number, serial.Sku)
+		}
+	}
+	return nil
+}
+
+func receiveSerials(trx store.Transaction, j journal, requested string, sku string, numbers []string, placed []Allocation) error {
+	for _, planned := range placed {
+		reason := domain.MovementInsert
+		if planned.WarehouseName != requested {
+			reason = domain.MovementInsertOverflow
+		}
+		for _, number := range numbers[:planned.Quantity] {
+			serial, err := trx.GetSerial(number)
+			if errors.Is(err, store.ErrNotFound) {
+				serial = domain.Serial{Number: number, Sku: sku, WarehouseName: planned.WarehouseName, ReceivedAt: j.timestamp}
+				err = trx.InsertSerial(serial)
+			} else if err == nil {
+				serial.WarehouseName = planned.WarehouseName
+				err = trx.UpdateSerial(serial)
+			}
+			if err != nil {
+				return err
+			}
+			if err := j.recordSerial(trx, number, planned.WarehouseName, reason); err != nil {
+				return err
+			}
+		}
+		numbers = numbers[planned.Quantity:]
+	}
+	return nil
+}
+
+func removeSerials(trx store.Transaction, j journal, warehouseName string, sku string, numbers []string) ([]Pick, error) {
+	var serials []domain.Serial
+	var picked []Pick
+	for _, number := range numbers {
+		serial, err := trx.GetSerial(number)
+		if err != nil && !errors.Is(err, store.ErrNotFound) {
+			return nil, err
+		}
+		if err != nil || serial.Sku != sku || serial.WarehouseName == "" {
+			return nil, fmt.Errorf("%w: %s of %s is not in stock", ErrSerialNotFound, number, sku)
+		}
+		serials = append(serials, serial)
+		i := slices.IndexFunc(picked, func(pick Pick) bool {
+			return pick.WarehouseName == serial.WarehouseName
+		})
+		if i == -1 {
+			picked = append(picked, Pick{WarehouseName: serial.WarehouseName})
+			i = len(picked) - 1
+		}
+		picked[i].Quantity++
+	}
+	for _, pick := range picked {
+		if _, err := trx.RemoveProduct(pick.WarehouseName, sku, pick.Quantity); err != nil {
+			return nil, err
+		}
+		if err := j.record(trx, pick.WarehouseName, sku, -pick.Quantity, removeReason(warehouseName, pick.WarehouseName)); err != nil {
+			return nil, err
+		}
+	}
+	for _, serial := range serials {
+		from := serial.WarehouseName
+		serial.WarehouseName = ""
+		if err := trx.UpdateSerial(serial); err != nil {
+			return nil, err
+		}
+		if err := j.recordSerial(trx, serial.Number, from, removeReason(warehouseName, from)); err != nil {
+			return nil, err
+		}
+	}
+	return picked, nil
+}
+
+func removeReason(requested string, warehouseName string) domain.MovementReason {
+	if warehouseName != requested {
+		return domain.MovementRemoveOverflow
+	}
+	return domain.MovementRemove
+}
+
+// dropSerials removes the units that no longer fit into the stock of the warehouse after an untargeted removal,
+// so products without serial numbers go first and then the earliest received units.
+func dropSerials(trx store.Transaction, j journal, warehouseName string, sku string, reason domain.MovementReason) error {
+	serials, err := trx.GetWarehouseSerials(warehouseName, sku)
+	if err != nil || len(serials) == 0 {
+		return err
+	}
+	quantity, err := warehouseQuantity(trx, warehouseName, sku)
+	if err != nil {
+		return err
+	}
+	for _, serial := range serials[:max(len(serials)-quantity, 0)] {
+		serial.WarehouseName = ""
+		if err := trx.UpdateSerial(serial); err != nil {
+			return err
+		}
+		if err := j.recordSerial(trx, serial.Number, warehouseName, reason); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// moveSerials moves the units along with quantity products of a SKU, products without serial numbers go first.
+// The warehouse quantities are left to the caller.
+func moveSerials(trx store.Transaction, j journal, from string, to string, sku string, quantity int, reason domain.MovementReason) error {
+	serials, err := trx.GetWarehouseSerials(from, sku)
+	if err != nil || len(serials) == 0 {
+		return err
+	}
+	fromQuantity, err := warehouseQuantity(trx, from, sku)
+	if err != nil {
+		return err
+	}
+	untracked := fromQuantity - len(serials)
+	for _, serial := range serials[:min(max(quantity-untracked, 0), len(serials))] {
+		serial.WarehouseName = to
+		if err := trx.UpdateSerial(serial); err != nil {
+			return err
+		}
+		if err := j.recordSerial(trx, serial.Number, to, reason); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func warehouseQuantity(trx store.Transaction, warehouseName string, sku string) (int, error) {
+	warehouseProducts, err := trx.GetWarehouseProductsBySkuOrderedFirstWithName(warehouseName, sku)
+	if err != nil {
+		return 0, err
+	}
+	for _, warehouseProduct := range warehouseProducts {
+		if warehouseProduct.WarehouseName == warehouseName && warehouseProduct.Sku == sku {
+			return warehouseProduct.Quantity, nil
+		}
+	}
+	return 0, nil
+}
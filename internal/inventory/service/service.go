@@ -1,10 +1,40 @@
 package service
 
-import "github.com/kijevigombooc/inventory-manager/internal/inventory/handler/dto"
+import (
+	"context"
+	"time"
+
+	"github.com/kijevigombooc/inventory-manager/internal/inventory/handler/dto"
+)
 
 type Service interface {
-	GetWarehouses() ([]dto.WarehouseDetail, error)
-	CreateWarehouse(warehouse dto.Warehouse) error
-	InsertProducts(warehouse string, product dto.IProduct, quantity int) error
-	RemoveProducts(warehouseName string, sku string, quantity int) error
+	GetWarehouses(ctx context.Context) ([]dto.WarehouseDetail, error)
+	CreateWarehouse(ctx context.Context, warehouse dto.Warehouse) error
+	GetWarehouse(ctx context.Context, name string) (dto.WarehouseDetail, error)
+	UpdateWarehouse(ctx context.Context, name string, update dto.UpdateWarehouseRequest) (dto.Warehouse, error)
+	DeleteWarehouse(ctx context.Context, name string, relocate bool) error
+	InsertProducts(ctx context.Context, warehouse string, product dto.IProduct, quantity int, options dto.InsertOptions) error
+	RemoveProducts(ctx context.Context, warehouseName string, sku string, quantity int, options dto.RemoveOptions) error
+	PlanInsert(ctx context.Context, warehouse string, product dto.IProduct, quantity int, options dto.InsertOptions) (dto.Plan, error)
+	PlanRemove(ctx context.Context, warehouseName string, sku string, quantity int, options dto.RemoveOptions) (dto.Plan, error)
+	TransferProducts(ctx context.Context, from string, to string, sku string, quantity int) error
+	GetMovements(ctx context.Context, filter dto.MovementFilter) ([]dto.Movement, error)
+	GetWarehousesAsOf(ctx context.Context, asOf time.Time) ([]dto.WarehouseDetail, error)
+	GetStock(ctx context.Context, sku string, asOf time.Time) (dto.SkuStock, error)
+	TakeSnapshot(ctx context.Context) error
+	Prune(ctx context.Context) (dto.PruneResult, error)
+	GetExpiringStock(ctx context.Context, days int) ([]dto.ExpiringStock, error)
+	QuarantineExpired(ctx context.Context) (int, error)
+	DiscardQuarantined(ctx context.Context, warehouseName string) error
+	GetSerial(ctx context.Context, serial string) (dto.Serial, error)
+	GetProducts(ctx context.Context) ([]dto.IProduct, error)
+	CreateProduct(ctx context.Context, product dto.IProduct) error
+	GetProduct(ctx context.Context, sku string) (dto.IProduct, error)
+	UpdateProduct(ctx context.Context, product dto.IProduct) error
+	DeleteProduct(ctx context.Context, sku string) error
+	GetBrands(ctx context.Context) ([]dto.BrandDetail, error)
+	CreateBrand(ctx context.Context, brand dto.Brand) error
+	GetBrand(ctx context.Context, name string) (dto.BrandDetail, error)
+	UpdateBrand(ctx context.Context, brand dto.Brand) error
+	DeleteBrand(ctx context.Context, name string) error
 }
@@ -1,138 +1,282 @@
 package service
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"time"
 
 	"github.com/kijevigombooc/inventory-manager/internal/inventory/handler/dto"
+	"github.com/kijevigombooc/inventory-manager/internal/inventory/producttype"
 	"github.com/kijevigombooc/inventory-manager/internal/inventory/store"
 	"github.com/kijevigombooc/inventory-manager/internal/inventory/store/domain"
-	"github.com/kijevigombooc/inventory-manager/internal/utils"
 )
 
-func NewInventoryService(store store.Store) *inventoryService {
-	return &inventoryService{store: store}
+func NewInventoryService(s store.Store, options ...Option) *inventoryService {
+	service := &inventoryService{
+		store:      s,
+		runner:     store.NewTransactionRunner(s, store.DefaultRetryPolicy),
+		now:        time.Now,
+		allocation: requestedFirstAllocation{},
+		picking:    requestedFirstPicking{},
+	}
+	for _, option := range options {
+		option(service)
+	}
+	return service
 }
 
-type inventoryService struct {
-	store store.Store
+type Option func(*inventoryService)
+
+func WithAllocationStrategy(strategy AllocationStrategy) Option {
+	return func(s *inventoryService) {
+		s.allocation = strategy
+	}
 }
 
-func (s *inventoryService) GetWarehouses() ([]dto.WarehouseDetail, error) {
-	trx := s.store.BeginTransaction()
-	defer trx.EndTransaction()
-	warehouses, err := trx.GetWarehouses()
-	if err != nil {
-		return nil, err
+func WithPickingStrategy(strategy PickingStrategy) Option {
+	return func(s *inventoryService) {
+		s.picking = strategy
 	}
-	result := []dto.WarehouseDetail{}
-	for _, warehouse := range warehouses {
-		productEntities, err := trx.GetProductsByWarehouse(warehouse.Name)
+}
+
+type inventoryService struct {
+	store      store.Store
+	runner     *store.TransactionRunner
+	now        func() time.Time
+	allocation AllocationStrategy
+	picking    PickingStrategy
+}
+
+func (s *inventoryService) GetWarehouses(ctx context.Context) ([]dto.WarehouseDetail, error) {
+	var result []dto.WarehouseDetail
+	err := s.runner.Run(ctx, func(trx store.Transaction) error {
+		warehouses, err := trx.GetWarehouses()
 		if err != nil {
-			return nil, err
+			return err
 		}
-		productDtos, err := utils.MapErrored(productEntities, productWithQuantityEntityToDto)
-		if err != nil {
-			return nil, err
+		result = []dto.WarehouseDetail{}
+		for _, warehouse := range warehouses {
+			detail, err := warehouseDetail(trx, warehouse)
+			if err != nil {
+				return err
+			}
+			result = append(result, detail)
 		}
-		result = append(result, dto.WarehouseDetail{
-			Warehouse: warehouseEntityToDto(warehouse),
-			Products:  productDtos,
-		})
-	}
-	if err := trx.CommitTransaction(); err != nil {
+		return nil
+	})
+	if err != nil {
 		return nil, err
 	}
 	return result, nil
 }
 
-func (s *inventoryService) CreateWarehouse(warehouse dto.Warehouse) error {
-	trx := s.store.BeginTransaction()
-	defer trx.EndTransaction()
-	if err := trx.InsertWarehouse(domain.Warehouse(warehouse)); err != nil {
+func (s *inventoryService) CreateWarehouse(ctx context.Context, warehouse dto.Warehouse) error {
+	return s.runner.Run(ctx, func(trx store.Transaction) error {
+		err := trx.InsertWarehouse(domain.Warehouse(warehouse))
+		if errors.Is(err, store.ErrAlreadyExists) {
+			return fmt.Errorf("%w: %s", ErrDuplicateWarehouse, warehouse.Name)
+		}
+		return err
+	})
+}
+
+func (s *inventoryService) InsertProducts(ctx context.Context, warehouse string, product dto.IProduct, quantity int, options dto.InsertOptions) error {
+	allocation, err := s.allocationStrategy(options.AllocationStrategy)
+	if err != nil {
 		return err
 	}
-	if err := trx.CommitTransaction(); err != nil {
+	j := s.newJournal(ctx)
+	lot, err := lotDtoToEntity(options.Lot, product.GetBaseProduct().SKU, j)
+	if err != nil {
 		return err
 	}
-	return nil
+	return s.runner.Run(ctx, func(trx store.Transaction) error {
+		_, err := insertProducts(trx, j, allocation, warehouse, product, quantity, lot, options.Serials)
+		return err
+	})
 }
 
-func (s *inventoryService) InsertProducts(warehouse string, product dto.IProduct, quantity int) error {
-	trx := s.store.BeginTransaction()
-	defer trx.EndTransaction()
-	warehouses, err := trx.GetWarehousesOrderedFirstWithName(warehouse)
+func (s *inventoryService) allocationStrategy(name string) (AllocationStrategy, error) {
+	if name == "" {
+		return s.allocation, nil
+	}
+	return ParseAllocationStrategy(name)
+}
+
+func (s *inventoryService) RemoveProducts(ctx context.Context, warehouseName string, sku string, quantity int, options dto.RemoveOptions) error {
+	picking, err := s.pickingStrategy(options.PickingStrategy)
 	if err != nil {
 		return err
 	}
+	j := s.newJournal(ctx)
+	return s.runner.Run(ctx, func(trx store.Transaction) error {
+		_, err := removeProducts(trx, j, picking, warehouseName, sku, quantity, options.Serials)
+		return err
+	})
+}
+
+func (s *inventoryService) pickingStrategy(name string) (PickingStrategy, error) {
+	if name == "" {
+		return s.picking, nil
+	}
+	return ParsePickingStrategy(name)
+}
+
+// insertProducts returns what it placed where, even when it fails with ErrInsufficientCapacity.
+// A nil lot leaves the products outside of lots, serial numbers are optional and handed out in placement order.
+func insertProducts(trx store.Transaction, j journal, allocation AllocationStrategy, warehouse string, product dto.IProduct, quantity int, lot *domain.Lot, serials []string) ([]Allocation, error) {
+	requestedWarehouse, err := getWarehouse(trx, warehouse)
+	if err != nil {
+		return nil, err
+	}
+	warehouses, err := trx.GetWarehousesOrderedFirstWithName(warehouse)
+	if err != nil {
+		return nil, err
+	}
 	// check if product sku already exists with different type
 	productType, err := trx.GetProductTypeBySku(product.GetBaseProduct().SKU)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	if productType != domain.None && productType != domain.ProductType(product.GetType()) {
-		return fmt.Errorf("product with sku %s already exists with different type", product.GetBaseProduct().SKU)
+		return nil, fmt.Errorf("%w: %s is %s", ErrSkuTypeConflict, product.GetBaseProduct().SKU, productType)
 	}
-	remainingQuantity := quantity
+	productEntity, err := productDtoToEntity(product)
+	if err != nil {
+		return nil, err
+	}
+	brand := productEntity.GetBaseProduct().Brand
+	if err := checkMatchesBrand(trx, brand); err != nil {
+		return nil, err
+	}
+	if !acceptsBrand(requestedWarehouse, brand) {
+		return nil, fmt.Errorf("%w: %s needs quality %d, %s has %d", ErrBrandQualityTooLow, warehouse, requestedWarehouse.MinBrandQuality, brand.Name, brand.Quality)
+	}
+	if productType != domain.None {
+		if err := checkMatchesCatalog(trx, productEntity); err != nil {
+			return nil, err
+		}
+	}
+	newLot := false
+	if lot != nil {
+		if newLot, err = checkLot(trx, productEntity, *lot); err != nil {
+			return nil, err
+		}
+	}
+	if len(serials) > 0 {
+		if err := checkNewSerials(trx, productEntity, serials, quantity); err != nil {
+			return nil, err
+		}
+	}
+	var spaces []WarehouseSpace
 	for _, warehouse := range warehouses {
+		if !acceptsBrand(warehouse, brand) {
+			continue
+		}
 		usedCapacity, err := trx.GetUsedCapacity(warehouse.Name)
 		if err != nil {
-			return err
+			return nil, err
 		}
-		availableCapacity := warehouse.Capacity - usedCapacity
-		toInsertQuantity := min(availableCapacity, remainingQuantity)
-		productEntity, err := productDtoToEntity(product)
-		if err != nil {
-			return err
+		spaces = append(spaces, WarehouseSpace{Name: warehouse.Name, Capacity: warehouse.Capacity, Used: usedCapacity})
+	}
+	var placed []Allocation
+	remainingQuantity := quantity
+	for _, planned := range allocation.Allocate(requestedWarehouse.Name, spaces, quantity) {
+		if err := trx.InsertProduct(planned.WarehouseName, productEntity, planned.Quantity); err != nil {
+			return nil, err
 		}
-		if err := trx.InsertProduct(warehouse.Name, productEntity, toInsertQuantity); err != nil {
-			return err
+		reason := domain.MovementInsert
+		if planned.WarehouseName != requestedWarehouse.Name {
+			reason = domain.MovementInsertOverflow
+		}
+		if err := j.record(trx, planned.WarehouseName, productEntity.GetBaseProduct().SKU, planned.Quantity, reason); err != nil {
+			return nil, err
 		}
-		remainingQuantity -= toInsertQuantity
-		if remainingQuantity == 0 {
-			break
+		placed = append(placed, planned)
+		remainingQuantity -= planned.Quantity
+	}
+	if lot != nil && len(placed) > 0 {
+		// the lot refers to the product, which only exists once something is placed
+		if newLot {
+			if err := trx.InsertLot(*lot); err != nil {
+				return nil, err
+			}
 		}
-		if remainingQuantity < 0 {
-			return fmt.Errorf("inserted more products than needed")
+		for _, planned := range placed {
+			if err := trx.ChangeLotQuantity(planned.WarehouseName, lot.Sku, lot.Number, planned.Quantity); err != nil {
+				return nil, err
+			}
 		}
 	}
-	if remainingQuantity > 0 {
-		return fmt.Errorf("not enough capacity in warehouses")
+	if remainingQuantity < 0 {
+		return nil, fmt.Errorf("%s allocation inserted more products than needed", allocation.Name())
 	}
-	if err := trx.CommitTransaction(); err != nil {
-		return err
+	if len(serials) > 0 {
+		if err := receiveSerials(trx, j, requestedWarehouse.Name, productEntity.GetBaseProduct().SKU, serials, placed); err != nil {
+			return nil, err
+		}
+	}
+	if remainingQuantity > 0 {
+		return placed, fmt.Errorf("%w: %d more needed", ErrInsufficientCapacity, remainingQuantity)
 	}
-	return nil
+	return placed, nil
 }
 
-func (s *inventoryService) RemoveProducts(warehouseName string, sku string, quantity int) error {
-	trx := s.store.BeginTransaction()
-	defer trx.EndTransaction()
-
-	warehouseProducts, err := trx.GetWarehouseProductsBySkuOrderedFirstWithName(warehouseName, sku)
+// removeProducts returns what it took from where, even when it fails with ErrInsufficientStock.
+// Listed serial numbers choose the units instead of the picking strategy.
+func removeProducts(trx store.Transaction, j journal, picking PickingStrategy, warehouseName string, sku string, quantity int, serials []string) ([]Pick, error) {
+	if err := checkWarehouseExists(trx, warehouseName); err != nil {
+		return nil, err
+	}
+	if len(serials) > 0 {
+		if err := checkSerialNumbers(serials, quantity); err != nil {
+			return nil, err
+		}
+		return removeSerials(trx, j, warehouseName, sku, serials)
+	}
+	sources, err := stockSources(trx, warehouseName, sku, picking.OrdersByReceipt())
 	if err != nil {
-		return err
+		return nil, err
 	}
+	var picked []Pick
 	remainingQuantity := quantity
-	for _, warehouseProduct := range warehouseProducts {
-		removedQuantity, err := trx.RemoveProduct(warehouseProduct.WarehouseName, warehouseProduct.Sku, remainingQuantity)
+	for _, pick := range picking.Pick(warehouseName, sources, quantity) {
+		removedQuantity, err := trx.RemoveProduct(pick.WarehouseName, sku, pick.Quantity)
 		if err != nil {
-			return err
+			return nil, err
 		}
-		remainingQuantity -= removedQuantity
-		if remainingQuantity == 0 {
-			break
+		if pick.LotNumber != "" {
+			if err := trx.ChangeLotQuantity(pick.WarehouseName, sku, pick.LotNumber, -removedQuantity); err != nil {
+				return nil, err
+			}
+		}
+		reason := removeReason(warehouseName, pick.WarehouseName)
+		if err := j.record(trx, pick.WarehouseName, sku, -removedQuantity, reason); err != nil {
+			return nil, err
 		}
-		if remainingQuantity < 0 {
-			return fmt.Errorf("removed more products than needed")
+		if err := dropSerials(trx, j, pick.WarehouseName, sku, reason); err != nil {
+			return nil, err
 		}
+		picked = append(picked, Pick{WarehouseName: pick.WarehouseName, LotNumber: pick.LotNumber, Quantity: removedQuantity})
+		remainingQuantity -= removedQuantity
 	}
-	if remainingQuantity > 0 {
-		return fmt.Errorf("not enough product in warehouses")
+	if remainingQuantity < 0 {
+		return nil, fmt.Errorf("%s picking removed more products than needed", picking.Name())
 	}
-	if err := trx.CommitTransaction(); err != nil {
-		return err
+	if remainingQuantity > 0 {
+		return picked, fmt.Errorf("%w: %d of %s missing", ErrInsufficientStock, remainingQuantity, sku)
 	}
-	return nil
+	return picked, nil
+}
+
+func acceptsBrand(warehouse domain.Warehouse, brand domain.Brand) bool {
+	return brand.Quality >= warehouse.MinBrandQuality
+}
+
+func checkWarehouseExists(trx store.Transaction, name string) error {
+	_, err := getWarehouse(trx, name)
+	return err
 }
 
 func warehouseEntityToDto(we domain.Warehouse) dto.Warehouse {
@@ -140,68 +284,53 @@ func warehouseEntityToDto(we domain.Warehouse) dto.Warehouse {
 }
 
 func productDtoToEntity(product dto.IProduct) (domain.IProduct, error) {
-	var result domain.IProduct = nil
-	switch product.GetType() {
-	case dto.Book:
-		bookProductDto := product.(*dto.BookProduct)
-		result = &domain.BookProduct{
-			Author: bookProductDto.Author,
-		}
-	case dto.Consumable:
-		consumableProductDto := product.(*dto.ConsumableProduct)
-		result = &domain.ConsumableProduct{
-			ExpirationDate: consumableProductDto.ExpirationDate,
-		}
-	case dto.Electronics:
-		electronicsProductDto := product.(*dto.ElectronicsProduct)
-		result = &domain.ElectronicsProduct{
-			WarrantyPeriod: electronicsProductDto.WarrantyPeriod,
-		}
-	default:
-		return nil, fmt.Errorf("unknown product type")
+	productType, err := producttype.Lookup(product.GetType())
+	if err != nil {
+		return nil, err
+	}
+	result, err := productType.ToEntity(product)
+	if err != nil {
+		return nil, err
 	}
 	baseProductDto := product.GetBaseProduct()
 	result.SetBaseProduct(domain.Product{
 		SKU:   baseProductDto.SKU,
 		Name:  baseProductDto.Name,
 		Price: baseProductDto.Price,
-		Brand: domain.Brand(baseProductDto.Brand),      // TODO: check conversion error
-		Type:  domain.ProductType(baseProductDto.Type), // TODO: check conversion error
+		Brand: domain.Brand(baseProductDto.Brand),
+		Type:  domain.ProductType(baseProductDto.Type),
 	})
 	return result, nil
 }
 
 func productWithQuantityEntityToDto(productWithQuantity domain.ProductWithQuantity) (dto.ProductWithQuantity, error) {
-	result := dto.ProductWithQuantity{
+	product, err := productEntityToDto(productWithQuantity.Product)
+	if err != nil {
+		return dto.ProductWithQuantity{}, err
+	}
+	return dto.ProductWithQuantity{
+		IProduct: product,
 		Quantity: productWithQuantity.Quantity,
+	}, nil
+}
+
+func productEntityToDto(product domain.IProduct) (dto.IProduct, error) {
+	productType, err := producttype.Lookup(product.GetType())
+	if err != nil {
+		return nil, err
 	}
-	switch productWithQuantity.Product.GetType() {
-	case domain.Book:
-		bookProductEntity := productWithQuantity.Product.(*domain.BookProduct)
-		result.IProduct = &dto.BookProduct{
-			Author: bookProductEntity.Author,
-		}
-	case domain.Consumable:
-		consumableProductEntity := productWithQuantity.Product.(*domain.ConsumableProduct)
-		result.IProduct = &dto.ConsumableProduct{
-			ExpirationDate: consumableProductEntity.ExpirationDate,
-		}
-	case domain.Electronics:
-		electronicsProductEntity := productWithQuantity.Product.(*domain.ElectronicsProduct)
-		result.IProduct = &dto.ElectronicsProduct{
-			WarrantyPeriod: electronicsProductEntity.WarrantyPeriod,
-		}
-	default:
-		return dto.ProductWithQuantity{}, fmt.Errorf("unknown product type")
+	result, err := productType.ToDto(product)
+	if err != nil {
+		return nil, err
 	}
-	baseProductEntity := productWithQuantity.Product.GetBaseProduct()
-	result.IProduct.SetBaseProduct(
+	baseProductEntity := product.GetBaseProduct()
+	result.SetBaseProduct(
 		dto.Product{
 			SKU:   baseProductEntity.SKU,
 			Name:  baseProductEntity.Name,
 			Price: baseProductEntity.Price,
-			Brand: dto.Brand(baseProductEntity.Brand),      // TODO: check conversion error
-			Type:  dto.ProductType(baseProductEntity.Type), // TODO: check conversion error
+			Brand: dto.Brand(baseProductEntity.Brand),
+			Type:  dto.ProductType(baseProductEntity.Type),
 		},
 	)
 	return result, nil
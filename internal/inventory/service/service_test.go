@@ -1,17 +1,45 @@
 package service
 
 import (
+	"context"
 	dbsql "database/sql"
+	"errors"
 	"fmt"
+	"os"
 	"reflect"
 	"testing"
+	"time"
 
 	"github.com/kijevigombooc/inventory-manager/internal/inventory/handler/dto"
+	"github.com/kijevigombooc/inventory-manager/internal/inventory/store"
+	"github.com/kijevigombooc/inventory-manager/internal/inventory/store/domain"
+	"github.com/kijevigombooc/inventory-manager/internal/inventory/store/memory"
 	"github.com/kijevigombooc/inventory-manager/internal/inventory/store/sql"
 )
 
+type backend struct {
+	name     string
+	newStore func() store.Store
+}
+
+var backends = []backend{
+	{name: "sqlite", newStore: func() store.Store {
+		var err error
+		db, err = sql.OpenSqliteDB(sql.InMemoryPath)
+		if err != nil {
+			panic(err)
+		}
+		return sql.NewInventoryStore(db, sql.SqliteDialect)
+	}},
+	{name: "memory", newStore: func() store.Store {
+		return memory.NewInventoryStore()
+	}},
+}
+
+var ctx = context.Background()
 var s Service
 var db *dbsql.DB
+var currentBackend backend
 var warehouses []dto.Warehouse
 var bookProducts []dto.BookProduct
 var consumableProducts []dto.ConsumableProduct
@@ -19,7 +47,15 @@ var electronicsProducts []dto.ElectronicsProduct
 
 func TestMain(m *testing.M) {
 	BeforeAll()
-	m.Run()
+	code := 0
+	for _, backend := range backends {
+		currentBackend = backend
+		fmt.Printf("running service tests against the %s store\n", backend.name)
+		if result := m.Run(); result != 0 {
+			code = result
+		}
+	}
+	os.Exit(code)
 }
 
 func BeforeAll() {
@@ -40,7 +76,7 @@ func BeforeAll() {
 					Name:    "Book Brand",
 					Quality: 4,
 				},
-				Type: dto.Book,
+				Type: "Book",
 			},
 			Author: "Author",
 		})
@@ -55,9 +91,9 @@ func BeforeAll() {
 					Name:    "Consumable Brand",
 					Quality: 4,
 				},
-				Type: dto.Consumable,
+				Type: "Consumable",
 			},
-			ExpirationDate: "2024.12.12",
+			ExpirationDate: dto.NewDate(2024, 12, 12),
 		})
 	}
 	for i := 0; i < 10; i++ {
@@ -70,25 +106,21 @@ func BeforeAll() {
 					Name:    "Electronics Brand",
 					Quality: 4,
 				},
-				Type: dto.Electronics,
+				Type: "Electronics",
 			},
-			WarrantyPeriod: "2 Years",
+			WarrantyPeriod: dto.Period{Years: 2},
 		})
 	}
 }
 
 func BeforeEach() {
-	db, err := dbsql.Open("sqlite3", ":memory:")
-	if err != nil {
-		panic(err)
-	}
-	store := sql.NewInventoryStore(db)
-	s = NewInventoryService(store)
+	s = NewInventoryService(currentBackend.newStore())
 }
 
 func AfterEach() {
 	if db != nil {
 		db.Close()
+		db = nil
 	}
 	s = nil
 }
@@ -97,7 +129,7 @@ func TestCreateWarehouseSuccessful(t *testing.T) {
 	BeforeEach()
 	defer AfterEach()
 	warehouseCapacity := 3
-	if err := s.CreateWarehouse(warehouses[warehouseCapacity]); err != nil {
+	if err := s.CreateWarehouse(ctx, warehouses[warehouseCapacity]); err != nil {
 		t.Fatalf("Error creating warehouse: %v", err)
 	}
 }
@@ -106,18 +138,18 @@ func TestCreateWarehouseErrorAlreadyExists(t *testing.T) {
 	BeforeEach()
 	defer AfterEach()
 	warehouseCapacity := 3
-	if err := s.CreateWarehouse(warehouses[warehouseCapacity]); err != nil {
+	if err := s.CreateWarehouse(ctx, warehouses[warehouseCapacity]); err != nil {
 		t.Fatalf("Error creating warehouse: %v", err)
 	}
-	if err := s.CreateWarehouse(warehouses[warehouseCapacity]); err == nil {
-		t.Fatalf("Should have failed to create warehouse")
+	if err := s.CreateWarehouse(ctx, warehouses[warehouseCapacity]); !errors.Is(err, ErrDuplicateWarehouse) {
+		t.Fatalf("Should have failed to create warehouse with %v, got %v", ErrDuplicateWarehouse, err)
 	}
 }
 
 func TestListWarehousesEmpty(t *testing.T) {
 	BeforeEach()
 	defer AfterEach()
-	warehouses, err := s.GetWarehouses()
+	warehouses, err := s.GetWarehouses(ctx)
 	if err != nil {
 		t.Fatalf("Error listing warehouses: %v", err)
 	}
@@ -130,10 +162,10 @@ func TestListWarehousesNotEmpty(t *testing.T) {
 	BeforeEach()
 	defer AfterEach()
 	warehouseCapacity := 3
-	if err := s.CreateWarehouse(warehouses[warehouseCapacity]); err != nil {
+	if err := s.CreateWarehouse(ctx, warehouses[warehouseCapacity]); err != nil {
 		t.Fatalf("Error creating warehouse: %v", err)
 	}
-	warehouses, err := s.GetWarehouses()
+	warehouses, err := s.GetWarehouses(ctx)
 	if err != nil {
 		t.Fatalf("Error listing warehouses: %v", err)
 	}
@@ -148,13 +180,13 @@ func TestListWarehousesWWarehouseNotEmpty(t *testing.T) {
 	warehouseCapacity := 3
 	toInsertQuantity := 3
 	toInsertProduct := bookProducts[0]
-	if err := s.CreateWarehouse(warehouses[warehouseCapacity]); err != nil {
+	if err := s.CreateWarehouse(ctx, warehouses[warehouseCapacity]); err != nil {
 		t.Fatalf("Error creating warehouse: %v", err)
 	}
-	if err := s.InsertProducts(warehouses[warehouseCapacity].Name, &toInsertProduct, toInsertQuantity); err != nil {
+	if err := s.InsertProducts(ctx, warehouses[warehouseCapacity].Name, &toInsertProduct, toInsertQuantity, dto.InsertOptions{}); err != nil {
 		t.Fatalf("Error inserting product: %v", err)
 	}
-	warehouses, err := s.GetWarehouses()
+	warehouses, err := s.GetWarehouses(ctx)
 	if err != nil {
 		t.Fatalf("Error listing warehouses: %v", err)
 	}
@@ -175,13 +207,13 @@ func TestInsertAndListSuccessfulByContent(t *testing.T) {
 	warehouseCapacity := 3
 	toInsertQuantity := 3
 	toInsertProduct := bookProducts[0]
-	if err := s.CreateWarehouse(warehouses[warehouseCapacity]); err != nil {
+	if err := s.CreateWarehouse(ctx, warehouses[warehouseCapacity]); err != nil {
 		t.Fatalf("Error creating warehouse: %v", err)
 	}
-	if err := s.InsertProducts(warehouses[warehouseCapacity].Name, &toInsertProduct, toInsertQuantity); err != nil {
+	if err := s.InsertProducts(ctx, warehouses[warehouseCapacity].Name, &toInsertProduct, toInsertQuantity, dto.InsertOptions{}); err != nil {
 		t.Fatalf("Error inserting product: %v", err)
 	}
-	warehouses, err := s.GetWarehouses()
+	warehouses, err := s.GetWarehouses(ctx)
 	if err != nil {
 		t.Fatalf("Error listing warehouses: %v", err)
 	}
@@ -195,10 +227,10 @@ func TestInsertLocalBookSuccessful(t *testing.T) {
 	defer AfterEach()
 	warehouseCapacity := 3
 	toInsertQuantity := 3
-	if err := s.CreateWarehouse(warehouses[warehouseCapacity]); err != nil {
+	if err := s.CreateWarehouse(ctx, warehouses[warehouseCapacity]); err != nil {
 		t.Fatalf("Error creating warehouse: %v", err)
 	}
-	if err := s.InsertProducts(warehouses[warehouseCapacity].Name, &bookProducts[0], toInsertQuantity); err != nil {
+	if err := s.InsertProducts(ctx, warehouses[warehouseCapacity].Name, &bookProducts[0], toInsertQuantity, dto.InsertOptions{}); err != nil {
 		t.Fatalf("Error inserting product: %v", err)
 	}
 }
@@ -208,10 +240,10 @@ func TestInsertLocalConsumableSuccessful(t *testing.T) {
 	defer AfterEach()
 	warehouseCapacity := 3
 	toInsertQuantity := 3
-	if err := s.CreateWarehouse(warehouses[warehouseCapacity]); err != nil {
+	if err := s.CreateWarehouse(ctx, warehouses[warehouseCapacity]); err != nil {
 		t.Fatalf("Error creating warehouse: %v", err)
 	}
-	if err := s.InsertProducts(warehouses[warehouseCapacity].Name, &consumableProducts[0], toInsertQuantity); err != nil {
+	if err := s.InsertProducts(ctx, warehouses[warehouseCapacity].Name, &consumableProducts[0], toInsertQuantity, dto.InsertOptions{}); err != nil {
 		t.Fatalf("Error inserting product: %v", err)
 	}
 }
@@ -222,10 +254,10 @@ func TestInsertLocalElectronicsSuccessful(t *testing.T) {
 
 	warehouseCapacity := 3
 	toInsertQuantity := 3
-	if err := s.CreateWarehouse(warehouses[warehouseCapacity]); err != nil {
+	if err := s.CreateWarehouse(ctx, warehouses[warehouseCapacity]); err != nil {
 		t.Fatalf("Error creating warehouse: %v", err)
 	}
-	if err := s.InsertProducts(warehouses[warehouseCapacity].Name, &electronicsProducts[0], toInsertQuantity); err != nil {
+	if err := s.InsertProducts(ctx, warehouses[warehouseCapacity].Name, &electronicsProducts[0], toInsertQuantity, dto.InsertOptions{}); err != nil {
 		t.Fatalf("Error inserting product: %v", err)
 	}
 }
@@ -235,11 +267,11 @@ func TestInsertLocalBookErrorNotEnoughSpace(t *testing.T) {
 	defer AfterEach()
 	warehouseCapacity := 2
 	toInsertQuantity := 3
-	if err := s.CreateWarehouse(warehouses[warehouseCapacity]); err != nil {
+	if err := s.CreateWarehouse(ctx, warehouses[warehouseCapacity]); err != nil {
 		t.Fatalf("Error creating warehouse: %v", err)
 	}
-	if err := s.InsertProducts(warehouses[warehouseCapacity].Name, &bookProducts[0], toInsertQuantity); err == nil {
-		t.Fatalf("Should have failed to insert product")
+	if err := s.InsertProducts(ctx, warehouses[warehouseCapacity].Name, &bookProducts[0], toInsertQuantity, dto.InsertOptions{}); !errors.Is(err, ErrInsufficientCapacity) {
+		t.Fatalf("Should have failed to insert product with %v, got %v", ErrInsufficientCapacity, err)
 	}
 }
 
@@ -248,10 +280,10 @@ func TestInsertLocalConsumableErrorNotEnoughSpace(t *testing.T) {
 	defer AfterEach()
 	warehouseCapacity := 2
 	toInsertQuantity := 3
-	if err := s.CreateWarehouse(warehouses[warehouseCapacity]); err != nil {
+	if err := s.CreateWarehouse(ctx, warehouses[warehouseCapacity]); err != nil {
 		t.Fatalf("Error creating warehouse: %v", err)
 	}
-	if err := s.InsertProducts(warehouses[warehouseCapacity].Name, &consumableProducts[0], toInsertQuantity); err == nil {
+	if err := s.InsertProducts(ctx, warehouses[warehouseCapacity].Name, &consumableProducts[0], toInsertQuantity, dto.InsertOptions{}); err == nil {
 		t.Fatalf("Should have failed to insert product")
 	}
 }
@@ -262,10 +294,10 @@ func TestInsertLocalElectronicsErrorNotEnoughSpace(t *testing.T) {
 
 	warehouseCapacity := 2
 	toInsertQuantity := 3
-	if err := s.CreateWarehouse(warehouses[warehouseCapacity]); err != nil {
+	if err := s.CreateWarehouse(ctx, warehouses[warehouseCapacity]); err != nil {
 		t.Fatalf("Error creating warehouse: %v", err)
 	}
-	if err := s.InsertProducts(warehouses[warehouseCapacity].Name, &electronicsProducts[0], toInsertQuantity); err == nil {
+	if err := s.InsertProducts(ctx, warehouses[warehouseCapacity].Name, &electronicsProducts[0], toInsertQuantity, dto.InsertOptions{}); err == nil {
 		t.Fatalf("Should have failed to insert product")
 	}
 }
@@ -275,16 +307,16 @@ func TestInsertLocalMultipleTypesSuccessful(t *testing.T) {
 	defer AfterEach()
 	warehouseCapacity := 10
 	toInsertQuantity := 3
-	if err := s.CreateWarehouse(warehouses[warehouseCapacity]); err != nil {
+	if err := s.CreateWarehouse(ctx, warehouses[warehouseCapacity]); err != nil {
 		t.Fatalf("Error creating warehouse: %v", err)
 	}
-	if err := s.InsertProducts(warehouses[warehouseCapacity].Name, &bookProducts[0], toInsertQuantity); err != nil {
+	if err := s.InsertProducts(ctx, warehouses[warehouseCapacity].Name, &bookProducts[0], toInsertQuantity, dto.InsertOptions{}); err != nil {
 		t.Fatalf("Error inserting product: %v", err)
 	}
-	if err := s.InsertProducts(warehouses[warehouseCapacity].Name, &consumableProducts[0], toInsertQuantity); err != nil {
+	if err := s.InsertProducts(ctx, warehouses[warehouseCapacity].Name, &consumableProducts[0], toInsertQuantity, dto.InsertOptions{}); err != nil {
 		t.Fatalf("Error inserting product: %v", err)
 	}
-	if err := s.InsertProducts(warehouses[warehouseCapacity].Name, &electronicsProducts[0], toInsertQuantity); err != nil {
+	if err := s.InsertProducts(ctx, warehouses[warehouseCapacity].Name, &electronicsProducts[0], toInsertQuantity, dto.InsertOptions{}); err != nil {
 		t.Fatalf("Error inserting product: %v", err)
 	}
 }
@@ -294,16 +326,16 @@ func TestInsertLocalMultipleTypesErrorNotEnoughSpace(t *testing.T) {
 	defer AfterEach()
 	warehouseCapacity := 10
 	toInsertQuantity := 4
-	if err := s.CreateWarehouse(warehouses[warehouseCapacity]); err != nil {
+	if err := s.CreateWarehouse(ctx, warehouses[warehouseCapacity]); err != nil {
 		t.Fatalf("Error creating warehouse: %v", err)
 	}
-	if err := s.InsertProducts(warehouses[warehouseCapacity].Name, &bookProducts[0], toInsertQuantity); err != nil {
+	if err := s.InsertProducts(ctx, warehouses[warehouseCapacity].Name, &bookProducts[0], toInsertQuantity, dto.InsertOptions{}); err != nil {
 		t.Fatalf("Error inserting product: %v", err)
 	}
-	if err := s.InsertProducts(warehouses[warehouseCapacity].Name, &consumableProducts[0], toInsertQuantity); err != nil {
+	if err := s.InsertProducts(ctx, warehouses[warehouseCapacity].Name, &consumableProducts[0], toInsertQuantity, dto.InsertOptions{}); err != nil {
 		t.Fatalf("Error inserting product: %v", err)
 	}
-	if err := s.InsertProducts(warehouses[warehouseCapacity].Name, &electronicsProducts[0], toInsertQuantity); err == nil {
+	if err := s.InsertProducts(ctx, warehouses[warehouseCapacity].Name, &electronicsProducts[0], toInsertQuantity, dto.InsertOptions{}); err == nil {
 		t.Fatalf("Should have failed to insert product")
 	}
 }
@@ -314,13 +346,13 @@ func TestInsertGlobalBookSuccessful(t *testing.T) {
 	toInsertQuantity := 4
 	warehouse1Capacity := 2
 	warehouse2Capacity := 3
-	if err := s.CreateWarehouse(warehouses[warehouse1Capacity]); err != nil {
+	if err := s.CreateWarehouse(ctx, warehouses[warehouse1Capacity]); err != nil {
 		t.Fatalf("Error creating warehouse: %v", err)
 	}
-	if err := s.CreateWarehouse(warehouses[warehouse2Capacity]); err != nil {
+	if err := s.CreateWarehouse(ctx, warehouses[warehouse2Capacity]); err != nil {
 		t.Fatalf("Error creating warehouse: %v", err)
 	}
-	if err := s.InsertProducts(warehouses[warehouse1Capacity].Name, &bookProducts[0], toInsertQuantity); err != nil {
+	if err := s.InsertProducts(ctx, warehouses[warehouse1Capacity].Name, &bookProducts[0], toInsertQuantity, dto.InsertOptions{}); err != nil {
 		t.Fatalf("Error inserting product: %v", err)
 	}
 }
@@ -331,13 +363,13 @@ func TestInsertGlobalConsumableSuccessful(t *testing.T) {
 	toInsertQuantity := 4
 	warehouse1Capacity := 2
 	warehouse2Capacity := 3
-	if err := s.CreateWarehouse(warehouses[warehouse1Capacity]); err != nil {
+	if err := s.CreateWarehouse(ctx, warehouses[warehouse1Capacity]); err != nil {
 		t.Fatalf("Error creating warehouse: %v", err)
 	}
-	if err := s.CreateWarehouse(warehouses[warehouse2Capacity]); err != nil {
+	if err := s.CreateWarehouse(ctx, warehouses[warehouse2Capacity]); err != nil {
 		t.Fatalf("Error creating warehouse: %v", err)
 	}
-	if err := s.InsertProducts(warehouses[warehouse1Capacity].Name, &consumableProducts[0], toInsertQuantity); err != nil {
+	if err := s.InsertProducts(ctx, warehouses[warehouse1Capacity].Name, &consumableProducts[0], toInsertQuantity, dto.InsertOptions{}); err != nil {
 		t.Fatalf("Error inserting product: %v", err)
 	}
 }
@@ -348,13 +380,13 @@ func TestInsertGlobalElectronicsSuccessful(t *testing.T) {
 	toInsertQuantity := 4
 	warehouse1Capacity := 2
 	warehouse2Capacity := 3
-	if err := s.CreateWarehouse(warehouses[warehouse1Capacity]); err != nil {
+	if err := s.CreateWarehouse(ctx, warehouses[warehouse1Capacity]); err != nil {
 		t.Fatalf("Error creating warehouse: %v", err)
 	}
-	if err := s.CreateWarehouse(warehouses[warehouse2Capacity]); err != nil {
+	if err := s.CreateWarehouse(ctx, warehouses[warehouse2Capacity]); err != nil {
 		t.Fatalf("Error creating warehouse: %v", err)
 	}
-	if err := s.InsertProducts(warehouses[warehouse1Capacity].Name, &electronicsProducts[0], toInsertQuantity); err != nil {
+	if err := s.InsertProducts(ctx, warehouses[warehouse1Capacity].Name, &electronicsProducts[0], toInsertQuantity, dto.InsertOptions{}); err != nil {
 		t.Fatalf("Error inserting product: %v", err)
 	}
 }
@@ -365,19 +397,19 @@ func TestInsertGlobalMultipleTypesSuccessful(t *testing.T) {
 	toInsertQuantity := 3
 	warehouse1Capacity := 4
 	warehouse2Capacity := 5
-	if err := s.CreateWarehouse(warehouses[warehouse1Capacity]); err != nil {
+	if err := s.CreateWarehouse(ctx, warehouses[warehouse1Capacity]); err != nil {
 		t.Fatalf("Error creating warehouse: %v", err)
 	}
-	if err := s.CreateWarehouse(warehouses[warehouse2Capacity]); err != nil {
+	if err := s.CreateWarehouse(ctx, warehouses[warehouse2Capacity]); err != nil {
 		t.Fatalf("Error creating warehouse: %v", err)
 	}
-	if err := s.InsertProducts(warehouses[warehouse1Capacity].Name, &bookProducts[0], toInsertQuantity); err != nil {
+	if err := s.InsertProducts(ctx, warehouses[warehouse1Capacity].Name, &bookProducts[0], toInsertQuantity, dto.InsertOptions{}); err != nil {
 		t.Fatalf("Error inserting product: %v", err)
 	}
-	if err := s.InsertProducts(warehouses[warehouse1Capacity].Name, &consumableProducts[0], toInsertQuantity); err != nil {
+	if err := s.InsertProducts(ctx, warehouses[warehouse1Capacity].Name, &consumableProducts[0], toInsertQuantity, dto.InsertOptions{}); err != nil {
 		t.Fatalf("Error inserting product: %v", err)
 	}
-	if err := s.InsertProducts(warehouses[warehouse1Capacity].Name, &electronicsProducts[0], toInsertQuantity); err != nil {
+	if err := s.InsertProducts(ctx, warehouses[warehouse1Capacity].Name, &electronicsProducts[0], toInsertQuantity, dto.InsertOptions{}); err != nil {
 		t.Fatalf("Error inserting product: %v", err)
 	}
 }
@@ -388,19 +420,19 @@ func TestInsertGlobalMultipleTypesErrorNotEnoughSpace(t *testing.T) {
 	toInsertQuantity := 4
 	warehouse1Capacity := 4
 	warehouse2Capacity := 5
-	if err := s.CreateWarehouse(warehouses[warehouse1Capacity]); err != nil {
+	if err := s.CreateWarehouse(ctx, warehouses[warehouse1Capacity]); err != nil {
 		t.Fatalf("Error creating warehouse: %v", err)
 	}
-	if err := s.CreateWarehouse(warehouses[warehouse2Capacity]); err != nil {
+	if err := s.CreateWarehouse(ctx, warehouses[warehouse2Capacity]); err != nil {
 		t.Fatalf("Error creating warehouse: %v", err)
 	}
-	if err := s.InsertProducts(warehouses[warehouse1Capacity].Name, &bookProducts[0], toInsertQuantity); err != nil {
+	if err := s.InsertProducts(ctx, warehouses[warehouse1Capacity].Name, &bookProducts[0], toInsertQuantity, dto.InsertOptions{}); err != nil {
 		t.Fatalf("Error inserting product: %v", err)
 	}
-	if err := s.InsertProducts(warehouses[warehouse1Capacity].Name, &consumableProducts[0], toInsertQuantity); err != nil {
+	if err := s.InsertProducts(ctx, warehouses[warehouse1Capacity].Name, &consumableProducts[0], toInsertQuantity, dto.InsertOptions{}); err != nil {
 		t.Fatalf("Error inserting product: %v", err)
 	}
-	if err := s.InsertProducts(warehouses[warehouse1Capacity].Name, &electronicsProducts[0], toInsertQuantity); err == nil {
+	if err := s.InsertProducts(ctx, warehouses[warehouse1Capacity].Name, &electronicsProducts[0], toInsertQuantity, dto.InsertOptions{}); err == nil {
 		t.Fatalf("Should have failed to insert product")
 	}
 }
@@ -413,19 +445,19 @@ func TestRemoveGlobalBookSuccessful(t *testing.T) {
 	toRemoveQuantity := 6
 	warehouse1Capacity := 4
 	warehouse2Capacity := 5
-	if err := s.CreateWarehouse(warehouses[warehouse1Capacity]); err != nil {
+	if err := s.CreateWarehouse(ctx, warehouses[warehouse1Capacity]); err != nil {
 		t.Fatalf("Error creating warehouse: %v", err)
 	}
-	if err := s.CreateWarehouse(warehouses[warehouse2Capacity]); err != nil {
+	if err := s.CreateWarehouse(ctx, warehouses[warehouse2Capacity]); err != nil {
 		t.Fatalf("Error creating warehouse: %v", err)
 	}
-	if err := s.InsertProducts(warehouses[warehouse1Capacity].Name, &bookProducts[0], toInsert1Quantity); err != nil {
+	if err := s.InsertProducts(ctx, warehouses[warehouse1Capacity].Name, &bookProducts[0], toInsert1Quantity, dto.InsertOptions{}); err != nil {
 		t.Fatalf("Error inserting product: %v", err)
 	}
-	if err := s.InsertProducts(warehouses[warehouse2Capacity].Name, &bookProducts[0], toInsert2Quantity); err != nil {
+	if err := s.InsertProducts(ctx, warehouses[warehouse2Capacity].Name, &bookProducts[0], toInsert2Quantity, dto.InsertOptions{}); err != nil {
 		t.Fatalf("Error inserting product: %v", err)
 	}
-	if err := s.RemoveProducts(warehouses[warehouse1Capacity].Name, bookProducts[0].SKU, toRemoveQuantity); err != nil {
+	if err := s.RemoveProducts(ctx, warehouses[warehouse1Capacity].Name, bookProducts[0].SKU, toRemoveQuantity, dto.RemoveOptions{}); err != nil {
 		t.Fatalf("Error removing product: %v", err)
 	}
 }
@@ -438,19 +470,952 @@ func TestRemoveGlobalBookErrorNotEnoughQuantity(t *testing.T) {
 	toRemoveQuantity := 7
 	warehouse1Capacity := 4
 	warehouse2Capacity := 5
-	if err := s.CreateWarehouse(warehouses[warehouse1Capacity]); err != nil {
+	if err := s.CreateWarehouse(ctx, warehouses[warehouse1Capacity]); err != nil {
+		t.Fatalf("Error creating warehouse: %v", err)
+	}
+	if err := s.CreateWarehouse(ctx, warehouses[warehouse2Capacity]); err != nil {
+		t.Fatalf("Error creating warehouse: %v", err)
+	}
+	if err := s.InsertProducts(ctx, warehouses[warehouse1Capacity].Name, &bookProducts[0], toInsert1Quantity, dto.InsertOptions{}); err != nil {
+		t.Fatalf("Error inserting product: %v", err)
+	}
+	if err := s.InsertProducts(ctx, warehouses[warehouse2Capacity].Name, &bookProducts[0], toInsert2Quantity, dto.InsertOptions{}); err != nil {
+		t.Fatalf("Error inserting product: %v", err)
+	}
+	if err := s.RemoveProducts(ctx, warehouses[warehouse1Capacity].Name, bookProducts[0].SKU, toRemoveQuantity, dto.RemoveOptions{}); !errors.Is(err, ErrInsufficientStock) {
+		t.Fatalf("Should have failed to remove product with %v, got %v", ErrInsufficientStock, err)
+	}
+}
+
+func TestInsertErrorWarehouseNotFound(t *testing.T) {
+	BeforeEach()
+	defer AfterEach()
+	if err := s.CreateWarehouse(ctx, warehouses[5]); err != nil {
+		t.Fatalf("Error creating warehouse: %v", err)
+	}
+	if err := s.InsertProducts(ctx, "missing", &bookProducts[0], 1, dto.InsertOptions{}); !errors.Is(err, ErrWarehouseNotFound) {
+		t.Fatalf("Should have failed to insert product with %v, got %v", ErrWarehouseNotFound, err)
+	}
+}
+
+func TestRemoveErrorWarehouseNotFound(t *testing.T) {
+	BeforeEach()
+	defer AfterEach()
+	if err := s.RemoveProducts(ctx, "missing", bookProducts[0].SKU, 1, dto.RemoveOptions{}); !errors.Is(err, ErrWarehouseNotFound) {
+		t.Fatalf("Should have failed to remove product with %v, got %v", ErrWarehouseNotFound, err)
+	}
+}
+
+func TestInsertErrorSkuTypeConflict(t *testing.T) {
+	BeforeEach()
+	defer AfterEach()
+	if err := s.CreateWarehouse(ctx, warehouses[5]); err != nil {
+		t.Fatalf("Error creating warehouse: %v", err)
+	}
+	if err := s.InsertProducts(ctx, warehouses[5].Name, &bookProducts[0], 1, dto.InsertOptions{}); err != nil {
+		t.Fatalf("Error inserting product: %v", err)
+	}
+	conflicting := consumableProducts[0]
+	conflicting.SKU = bookProducts[0].SKU
+	if err := s.InsertProducts(ctx, warehouses[5].Name, &conflicting, 1, dto.InsertOptions{}); !errors.Is(err, ErrSkuTypeConflict) {
+		t.Fatalf("Should have failed to insert product with %v, got %v", ErrSkuTypeConflict, err)
+	}
+}
+
+func TestGetWarehouseErrorNotFound(t *testing.T) {
+	BeforeEach()
+	defer AfterEach()
+	if _, err := s.GetWarehouse(ctx, "missing"); !errors.Is(err, ErrWarehouseNotFound) {
+		t.Fatalf("Should have failed to get warehouse with %v, got %v", ErrWarehouseNotFound, err)
+	}
+}
+
+func TestUpdateWarehouseRenameKeepsProducts(t *testing.T) {
+	BeforeEach()
+	defer AfterEach()
+	warehouseCapacity := 5
+	if err := s.CreateWarehouse(ctx, warehouses[warehouseCapacity]); err != nil {
+		t.Fatalf("Error creating warehouse: %v", err)
+	}
+	if err := s.InsertProducts(ctx, warehouses[warehouseCapacity].Name, &bookProducts[0], 3, dto.InsertOptions{}); err != nil {
+		t.Fatalf("Error inserting product: %v", err)
+	}
+	newName := "Renamed"
+	newAddress := "New address"
+	if _, err := s.UpdateWarehouse(ctx, warehouses[warehouseCapacity].Name, dto.UpdateWarehouseRequest{Name: &newName, Address: &newAddress}); err != nil {
+		t.Fatalf("Error updating warehouse: %v", err)
+	}
+	warehouse, err := s.GetWarehouse(ctx, newName)
+	if err != nil {
+		t.Fatalf("Error getting warehouse: %v", err)
+	}
+	if warehouse.Address != newAddress || warehouse.Capacity != warehouseCapacity {
+		t.Fatalf("Warehouse should have the new address and the old capacity, got %v", warehouse.Warehouse)
+	}
+	if len(warehouse.Products) != 1 || warehouse.Products[0].Quantity != 3 {
+		t.Fatalf("Renamed warehouse should keep its products, got %v", warehouse.Products)
+	}
+	if _, err := s.GetWarehouse(ctx, warehouses[warehouseCapacity].Name); !errors.Is(err, ErrWarehouseNotFound) {
+		t.Fatalf("Old warehouse name should be gone, got %v", err)
+	}
+}
+
+func TestUpdateWarehouseErrorCapacityBelowUsage(t *testing.T) {
+	BeforeEach()
+	defer AfterEach()
+	warehouseCapacity := 5
+	if err := s.CreateWarehouse(ctx, warehouses[warehouseCapacity]); err != nil {
+		t.Fatalf("Error creating warehouse: %v", err)
+	}
+	if err := s.InsertProducts(ctx, warehouses[warehouseCapacity].Name, &bookProducts[0], 4, dto.InsertOptions{}); err != nil {
+		t.Fatalf("Error inserting product: %v", err)
+	}
+	newCapacity := 3
+	if _, err := s.UpdateWarehouse(ctx, warehouses[warehouseCapacity].Name, dto.UpdateWarehouseRequest{Capacity: &newCapacity}); !errors.Is(err, ErrCapacityBelowUsage) {
+		t.Fatalf("Should have failed to shrink warehouse with %v, got %v", ErrCapacityBelowUsage, err)
+	}
+}
+
+func TestUpdateWarehouseShrinkWithRelocate(t *testing.T) {
+	BeforeEach()
+	defer AfterEach()
+	warehouse1Capacity := 5
+	warehouse2Capacity := 3
+	if err := s.CreateWarehouse(ctx, warehouses[warehouse1Capacity]); err != nil {
+		t.Fatalf("Error creating warehouse: %v", err)
+	}
+	if err := s.CreateWarehouse(ctx, warehouses[warehouse2Capacity]); err != nil {
+		t.Fatalf("Error creating warehouse: %v", err)
+	}
+	if err := s.InsertProducts(ctx, warehouses[warehouse1Capacity].Name, &bookProducts[0], 4, dto.InsertOptions{}); err != nil {
+		t.Fatalf("Error inserting product: %v", err)
+	}
+	newCapacity := 1
+	if _, err := s.UpdateWarehouse(ctx, warehouses[warehouse1Capacity].Name, dto.UpdateWarehouseRequest{Capacity: &newCapacity, Relocate: true}); err != nil {
+		t.Fatalf("Error shrinking warehouse: %v", err)
+	}
+	assertWarehouseQuantity(t, warehouses[warehouse1Capacity].Name, 1)
+	assertWarehouseQuantity(t, warehouses[warehouse2Capacity].Name, 3)
+}
+
+func TestUpdateWarehouseShrinkLeavesQuarantinedStock(t *testing.T) {
+	BeforeEach()
+	defer AfterEach()
+	s.(*inventoryService).now = func() time.Time { return time.Date(2024, 6, 15, 12, 0, 0, 0, time.UTC) }
+	for _, warehouse := range []dto.Warehouse{warehouses[3], warehouses[5]} {
+		if err := s.CreateWarehouse(ctx, warehouse); err != nil {
+			t.Fatalf("Error creating warehouse: %v", err)
+		}
+	}
+	product := &consumableProducts[0]
+	if err := s.InsertProducts(ctx, warehouses[5].Name, product, 2, dto.InsertOptions{}); err != nil {
+		t.Fatalf("Error inserting product: %v", err)
+	}
+	if err := s.InsertProducts(ctx, warehouses[5].Name, product, 2, dto.InsertOptions{Lot: &dto.Lot{LotNumber: "L1", ExpirationDate: dto.NewDate(2024, 6, 1)}}); err != nil {
+		t.Fatalf("Error inserting product: %v", err)
+	}
+	if quarantined, err := s.QuarantineExpired(ctx); err != nil || quarantined != 2 {
+		t.Fatalf("Quarantine should take 2 products, got %d, %v", quarantined, err)
+	}
+	newCapacity := 1
+	_, err := s.UpdateWarehouse(ctx, warehouses[5].Name, dto.UpdateWarehouseRequest{Capacity: &newCapacity, Relocate: true})
+	if !errors.Is(err, ErrInsufficientCapacity) {
+		t.Fatalf("Expected ErrInsufficientCapacity, got %v", err)
+	}
+	assertWarehouseQuantity(t, warehouses[5].Name, 4)
+	newCapacity = 2
+	if _, err := s.UpdateWarehouse(ctx, warehouses[5].Name, dto.UpdateWarehouseRequest{Capacity: &newCapacity, Relocate: true}); err != nil {
+		t.Fatalf("Error shrinking warehouse: %v", err)
+	}
+	assertWarehouseQuantity(t, warehouses[5].Name, 2)
+	assertWarehouseQuantity(t, warehouses[3].Name, 2)
+	assertLots(t, warehouses[3].Name, product.SKU, map[string]int{})
+	report, err := s.GetExpiringStock(ctx, 0)
+	if err != nil {
+		t.Fatalf("Error getting expiring stock: %v", err)
+	}
+	if len(report) != 1 || report[0].WarehouseName != warehouses[5].Name || !report[0].Quarantined || report[0].Quantity != 2 {
+		t.Fatalf("The quarantined lot should stay in %s, got %v", warehouses[5].Name, report)
+	}
+}
+
+func TestDeleteWarehouseErrorNotEmpty(t *testing.T) {
+	BeforeEach()
+	defer AfterEach()
+	warehouseCapacity := 5
+	if err := s.CreateWarehouse(ctx, warehouses[warehouseCapacity]); err != nil {
+		t.Fatalf("Error creating warehouse: %v", err)
+	}
+	if err := s.InsertProducts(ctx, warehouses[warehouseCapacity].Name, &bookProducts[0], 1, dto.InsertOptions{}); err != nil {
+		t.Fatalf("Error inserting product: %v", err)
+	}
+	if err := s.DeleteWarehouse(ctx, warehouses[warehouseCapacity].Name, false); !errors.Is(err, ErrWarehouseNotEmpty) {
+		t.Fatalf("Should have failed to delete warehouse with %v, got %v", ErrWarehouseNotEmpty, err)
+	}
+}
+
+func TestDeleteWarehouseWithRelocate(t *testing.T) {
+	BeforeEach()
+	defer AfterEach()
+	warehouse1Capacity := 4
+	warehouse2Capacity := 5
+	if err := s.CreateWarehouse(ctx, warehouses[warehouse1Capacity]); err != nil {
+		t.Fatalf("Error creating warehouse: %v", err)
+	}
+	if err := s.CreateWarehouse(ctx, warehouses[warehouse2Capacity]); err != nil {
+		t.Fatalf("Error creating warehouse: %v", err)
+	}
+	if err := s.InsertProducts(ctx, warehouses[warehouse1Capacity].Name, &bookProducts[0], 2, dto.InsertOptions{}); err != nil {
+		t.Fatalf("Error inserting product: %v", err)
+	}
+	if err := s.InsertProducts(ctx, warehouses[warehouse1Capacity].Name, &consumableProducts[0], 2, dto.InsertOptions{}); err != nil {
+		t.Fatalf("Error inserting product: %v", err)
+	}
+	if err := s.DeleteWarehouse(ctx, warehouses[warehouse1Capacity].Name, true); err != nil {
+		t.Fatalf("Error deleting warehouse: %v", err)
+	}
+	assertWarehouseQuantity(t, warehouses[warehouse2Capacity].Name, 4)
+}
+
+func TestDeleteWarehouseRelocateErrorNotEnoughSpace(t *testing.T) {
+	BeforeEach()
+	defer AfterEach()
+	warehouse1Capacity := 4
+	warehouse2Capacity := 2
+	if err := s.CreateWarehouse(ctx, warehouses[warehouse1Capacity]); err != nil {
+		t.Fatalf("Error creating warehouse: %v", err)
+	}
+	if err := s.CreateWarehouse(ctx, warehouses[warehouse2Capacity]); err != nil {
+		t.Fatalf("Error creating warehouse: %v", err)
+	}
+	if err := s.InsertProducts(ctx, warehouses[warehouse1Capacity].Name, &bookProducts[0], 3, dto.InsertOptions{}); err != nil {
+		t.Fatalf("Error inserting product: %v", err)
+	}
+	if err := s.DeleteWarehouse(ctx, warehouses[warehouse1Capacity].Name, true); !errors.Is(err, ErrInsufficientCapacity) {
+		t.Fatalf("Should have failed to delete warehouse with %v, got %v", ErrInsufficientCapacity, err)
+	}
+	assertWarehouseQuantity(t, warehouses[warehouse1Capacity].Name, 3)
+}
+
+func TestCreateAndUpdateProductSuccessful(t *testing.T) {
+	BeforeEach()
+	defer AfterEach()
+	product := bookProducts[0]
+	if err := s.CreateProduct(ctx, &product); err != nil {
+		t.Fatalf("Error creating product: %v", err)
+	}
+	if err := s.CreateProduct(ctx, &product); !errors.Is(err, ErrDuplicateProduct) {
+		t.Fatalf("Should have failed to create product with %v, got %v", ErrDuplicateProduct, err)
+	}
+	product.Name = "Corrected name"
+	product.Price = 120
+	if err := s.UpdateProduct(ctx, &product); err != nil {
+		t.Fatalf("Error updating product: %v", err)
+	}
+	stored, err := s.GetProduct(ctx, product.SKU)
+	if err != nil {
+		t.Fatalf("Error getting product: %v", err)
+	}
+	if !reflect.DeepEqual(stored, &product) {
+		t.Fatalf("Products should be the same: %v, %v", stored, &product)
+	}
+	products, err := s.GetProducts(ctx)
+	if err != nil {
+		t.Fatalf("Error listing products: %v", err)
+	}
+	if len(products) != 1 {
+		t.Fatalf("Catalog should have 1 product, got %d", len(products))
+	}
+}
+
+func TestUpdateProductErrorTypeChange(t *testing.T) {
+	BeforeEach()
+	defer AfterEach()
+	if err := s.CreateProduct(ctx, &bookProducts[0]); err != nil {
+		t.Fatalf("Error creating product: %v", err)
+	}
+	product := consumableProducts[0]
+	product.SKU = bookProducts[0].SKU
+	if err := s.UpdateProduct(ctx, &product); !errors.Is(err, ErrSkuTypeConflict) {
+		t.Fatalf("Should have failed to update product with %v, got %v", ErrSkuTypeConflict, err)
+	}
+	if err := s.UpdateProduct(ctx, &bookProducts[1]); !errors.Is(err, ErrProductNotFound) {
+		t.Fatalf("Should have failed to update product with %v, got %v", ErrProductNotFound, err)
+	}
+}
+
+func TestInsertErrorProductMismatch(t *testing.T) {
+	BeforeEach()
+	defer AfterEach()
+	warehouseCapacity := 5
+	if err := s.CreateWarehouse(ctx, warehouses[warehouseCapacity]); err != nil {
+		t.Fatalf("Error creating warehouse: %v", err)
+	}
+	if err := s.CreateProduct(ctx, &bookProducts[0]); err != nil {
+		t.Fatalf("Error creating product: %v", err)
+	}
+	if err := s.InsertProducts(ctx, warehouses[warehouseCapacity].Name, &bookProducts[0], 1, dto.InsertOptions{}); err != nil {
+		t.Fatalf("Error inserting product matching the catalog: %v", err)
+	}
+	product := bookProducts[0]
+	product.Price = 999
+	if err := s.InsertProducts(ctx, warehouses[warehouseCapacity].Name, &product, 1, dto.InsertOptions{}); !errors.Is(err, ErrProductMismatch) {
+		t.Fatalf("Should have failed to insert product with %v, got %v", ErrProductMismatch, err)
+	}
+	assertWarehouseQuantity(t, warehouses[warehouseCapacity].Name, 1)
+}
+
+func TestDeleteProductErrorInStock(t *testing.T) {
+	BeforeEach()
+	defer AfterEach()
+	warehouseCapacity := 5
+	if err := s.CreateWarehouse(ctx, warehouses[warehouseCapacity]); err != nil {
+		t.Fatalf("Error creating warehouse: %v", err)
+	}
+	if err := s.InsertProducts(ctx, warehouses[warehouseCapacity].Name, &bookProducts[0], 1, dto.InsertOptions{}); err != nil {
+		t.Fatalf("Error inserting product: %v", err)
+	}
+	if err := s.DeleteProduct(ctx, bookProducts[0].SKU); !errors.Is(err, ErrProductInStock) {
+		t.Fatalf("Should have failed to delete product with %v, got %v", ErrProductInStock, err)
+	}
+	if err := s.RemoveProducts(ctx, warehouses[warehouseCapacity].Name, bookProducts[0].SKU, 1, dto.RemoveOptions{}); err != nil {
+		t.Fatalf("Error removing product: %v", err)
+	}
+	if err := s.DeleteProduct(ctx, bookProducts[0].SKU); err != nil {
+		t.Fatalf("Error deleting product: %v", err)
+	}
+	if _, err := s.GetProduct(ctx, bookProducts[0].SKU); !errors.Is(err, ErrProductNotFound) {
+		t.Fatalf("Should have failed to get product with %v, got %v", ErrProductNotFound, err)
+	}
+}
+
+func TestInsertGlobalSkipsWarehouseBelowMinBrandQuality(t *testing.T) {
+	BeforeEach()
+	defer AfterEach()
+	requested := warehouses[2]
+	strict := warehouses[5]
+	strict.MinBrandQuality = bookProducts[0].Brand.Quality + 1
+	overflow := warehouses[6]
+	for _, warehouse := range []dto.Warehouse{requested, strict, overflow} {
+		if err := s.CreateWarehouse(ctx, warehouse); err != nil {
+			t.Fatalf("Error creating warehouse: %v", err)
+		}
+	}
+	if err := s.InsertProducts(ctx, requested.Name, &bookProducts[0], 5, dto.InsertOptions{}); err != nil {
+		t.Fatalf("Error inserting product: %v", err)
+	}
+	assertWarehouseQuantity(t, requested.Name, 2)
+	assertWarehouseQuantity(t, strict.Name, 0)
+	assertWarehouseQuantity(t, overflow.Name, 3)
+}
+
+func TestInsertErrorBrandQualityTooLow(t *testing.T) {
+	BeforeEach()
+	defer AfterEach()
+	strict := warehouses[5]
+	strict.MinBrandQuality = bookProducts[0].Brand.Quality + 1
+	if err := s.CreateWarehouse(ctx, strict); err != nil {
+		t.Fatalf("Error creating warehouse: %v", err)
+	}
+	if err := s.InsertProducts(ctx, strict.Name, &bookProducts[0], 1, dto.InsertOptions{}); !errors.Is(err, ErrBrandQualityTooLow) {
+		t.Fatalf("Should have failed to insert product with %v, got %v", ErrBrandQualityTooLow, err)
+	}
+}
+
+func TestUpdateBrandQuality(t *testing.T) {
+	BeforeEach()
+	defer AfterEach()
+	if err := s.CreateProduct(ctx, &bookProducts[0]); err != nil {
+		t.Fatalf("Error creating product: %v", err)
+	}
+	product := bookProducts[1]
+	product.Brand.Quality = 1
+	if err := s.CreateProduct(ctx, &product); !errors.Is(err, ErrBrandMismatch) {
+		t.Fatalf("Should have failed to create product with %v, got %v", ErrBrandMismatch, err)
+	}
+	if err := s.UpdateBrand(ctx, product.Brand); err != nil {
+		t.Fatalf("Error updating brand: %v", err)
+	}
+	brand, err := s.GetBrand(ctx, product.Brand.Name)
+	if err != nil {
+		t.Fatalf("Error getting brand: %v", err)
+	}
+	if brand.Quality != 1 || len(brand.Products) != 1 || brand.Products[0].GetBaseProduct().Brand.Quality != 1 {
+		t.Fatalf("Brand and its products should have the new quality, got %v", brand)
+	}
+	if err := s.DeleteBrand(ctx, product.Brand.Name); !errors.Is(err, ErrBrandInUse) {
+		t.Fatalf("Should have failed to delete brand with %v, got %v", ErrBrandInUse, err)
+	}
+}
+
+func TestTransferProductsSuccessful(t *testing.T) {
+	BeforeEach()
+	defer AfterEach()
+	from := warehouses[5]
+	to := warehouses[3]
+	for _, warehouse := range []dto.Warehouse{from, to} {
+		if err := s.CreateWarehouse(ctx, warehouse); err != nil {
+			t.Fatalf("Error creating warehouse: %v", err)
+		}
+	}
+	if err := s.InsertProducts(ctx, from.Name, &bookProducts[0], 4, dto.InsertOptions{}); err != nil {
+		t.Fatalf("Error inserting product: %v", err)
+	}
+	if err := s.TransferProducts(ctx, from.Name, to.Name, bookProducts[0].SKU, 3); err != nil {
+		t.Fatalf("Error transferring product: %v", err)
+	}
+	assertWarehouseQuantity(t, from.Name, 1)
+	assertWarehouseQuantity(t, to.Name, 3)
+}
+
+func TestTransferProductsErrorLeavesStockUnchanged(t *testing.T) {
+	BeforeEach()
+	defer AfterEach()
+	from := warehouses[5]
+	to := warehouses[2]
+	for _, warehouse := range []dto.Warehouse{from, to} {
+		if err := s.CreateWarehouse(ctx, warehouse); err != nil {
+			t.Fatalf("Error creating warehouse: %v", err)
+		}
+	}
+	if err := s.InsertProducts(ctx, from.Name, &bookProducts[0], 4, dto.InsertOptions{}); err != nil {
+		t.Fatalf("Error inserting product: %v", err)
+	}
+	if err := s.TransferProducts(ctx, from.Name, to.Name, bookProducts[0].SKU, 3); !errors.Is(err, ErrInsufficientCapacity) {
+		t.Fatalf("Should have failed to transfer product with %v, got %v", ErrInsufficientCapacity, err)
+	}
+	if err := s.TransferProducts(ctx, to.Name, from.Name, bookProducts[0].SKU, 1); !errors.Is(err, ErrInsufficientStock) {
+		t.Fatalf("Should have failed to transfer product with %v, got %v", ErrInsufficientStock, err)
+	}
+	if err := s.TransferProducts(ctx, from.Name, "missing", bookProducts[0].SKU, 1); !errors.Is(err, ErrWarehouseNotFound) {
+		t.Fatalf("Should have failed to transfer product with %v, got %v", ErrWarehouseNotFound, err)
+	}
+	assertWarehouseQuantity(t, from.Name, 4)
+	assertWarehouseQuantity(t, to.Name, 0)
+}
+
+func TestStockChangesWriteMovements(t *testing.T) {
+	BeforeEach()
+	defer AfterEach()
+	warehouse1 := warehouses[2]
+	warehouse2 := warehouses[5]
+	for _, warehouse := range []dto.Warehouse{warehouse1, warehouse2} {
+		if err := s.CreateWarehouse(ctx, warehouse); err != nil {
+			t.Fatalf("Error creating warehouse: %v", err)
+		}
+	}
+	sku := bookProducts[0].SKU
+	if err := s.InsertProducts(ctx, warehouse1.Name, &bookProducts[0], 4, dto.InsertOptions{}); err != nil {
+		t.Fatalf("Error inserting product: %v", err)
+	}
+	if err := s.RemoveProducts(ctx, warehouse2.Name, sku, 3, dto.RemoveOptions{}); err != nil {
+		t.Fatalf("Error removing product: %v", err)
+	}
+	movements, err := s.GetMovements(ctx, dto.MovementFilter{Sku: sku})
+	if err != nil {
+		t.Fatalf("Error listing movements: %v", err)
+	}
+	type change struct {
+		warehouse string
+		delta     int
+		reason    string
+	}
+	var changes []change
+	for _, movement := range movements {
+		changes = append(changes, change{movement.WarehouseName, movement.Delta, movement.Reason})
+	}
+	expected := []change{
+		{warehouse1.Name, 2, "insert"},
+		{warehouse2.Name, 2, "insert_overflow"},
+		{warehouse2.Name, -2, "remove"},
+		{warehouse1.Name, -1, "remove_overflow"},
+	}
+	if !reflect.DeepEqual(changes, expected) {
+		t.Fatalf("Movements should be %v, got %v", expected, changes)
+	}
+	if movements[0].CorrelationID == "" || movements[0].CorrelationID != movements[1].CorrelationID || movements[1].CorrelationID == movements[2].CorrelationID {
+		t.Fatalf("Movements of one call should share a correlation id, got %v", movements)
+	}
+}
+
+func TestInsertWithAllocationStrategy(t *testing.T) {
+	BeforeEach()
+	defer AfterEach()
+	for _, warehouse := range warehouses[2:5] {
+		if err := s.CreateWarehouse(ctx, warehouse); err != nil {
+			t.Fatalf("Error creating warehouse: %v", err)
+		}
+	}
+	err := s.InsertProducts(ctx, warehouses[2].Name, &bookProducts[0], 3, dto.InsertOptions{AllocationStrategy: AllocationLocalOnly})
+	if !errors.Is(err, ErrInsufficientCapacity) {
+		t.Fatalf("Expected ErrInsufficientCapacity, got %v", err)
+	}
+	assertWarehouseQuantity(t, warehouses[2].Name, 0)
+	if err := s.InsertProducts(ctx, warehouses[2].Name, &bookProducts[0], 3, dto.InsertOptions{AllocationStrategy: AllocationMostFreeCapacity}); err != nil {
+		t.Fatalf("Error inserting product: %v", err)
+	}
+	assertWarehouseQuantity(t, warehouses[2].Name, 0)
+	assertWarehouseQuantity(t, warehouses[4].Name, 3)
+	if err := s.InsertProducts(ctx, warehouses[2].Name, &bookProducts[0], 1, dto.InsertOptions{AllocationStrategy: "random"}); !errors.Is(err, ErrUnknownAllocationStrategy) {
+		t.Fatalf("Expected ErrUnknownAllocationStrategy, got %v", err)
+	}
+}
+
+func TestInsertUsesDefaultAllocationStrategy(t *testing.T) {
+	defer AfterEach()
+	s = NewInventoryService(currentBackend.newStore(), WithAllocationStrategy(localOnlyAllocation{}))
+	for _, warehouse := range warehouses[2:4] {
+		if err := s.CreateWarehouse(ctx, warehouse); err != nil {
+			t.Fatalf("Error creating warehouse: %v", err)
+		}
+	}
+	if err := s.InsertProducts(ctx, warehouses[2].Name, &bookProducts[0], 3, dto.InsertOptions{}); !errors.Is(err, ErrInsufficientCapacity) {
+		t.Fatalf("Expected ErrInsufficientCapacity, got %v", err)
+	}
+	if err := s.InsertProducts(ctx, warehouses[2].Name, &bookProducts[0], 3, dto.InsertOptions{AllocationStrategy: AllocationRequestedFirst}); err != nil {
+		t.Fatalf("Error inserting product: %v", err)
+	}
+}
+
+func TestRemoveWithFirstReceivedPicking(t *testing.T) {
+	BeforeEach()
+	defer AfterEach()
+	clock := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	s.(*inventoryService).now = func() time.Time { return clock }
+	for _, warehouse := range warehouses[3:5] {
+		if err := s.CreateWarehouse(ctx, warehouse); err != nil {
+			t.Fatalf("Error creating warehouse: %v", err)
+		}
+	}
+	if err := s.InsertProducts(ctx, warehouses[4].Name, &bookProducts[0], 2, dto.InsertOptions{}); err != nil {
+		t.Fatalf("Error inserting product: %v", err)
+	}
+	clock = clock.Add(time.Hour)
+	if err := s.InsertProducts(ctx, warehouses[3].Name, &bookProducts[0], 3, dto.InsertOptions{}); err != nil {
+		t.Fatalf("Error inserting product: %v", err)
+	}
+	if err := s.RemoveProducts(ctx, warehouses[3].Name, bookProducts[0].SKU, 3, dto.RemoveOptions{PickingStrategy: PickingFirstReceived}); err != nil {
+		t.Fatalf("Error removing product: %v", err)
+	}
+	assertWarehouseQuantity(t, warehouses[4].Name, 0)
+	assertWarehouseQuantity(t, warehouses[3].Name, 2)
+	err := s.RemoveProducts(ctx, warehouses[4].Name, bookProducts[0].SKU, 1, dto.RemoveOptions{PickingStrategy: PickingLocalOnly})
+	if !errors.Is(err, ErrInsufficientStock) {
+		t.Fatalf("Expected ErrInsufficientStock, got %v", err)
+	}
+	assertWarehouseQuantity(t, warehouses[3].Name, 2)
+}
+
+func TestPlanInsertChangesNothing(t *testing.T) {
+	BeforeEach()
+	defer AfterEach()
+	for _, warehouse := range warehouses[2:4] {
+		if err := s.CreateWarehouse(ctx, warehouse); err != nil {
+			t.Fatalf("Error creating warehouse: %v", err)
+		}
+	}
+	plan, err := s.PlanInsert(ctx, warehouses[2].Name, &bookProducts[0], 4, dto.InsertOptions{})
+	if err != nil {
+		t.Fatalf("Error planning insert: %v", err)
+	}
+	expected := dto.Plan{Quantity: 4, Shortfall: 0, Warehouses: []dto.PlannedQuantity{
+		{WarehouseName: warehouses[2].Name, Quantity: 2, RemainingCapacity: 0},
+		{WarehouseName: warehouses[3].Name, Quantity: 2, RemainingCapacity: 1},
+	}}
+	if !reflect.DeepEqual(plan, expected) {
+		t.Fatalf("Plan should be %v, got %v", expected, plan)
+	}
+	assertWarehouseQuantity(t, warehouses[2].Name, 0)
+	assertWarehouseQuantity(t, warehouses[3].Name, 0)
+	movements, err := s.GetMovements(ctx, dto.MovementFilter{})
+	if err != nil {
+		t.Fatalf("Error listing movements: %v", err)
+	}
+	if len(movements) != 0 {
+		t.Fatalf("Planning should not write movements, got %v", movements)
+	}
+
+	plan, err = s.PlanInsert(ctx, warehouses[2].Name, &bookProducts[0], 7, dto.InsertOptions{})
+	if err != nil {
+		t.Fatalf("Error planning insert: %v", err)
+	}
+	if plan.Shortfall != 2 || len(plan.Warehouses) != 2 {
+		t.Fatalf("Plan should fill both warehouses and miss 2, got %v", plan)
+	}
+}
+
+func TestPlanRemoveReportsShortfall(t *testing.T) {
+	BeforeEach()
+	defer AfterEach()
+	if err := s.CreateWarehouse(ctx, warehouses[5]); err != nil {
 		t.Fatalf("Error creating warehouse: %v", err)
 	}
-	if err := s.CreateWarehouse(warehouses[warehouse2Capacity]); err != nil {
+	if err := s.InsertProducts(ctx, warehouses[5].Name, &bookProducts[0], 3, dto.InsertOptions{}); err != nil {
+		t.Fatalf("Error inserting product: %v", err)
+	}
+	plan, err := s.PlanRemove(ctx, warehouses[5].Name, bookProducts[0].SKU, 4, dto.RemoveOptions{})
+	if err != nil {
+		t.Fatalf("Error planning removal: %v", err)
+	}
+	expected := dto.Plan{Quantity: 4, Shortfall: 1, Warehouses: []dto.PlannedQuantity{
+		{WarehouseName: warehouses[5].Name, Quantity: 3, RemainingCapacity: 5},
+	}}
+	if !reflect.DeepEqual(plan, expected) {
+		t.Fatalf("Plan should be %v, got %v", expected, plan)
+	}
+	assertWarehouseQuantity(t, warehouses[5].Name, 3)
+	if _, err := s.PlanRemove(ctx, "missing", bookProducts[0].SKU, 1, dto.RemoveOptions{}); !errors.Is(err, ErrWarehouseNotFound) {
+		t.Fatalf("Expected ErrWarehouseNotFound, got %v", err)
+	}
+}
+
+func TestStockAsOfRebuildsPastQuantities(t *testing.T) {
+	BeforeEach()
+	defer AfterEach()
+	clock := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	s.(*inventoryService).now = func() time.Time { return clock }
+	at := func(hour int) time.Time { return clock.Truncate(24 * time.Hour).Add(time.Duration(hour) * time.Hour) }
+	warehouse := warehouses[5]
+	if err := s.CreateWarehouse(ctx, warehouse); err != nil {
 		t.Fatalf("Error creating warehouse: %v", err)
 	}
-	if err := s.InsertProducts(warehouses[warehouse1Capacity].Name, &bookProducts[0], toInsert1Quantity); err != nil {
+	sku := bookProducts[0].SKU
+	clock = at(1)
+	if err := s.InsertProducts(ctx, warehouse.Name, &bookProducts[0], 4, dto.InsertOptions{}); err != nil {
 		t.Fatalf("Error inserting product: %v", err)
 	}
-	if err := s.InsertProducts(warehouses[warehouse2Capacity].Name, &bookProducts[0], toInsert2Quantity); err != nil {
+	clock = at(2)
+	if err := s.RemoveProducts(ctx, warehouse.Name, sku, 1, dto.RemoveOptions{}); err != nil {
+		t.Fatalf("Error removing product: %v", err)
+	}
+	clock = at(3)
+	newName := "Renamed"
+	if _, err := s.UpdateWarehouse(ctx, warehouse.Name, dto.UpdateWarehouseRequest{Name: &newName}); err != nil {
+		t.Fatalf("Error updating warehouse: %v", err)
+	}
+	clock = at(4)
+	if err := s.TakeSnapshot(ctx); err != nil {
+		t.Fatalf("Error taking snapshot: %v", err)
+	}
+	if err := s.TakeSnapshot(ctx); err != nil {
+		t.Fatalf("Taking the same snapshot again should be a no-op, got %v", err)
+	}
+	clock = at(5)
+	if err := s.InsertProducts(ctx, newName, &bookProducts[0], 2, dto.InsertOptions{}); err != nil {
 		t.Fatalf("Error inserting product: %v", err)
 	}
-	if err := s.RemoveProducts(warehouses[warehouse1Capacity].Name, bookProducts[0].SKU, toRemoveQuantity); err == nil {
-		t.Fatalf("Should have failed to remove product")
+
+	assertStock(t, sku, at(0), map[string]int{})
+	assertStock(t, sku, at(1), map[string]int{warehouse.Name: 4})
+	assertStock(t, sku, at(2), map[string]int{warehouse.Name: 3})
+	assertStock(t, sku, at(3), map[string]int{newName: 3})
+	assertStock(t, sku, at(4), map[string]int{newName: 3})
+	assertStock(t, sku, at(5), map[string]int{newName: 5})
+	assertStock(t, sku, time.Time{}, map[string]int{newName: 5})
+
+	past, err := s.GetWarehousesAsOf(ctx, at(4))
+	if err != nil {
+		t.Fatalf("Error listing warehouses as of a past time: %v", err)
+	}
+	if len(past) != 1 || len(past[0].Products) != 1 || past[0].Products[0].Quantity != 3 {
+		t.Fatalf("Warehouse should hold 3 products as of the snapshot, got %v", past)
+	}
+}
+
+func TestWarehousesAsOfKeepDeletedAndRenamedWarehouses(t *testing.T) {
+	BeforeEach()
+	defer AfterEach()
+	clock := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	s.(*inventoryService).now = func() time.Time { return clock }
+	at := func(hour int) time.Time { return clock.Truncate(24 * time.Hour).Add(time.Duration(hour) * time.Hour) }
+	deleted, renamed, created := warehouses[3], warehouses[7], warehouses[5]
+	for _, warehouse := range []dto.Warehouse{deleted, renamed} {
+		if err := s.CreateWarehouse(ctx, warehouse); err != nil {
+			t.Fatalf("Error creating warehouse: %v", err)
+		}
+	}
+	clock = at(1)
+	if err := s.InsertProducts(ctx, deleted.Name, &bookProducts[0], 2, dto.InsertOptions{}); err != nil {
+		t.Fatalf("Error inserting product: %v", err)
+	}
+	if err := s.InsertProducts(ctx, renamed.Name, &bookProducts[0], 3, dto.InsertOptions{}); err != nil {
+		t.Fatalf("Error inserting product: %v", err)
+	}
+	clock = at(2)
+	newName := "Renamed"
+	if _, err := s.UpdateWarehouse(ctx, renamed.Name, dto.UpdateWarehouseRequest{Name: &newName}); err != nil {
+		t.Fatalf("Error updating warehouse: %v", err)
+	}
+	clock = at(3)
+	if err := s.DeleteWarehouse(ctx, deleted.Name, true); err != nil {
+		t.Fatalf("Error deleting warehouse: %v", err)
+	}
+	clock = at(4)
+	if err := s.CreateWarehouse(ctx, created); err != nil {
+		t.Fatalf("Error creating warehouse: %v", err)
+	}
+
+	current := renamed
+	current.Name = newName
+	for _, test := range []struct {
+		asOf     time.Time
+		expected []dto.Warehouse
+		quantity []int
+	}{
+		{at(1), []dto.Warehouse{{Name: deleted.Name}, {Name: renamed.Name}}, []int{2, 3}},
+		{at(2), []dto.Warehouse{current, {Name: deleted.Name}}, []int{3, 2}},
+		{at(4), []dto.Warehouse{current}, []int{5}},
+	} {
+		past, err := s.GetWarehousesAsOf(ctx, test.asOf)
+		if err != nil {
+			t.Fatalf("Error listing warehouses as of %v: %v", test.asOf, err)
+		}
+		if len(past) != len(test.expected) {
+			t.Fatalf("Expected %d warehouses as of %v, got %v", len(test.expected), test.asOf, past)
+		}
+		for i, warehouse := range past {
+			if warehouse.Warehouse != test.expected[i] || len(warehouse.Products) != 1 || warehouse.Products[0].Quantity != test.quantity[i] {
+				t.Fatalf("Warehouse should be %v holding %d products as of %v, got %v", test.expected[i], test.quantity[i], test.asOf, warehouse)
+			}
+		}
+	}
+}
+
+func TestLotsArePickedAndMovedByExpiration(t *testing.T) {
+	BeforeEach()
+	defer AfterEach()
+	for _, warehouse := range []dto.Warehouse{warehouses[3], warehouses[5]} {
+		if err := s.CreateWarehouse(ctx, warehouse); err != nil {
+			t.Fatalf("Error creating warehouse: %v", err)
+		}
+	}
+	product := &consumableProducts[0]
+	inserts := []struct {
+		quantity int
+		lot      *dto.Lot
+	}{
+		{2, nil},
+		{2, &dto.Lot{LotNumber: "L2", ExpirationDate: dto.NewDate(2024, 6, 1)}},
+		{1, &dto.Lot{LotNumber: "L1", ExpirationDate: dto.NewDate(2024, 3, 1)}},
+	}
+	for _, insert := range inserts {
+		if err := s.InsertProducts(ctx, warehouses[5].Name, product, insert.quantity, dto.InsertOptions{Lot: insert.lot}); err != nil {
+			t.Fatalf("Error inserting product: %v", err)
+		}
+	}
+	assertLots(t, warehouses[5].Name, product.SKU, map[string]int{"L1": 1, "L2": 2})
+	err := s.InsertProducts(ctx, warehouses[3].Name, product, 1, dto.InsertOptions{Lot: &dto.Lot{LotNumber: "L1", ExpirationDate: dto.NewDate(2024, 4, 1)}})
+	if !errors.Is(err, ErrLotMismatch) {
+		t.Fatalf("Expected ErrLotMismatch, got %v", err)
+	}
+	err = s.InsertProducts(ctx, warehouses[3].Name, &bookProducts[0], 1, dto.InsertOptions{Lot: &dto.Lot{LotNumber: "L1", ExpirationDate: dto.NewDate(2024, 3, 1)}})
+	if !errors.Is(err, ErrInvalidLot) {
+		t.Fatalf("Expected ErrInvalidLot, got %v", err)
+	}
+
+	if err := s.RemoveProducts(ctx, warehouses[5].Name, product.SKU, 2, dto.RemoveOptions{PickingStrategy: PickingFirstExpiring}); err != nil {
+		t.Fatalf("Error removing product: %v", err)
+	}
+	assertLots(t, warehouses[5].Name, product.SKU, map[string]int{"L2": 1})
+	if err := s.TransferProducts(ctx, warehouses[5].Name, warehouses[3].Name, product.SKU, 1); err != nil {
+		t.Fatalf("Error transferring product: %v", err)
+	}
+	assertLots(t, warehouses[5].Name, product.SKU, map[string]int{})
+	assertLots(t, warehouses[3].Name, product.SKU, map[string]int{"L2": 1})
+	assertWarehouseQuantity(t, warehouses[5].Name, 2)
+}
+
+func TestQuarantinedStockCannotBePicked(t *testing.T) {
+	BeforeEach()
+	defer AfterEach()
+	s.(*inventoryService).now = func() time.Time { return time.Date(2024, 6, 15, 12, 0, 0, 0, time.UTC) }
+	if err := s.CreateWarehouse(ctx, warehouses[5]); err != nil {
+		t.Fatalf("Error creating warehouse: %v", err)
+	}
+	product := &consumableProducts[0]
+	for _, insert := range []struct {
+		quantity int
+		lot      *dto.Lot
+	}{
+		{2, nil},
+		{2, &dto.Lot{LotNumber: "L1", ExpirationDate: dto.NewDate(2024, 6, 1)}},
+		{1, &dto.Lot{LotNumber: "L2", ExpirationDate: dto.NewDate(2024, 6, 20)}},
+	} {
+		if err := s.InsertProducts(ctx, warehouses[5].Name, product, insert.quantity, dto.InsertOptions{Lot: insert.lot}); err != nil {
+			t.Fatalf("Error inserting product: %v", err)
+		}
+	}
+	report, err := s.GetExpiringStock(ctx, 30)
+	if err != nil {
+		t.Fatalf("Error getting expiring stock: %v", err)
+	}
+	expected := []dto.ExpiringStock{
+		{WarehouseName: warehouses[5].Name, Sku: product.SKU, LotNumber: "L1", ExpirationDate: dto.NewDate(2024, 6, 1), Quantity: 2, Expired: true},
+		{WarehouseName: warehouses[5].Name, Sku: product.SKU, LotNumber: "L2", ExpirationDate: dto.NewDate(2024, 6, 20), Quantity: 1},
+	}
+	if !reflect.DeepEqual(report, expected) {
+		t.Fatalf("Expiring stock should be %v, got %v", expected, report)
+	}
+
+	if quarantined, err := s.QuarantineExpired(ctx); err != nil || quarantined != 2 {
+		t.Fatalf("Quarantine should take 2 products, got %d, %v", quarantined, err)
+	}
+	report, err = s.GetExpiringStock(ctx, 0)
+	if err != nil {
+		t.Fatalf("Error getting expiring stock: %v", err)
+	}
+	expected = []dto.ExpiringStock{
+		{WarehouseName: warehouses[5].Name, Sku: product.SKU, LotNumber: "L1", ExpirationDate: dto.NewDate(2024, 6, 1), Quantity: 2, Expired: true, Quarantined: true},
+	}
+	if !reflect.DeepEqual(report, expected) {
+		t.Fatalf("Expiring stock should be %v, got %v", expected, report)
+	}
+	err = s.RemoveProducts(ctx, warehouses[5].Name, product.SKU, 4, dto.RemoveOptions{PickingStrategy: PickingLocalOnly})
+	if !errors.Is(err, ErrInsufficientStock) {
+		t.Fatalf("Expected ErrInsufficientStock, got %v", err)
+	}
+	if err := s.DeleteWarehouse(ctx, warehouses[5].Name, true); !errors.Is(err, ErrWarehouseNotEmpty) {
+		t.Fatalf("Expected ErrWarehouseNotEmpty, got %v", err)
+	}
+	if err := s.DiscardQuarantined(ctx, warehouses[5].Name); err != nil {
+		t.Fatalf("Error discarding quarantined stock: %v", err)
+	}
+	assertWarehouseQuantity(t, warehouses[5].Name, 3)
+	assertLots(t, warehouses[5].Name, product.SKU, map[string]int{"L2": 1})
+}
+
+func TestSerialsFollowElectronicsUnits(t *testing.T) {
+	BeforeEach()
+	defer AfterEach()
+	s.(*inventoryService).now = func() time.Time { return time.Date(2024, 6, 15, 12, 0, 0, 0, time.UTC) }
+	for _, warehouse := range []dto.Warehouse{warehouses[3], warehouses[5]} {
+		if err := s.CreateWarehouse(ctx, warehouse); err != nil {
+			t.Fatalf("Error creating warehouse: %v", err)
+		}
+	}
+	product := &electronicsProducts[0]
+	if err := s.InsertProducts(ctx, warehouses[5].Name, product, 2, dto.InsertOptions{}); err != nil {
+		t.Fatalf("Error inserting product: %v", err)
+	}
+	if err := s.InsertProducts(ctx, warehouses[5].Name, product, 2, dto.InsertOptions{Serials: []string{"SN-1", "SN-2"}}); err != nil {
+		t.Fatalf("Error inserting product: %v", err)
+	}
+	err := s.InsertProducts(ctx, warehouses[3].Name, product, 1, dto.InsertOptions{Serials: []string{"SN-1"}})
+	if !errors.Is(err, ErrDuplicateSerial) {
+		t.Fatalf("Expected ErrDuplicateSerial, got %v", err)
+	}
+	err = s.InsertProducts(ctx, warehouses[3].Name, &bookProducts[0], 1, dto.InsertOptions{Serials: []string{"SN-3"}})
+	if !errors.Is(err, ErrInvalidSerial) {
+		t.Fatalf("Expected ErrInvalidSerial, got %v", err)
+	}
+
+	// units without serial numbers are removed and moved first
+	if err := s.RemoveProducts(ctx, warehouses[5].Name, product.SKU, 1, dto.RemoveOptions{}); err != nil {
+		t.Fatalf("Error removing product: %v", err)
+	}
+	if err := s.TransferProducts(ctx, warehouses[5].Name, warehouses[3].Name, product.SKU, 2); err != nil {
+		t.Fatalf("Error transferring product: %v", err)
+	}
+	if err := s.RemoveProducts(ctx, warehouses[3].Name, product.SKU, 1, dto.RemoveOptions{Serials: []string{"SN-2"}}); err != nil {
+		t.Fatalf("Error removing product by serial: %v", err)
+	}
+	assertWarehouseQuantity(t, warehouses[5].Name, 0)
+	assertWarehouseQuantity(t, warehouses[3].Name, 2)
+	err = s.RemoveProducts(ctx, warehouses[3].Name, product.SKU, 1, dto.RemoveOptions{Serials: []string{"SN-2"}})
+	if !errors.Is(err, ErrSerialNotFound) {
+		t.Fatalf("Expected ErrSerialNotFound, got %v", err)
+	}
+
+	serial, err := s.GetSerial(ctx, "SN-1")
+	if err != nil {
+		t.Fatalf("Error getting serial: %v", err)
+	}
+	if serial.WarehouseName != warehouses[3].Name || !serial.InStock || serial.WarrantyEndsOn == nil || *serial.WarrantyEndsOn != dto.NewDate(2026, 6, 15) {
+		t.Fatalf("SN-1 should be in %s with a warranty until 2026-06-15, got %+v", warehouses[3].Name, serial)
+	}
+	assertSerialHistory(t, serial, "insert", "transfer_in")
+	serial, err = s.GetSerial(ctx, "SN-2")
+	if err != nil {
+		t.Fatalf("Error getting serial: %v", err)
+	}
+	if serial.InStock {
+		t.Fatalf("SN-2 should be out of stock, got %+v", serial)
+	}
+	assertSerialHistory(t, serial, "insert", "remove_overflow")
+	if _, err := s.GetSerial(ctx, "missing"); !errors.Is(err, ErrSerialNotFound) {
+		t.Fatalf("Expected ErrSerialNotFound, got %v", err)
+	}
+}
+
+func assertSerialHistory(t *testing.T, serial dto.Serial, expected ...string) {
+	var reasons []string
+	for _, event := range serial.History {
+		reasons = append(reasons, event.Reason)
+	}
+	if !reflect.DeepEqual(reasons, expected) {
+		t.Fatalf("History of %s should be %v, got %v", serial.Serial, expected, reasons)
+	}
+}
+
+func assertStock(t *testing.T, sku string, asOf time.Time, expected map[string]int) {
+	t.Helper()
+	stock, err := s.GetStock(ctx, sku, asOf)
+	if err != nil {
+		t.Fatalf("Error getting stock: %v", err)
+	}
+	actual := map[string]int{}
+	total := 0
+	for _, warehouse := range stock.Warehouses {
+		actual[warehouse.WarehouseName] = warehouse.Quantity
+		total += warehouse.Quantity
+	}
+	if !reflect.DeepEqual(actual, expected) || stock.Total != total {
+		t.Fatalf("Stock of %s as of %v should be %v, got %v", sku, asOf, expected, stock)
+	}
+}
+
+func assertWarehouseQuantity(t *testing.T, name string, expected int) {
+	t.Helper()
+	warehouse, err := s.GetWarehouse(ctx, name)
+	if err != nil {
+		t.Fatalf("Error getting warehouse: %v", err)
+	}
+	quantity := 0
+	for _, product := range warehouse.Products {
+		quantity += product.Quantity
+	}
+	if quantity != expected {
+		t.Fatalf("Warehouse %s should hold %d products, got %d", name, expected, quantity)
+	}
+}
+
+func assertLots(t *testing.T, name string, sku string, expected map[string]int) {
+	t.Helper()
+	warehouse, err := s.GetWarehouse(ctx, name)
+	if err != nil {
+		t.Fatalf("Error getting warehouse: %v", err)
+	}
+	actual := map[string]int{}
+	for _, product := range warehouse.Products {
+		if product.GetBaseProduct().SKU != sku {
+			continue
+		}
+		for _, lot := range product.Lots {
+			actual[lot.LotNumber] = lot.Quantity
+		}
+	}
+	if !reflect.DeepEqual(actual, expected) {
+		t.Fatalf("Lots of %s in %s should be %v, got %v", sku, name, expected, actual)
+	}
+}
+
+func TestProductConversionErrorMismatchedType(t *testing.T) {
+	entity := &domain.BookProduct{Product: domain.Product{SKU: "BOOK-A", Type: "Consumable"}}
+	if _, err := productEntityToDto(entity); err == nil {
+		t.Fatalf("Expected an error converting a book stored as a consumable")
+	}
+	product := &dto.BookProduct{Product: dto.Product{SKU: "BOOK-A", Type: "Electronics"}}
+	if _, err := productDtoToEntity(product); err == nil {
+		t.Fatalf("Expected an error converting a book sent as electronics")
 	}
 }
@@ -0,0 +1,63 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/kijevigombooc/inventory-manager/internal/inventory/store"
+	"github.com/kijevigombooc/inventory-manager/internal/inventory/store/domain"
+)
+
+func (s *inventoryService) TransferProducts(ctx context.Context, from string, to string, sku string, quantity int) error {
+	j := s.newJournal(ctx)
+	return s.runner.Run(ctx, func(trx store.Transaction) error {
+		return transferProducts(trx, j, from, to, sku, quantity)
+	})
+}
+
+// transferProducts moves stock between exactly two warehouses, unlike InsertProducts nothing spills over to other warehouses.
+func transferProducts(trx store.Transaction, j journal, from string, to string, sku string, quantity int) error {
+	if _, err := getWarehouse(trx, from); err != nil {
+		return err
+	}
+	destination, err := getWarehouse(trx, to)
+	if err != nil {
+		return err
+	}
+	product, err := getCatalogProduct(trx, sku)
+	if err != nil {
+		return err
+	}
+	brand := product.GetBaseProduct().Brand
+	if !acceptsBrand(destination, brand) {
+		return fmt.Errorf("%w: %s needs quality %d, %s has %d", ErrBrandQualityTooLow, to, destination.MinBrandQuality, brand.Name, brand.Quality)
+	}
+	usedCapacity, err := trx.GetUsedCapacity(to)
+	if err != nil {
+		return err
+	}
+	if availableCapacity := destination.Capacity - usedCapacity; availableCapacity < quantity {
+		return fmt.Errorf("%w: %s has room for %d, %d needed", ErrInsufficientCapacity, to, availableCapacity, quantity)
+	}
+	// lots are moved first, they are picked from the stock that is still in the source warehouse
+	pickedQuantity, err := moveLots(trx, from, to, sku, quantity)
+	if err != nil {
+		return err
+	}
+	if pickedQuantity < quantity {
+		return fmt.Errorf("%w: %s holds %d of %s outside of quarantine, %d requested", ErrInsufficientStock, from, pickedQuantity, sku, quantity)
+	}
+	if err := moveSerials(trx, j, from, to, sku, quantity, domain.MovementTransferIn); err != nil {
+		return err
+	}
+	if _, err := trx.RemoveProduct(from, sku, quantity); err != nil {
+		return err
+	}
+	if err := trx.InsertProduct(to, product, quantity); err != nil {
+		return err
+	}
+	if err := j.record(trx, from, sku, -quantity, domain.MovementTransferOut); err != nil {
+		return err
+	}
+	return j.record(trx, to, sku, quantity, domain.MovementTransferIn)
+}
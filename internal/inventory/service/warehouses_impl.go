@@ -0,0 +1,251 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/kijevigombooc/inventory-manager/internal/inventory/handler/dto"
+	"github.com/kijevigombooc/inventory-manager/internal/inventory/store"
+	"github.com/kijevigombooc/inventory-manager/internal/inventory/store/domain"
+	"github.com/kijevigombooc/inventory-manager/internal/utils"
+)
+
+func (s *inventoryService) GetWarehouse(ctx context.Context, name string) (dto.WarehouseDetail, error) {
+	var result dto.WarehouseDetail
+	err := s.runner.Run(ctx, func(trx store.Transaction) error {
+		warehouse, err := getWarehouse(trx, name)
+		if err != nil {
+			return err
+		}
+		result, err = warehouseDetail(trx, warehouse)
+		return err
+	})
+	return result, err
+}
+
+func (s *inventoryService) UpdateWarehouse(ctx context.Context, name string, update dto.UpdateWarehouseRequest) (dto.Warehouse, error) {
+	var result dto.Warehouse
+	j := s.newJournal(ctx)
+	err := s.runner.Run(ctx, func(trx store.Transaction) error {
+		warehouse, err := getWarehouse(trx, name)
+		if err != nil {
+			return err
+		}
+		if update.Name != nil {
+			warehouse.Name = *update.Name
+		}
+		if update.Address != nil {
+			warehouse.Address = *update.Address
+		}
+		if update.Capacity != nil {
+			warehouse.Capacity = *update.Capacity
+		}
+		if update.MinBrandQuality != nil {
+			warehouse.MinBrandQuality = *update.MinBrandQuality
+		}
+		usedCapacity, err := trx.GetUsedCapacity(name)
+		if err != nil {
+			return err
+		}
+		if excess := usedCapacity - warehouse.Capacity; excess > 0 {
+			if !update.Relocate {
+				return fmt.Errorf("%w: %s holds %d products, capacity %d is too small", ErrCapacityBelowUsage, name, usedCapacity, warehouse.Capacity)
+			}
+			if err := relocateStock(trx, j, name, excess); err != nil {
+				return err
+			}
+		}
+		err = trx.UpdateWarehouse(name, warehouse)
+		if errors.Is(err, store.ErrAlreadyExists) {
+			return fmt.Errorf("%w: %s", ErrDuplicateWarehouse, warehouse.Name)
+		}
+		if err != nil {
+			return err
+		}
+		if warehouse.Name != name {
+			if err := recordRename(trx, j, name, warehouse.Name); err != nil {
+				return err
+			}
+		}
+		result = warehouseEntityToDto(warehouse)
+		return nil
+	})
+	return result, err
+}
+
+func (s *inventoryService) DeleteWarehouse(ctx context.Context, name string, relocate bool) error {
+	j := s.newJournal(ctx)
+	return s.runner.Run(ctx, func(trx store.Transaction) error {
+		if _, err := getWarehouse(trx, name); err != nil {
+			return err
+		}
+		usedCapacity, err := trx.GetUsedCapacity(name)
+		if err != nil {
+			return err
+		}
+		if usedCapacity > 0 {
+			if !relocate {
+				return fmt.Errorf("%w: %s holds %d products", ErrWarehouseNotEmpty, name, usedCapacity)
+			}
+			quarantined, err := trx.GetQuarantinedStock(name, "")
+			if err != nil {
+				return err
+			}
+			if len(quarantined) > 0 {
+				return fmt.Errorf("%w: %s holds quarantined products, discard them first", ErrWarehouseNotEmpty, name)
+			}
+			if err := relocateStock(trx, j, name, usedCapacity); err != nil {
+				return err
+			}
+		}
+		return trx.DeleteWarehouse(name)
+	})
+}
+
+// relocateStock moves quantity products out of the warehouse, taking SKUs in order and
+// filling the other warehouses in name order. Quarantined stock stays where it is.
+func relocateStock(trx store.Transaction, j journal, warehouseName string, quantity int) error {
+	products, err := trx.GetProductsByWarehouse(warehouseName)
+	if err != nil {
+		return err
+	}
+	sort.Slice(products, func(i, j int) bool {
+		return products[i].Product.GetBaseProduct().SKU < products[j].Product.GetBaseProduct().SKU
+	})
+	warehouses, err := trx.GetWarehousesOrderedFirstWithName(warehouseName)
+	if err != nil {
+		return err
+	}
+	freeCapacities := map[string]int{}
+	for _, warehouse := range warehouses {
+		usedCapacity, err := trx.GetUsedCapacity(warehouse.Name)
+		if err != nil {
+			return err
+		}
+		freeCapacities[warehouse.Name] = warehouse.Capacity - usedCapacity
+	}
+	remainingQuantity := quantity
+	for _, product := range products {
+		sku := product.Product.GetBaseProduct().SKU
+		pickableQuantity, err := warehousePickableQuantity(trx, warehouseName, sku)
+		if err != nil {
+			return err
+		}
+		toMoveQuantity := min(pickableQuantity, remainingQuantity)
+		for _, target := range warehouses {
+			if target.Name == warehouseName || toMoveQuantity == 0 || !acceptsBrand(target, product.Product.GetBaseProduct().Brand) {
+				continue
+			}
+			movedQuantity := min(freeCapacities[target.Name], toMoveQuantity)
+			if movedQuantity <= 0 {
+				continue
+			}
+			pickedQuantity, err := moveLots(trx, warehouseName, target.Name, sku, movedQuantity)
+			if err != nil {
+				return err
+			}
+			if pickedQuantity < movedQuantity {
+				return fmt.Errorf("%w: %s holds %d of %s outside of quarantine, %d to relocate", ErrInsufficientStock, warehouseName, pickedQuantity, sku, movedQuantity)
+			}
+			if err := moveSerials(trx, j, warehouseName, target.Name, sku, movedQuantity, domain.MovementRelocateIn); err != nil {
+				return err
+			}
+			if _, err := trx.RemoveProduct(warehouseName, sku, movedQuantity); err != nil {
+				return err
+			}
+			if err := trx.InsertProduct(target.Name, product.Product, movedQuantity); err != nil {
+				return err
+			}
+			if err := j.record(trx, warehouseName, sku, -movedQuantity, domain.MovementRelocateOut); err != nil {
+				return err
+			}
+			if err := j.record(trx, target.Name, sku, movedQuantity, domain.MovementRelocateIn); err != nil {
+				return err
+			}
+			freeCapacities[target.Name] -= movedQuantity
+			toMoveQuantity -= movedQuantity
+			remainingQuantity -= movedQuantity
+		}
+		if toMoveQuantity > 0 {
+			return fmt.Errorf("%w: %d of %s cannot be relocated from %s", ErrInsufficientCapacity, toMoveQuantity, sku, warehouseName)
+		}
+		if remainingQuantity == 0 {
+			break
+		}
+	}
+	if remainingQuantity > 0 {
+		return fmt.Errorf("%w: %d quarantined products cannot be relocated from %s", ErrInsufficientCapacity, remainingQuantity, warehouseName)
+	}
+	return nil
+}
+
+// warehousePickableQuantity is the stock of a SKU in the warehouse that is not quarantined.
+func warehousePickableQuantity(trx store.Transaction, warehouseName string, sku string) (int, error) {
+	warehouseProducts, err := trx.GetWarehouseProductsBySkuOrderedFirstWithName(warehouseName, sku)
+	if err != nil {
+		return 0, err
+	}
+	if len(warehouseProducts) == 0 || warehouseProducts[0].WarehouseName != warehouseName {
+		return 0, nil
+	}
+	quarantined, err := trx.GetQuarantinedStock(warehouseName, sku)
+	if err != nil {
+		return 0, err
+	}
+	quantity := warehouseProducts[0].Quantity
+	for _, stock := range quarantined {
+		quantity -= stock.Quantity
+	}
+	return max(quantity, 0), nil
+}
+
+// recordRename moves the stock to the new name in the ledger, so that the history of a warehouse
+// can still be rebuilt from movements after a rename.
+func recordRename(trx store.Transaction, j journal, oldName string, newName string) error {
+	products, err := trx.GetProductsByWarehouse(newName)
+	if err != nil {
+		return err
+	}
+	for _, product := range products {
+		sku := product.Product.GetBaseProduct().SKU
+		if err := j.record(trx, oldName, sku, -product.Quantity, domain.MovementRenameOut); err != nil {
+			return err
+		}
+		if err := j.record(trx, newName, sku, product.Quantity, domain.MovementRenameIn); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func getWarehouse(trx store.Transaction, name string) (domain.Warehouse, error) {
+	warehouse, err := trx.GetWarehouse(name)
+	if errors.Is(err, store.ErrNotFound) {
+		return domain.Warehouse{}, fmt.Errorf("%w: %s", ErrWarehouseNotFound, name)
+	}
+	return warehouse, err
+}
+
+func warehouseDetail(trx store.Transaction, warehouse domain.Warehouse) (dto.WarehouseDetail, error) {
+	productEntities, err := trx.GetProductsByWarehouse(warehouse.Name)
+	if err != nil {
+		return dto.WarehouseDetail{}, err
+	}
+	productDtos, err := utils.MapErrored(productEntities, productWithQuantityEntityToDto)
+	if err != nil {
+		return dto.WarehouseDetail{}, err
+	}
+	lots, err := lotQuantitiesBySku(trx, warehouse.Name)
+	if err != nil {
+		return dto.WarehouseDetail{}, err
+	}
+	for i := range productDtos {
+		productDtos[i].Lots = lots[productDtos[i].GetBaseProduct().SKU]
+	}
+	return dto.WarehouseDetail{
+		Warehouse: warehouseEntityToDto(warehouse),
+		Products:  productDtos,
+	}, nil
+}
@@ -1,6 +1,9 @@
 package domain
 
+import "time"
+
 type ConsumableProduct struct {
 	Product
-	ExpirationDate string
+	// ExpirationDate is midnight UTC of the last day the product can be used
+	ExpirationDate time.Time
 }
@@ -2,5 +2,5 @@ package domain
 
 type ElectronicsProduct struct {
 	Product
-	WarrantyPeriod string
+	WarrantyPeriod Period
 }
@@ -0,0 +1,16 @@
+package domain
+
+import "time"
+
+type Lot struct {
+	Sku            string
+	Number         string
+	ExpirationDate time.Time
+	ReceivedAt     time.Time
+}
+
+type WarehouseLot struct {
+	WarehouseName string
+	Lot           Lot
+	Quantity      int
+}
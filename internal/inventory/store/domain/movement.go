@@ -0,0 +1,41 @@
+package domain
+
+import "time"
+
+type MovementReason string
+
+const (
+	MovementInsert         MovementReason = "insert"
+	MovementInsertOverflow MovementReason = "insert_overflow"
+	MovementRemove         MovementReason = "remove"
+	MovementRemoveOverflow MovementReason = "remove_overflow"
+	MovementTransferOut    MovementReason = "transfer_out"
+	MovementTransferIn     MovementReason = "transfer_in"
+	MovementRelocateOut    MovementReason = "relocate_out"
+	MovementRelocateIn     MovementReason = "relocate_in"
+	MovementRenameOut      MovementReason = "rename_out"
+	MovementRenameIn       MovementReason = "rename_in"
+	MovementDiscard        MovementReason = "discard"
+	// MovementOpeningBalance is written by the migration for stock that predates the ledger.
+	MovementOpeningBalance MovementReason = "opening_balance"
+)
+
+// Movement is one change of the quantity of a SKU in a warehouse, movements are never changed once written.
+type Movement struct {
+	ID            int64
+	Timestamp     time.Time
+	WarehouseName string
+	Sku           string
+	Delta         int
+	Reason        MovementReason
+	CorrelationID string
+	Actor         string
+}
+
+// MovementFilter matches every movement on its zero value, From is inclusive and To is exclusive.
+type MovementFilter struct {
+	Sku           string
+	WarehouseName string
+	From          time.Time
+	To            time.Time
+}
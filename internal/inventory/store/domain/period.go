@@ -0,0 +1,13 @@
+package domain
+
+import "time"
+
+// Period is a calendar length such as a warranty, years are kept as months so that periods sort by months and then days.
+type Period struct {
+	Months int
+	Days   int
+}
+
+func (p Period) AddTo(t time.Time) time.Time {
+	return t.AddDate(0, p.Months, p.Days)
+}
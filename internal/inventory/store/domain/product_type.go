@@ -2,9 +2,4 @@ package domain
 
 type ProductType string
 
-const (
-	Book        ProductType = "Book"
-	Consumable  ProductType = "Consumable"
-	Electronics ProductType = "Electronics"
-	None        ProductType = "None"
-)
+const None ProductType = "None"
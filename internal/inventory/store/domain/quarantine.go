@@ -0,0 +1,12 @@
+package domain
+
+import "time"
+
+// QuarantinedStock is expired stock that still takes up space in the warehouse but can no longer be picked.
+type QuarantinedStock struct {
+	WarehouseName  string
+	Sku            string
+	LotNumber      string
+	ExpirationDate time.Time
+	Quantity       int
+}
@@ -0,0 +1,23 @@
+package domain
+
+import "time"
+
+// Serial is one electronics unit, it keeps its history after the unit left the inventory.
+type Serial struct {
+	Number string
+	Sku    string
+	// WarehouseName is empty once the unit was removed
+	WarehouseName string
+	ReceivedAt    time.Time
+}
+
+// SerialEvent is one move of a unit, the warehouse is the one the unit entered or, for removals, left.
+type SerialEvent struct {
+	ID            int64
+	Serial        string
+	Timestamp     time.Time
+	WarehouseName string
+	Reason        MovementReason
+	CorrelationID string
+	Actor         string
+}
@@ -1,7 +1,8 @@
 package domain
 
 type Warehouse struct {
-	Name     string
-	Address  string
-	Capacity int
+	Name            string
+	Address         string
+	Capacity        int
+	MinBrandQuality int
 }
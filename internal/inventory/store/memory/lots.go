@@ -0,0 +1,84 @@
+package memory
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+
+	"github.com/kijevigombooc/inventory-manager/internal/inventory/store"
+	"github.com/kijevigombooc/inventory-manager/internal/inventory/store/domain"
+)
+
+func (t *MemoryTransaction) GetLot(sku string, lotNumber string) (domain.Lot, error) {
+	lot, ok := t.read().lots[lotKey{sku, lotNumber}]
+	if !ok {
+		return domain.Lot{}, fmt.Errorf("%w: lot %s of %s", store.ErrNotFound, lotNumber, sku)
+	}
+	return lot, nil
+}
+
+func (t *MemoryTransaction) InsertLot(lot domain.Lot) error {
+	key := lotKey{lot.Sku, lot.Number}
+	if _, ok := t.read().lots[key]; ok {
+		return fmt.Errorf("%w: lot %s of %s", store.ErrAlreadyExists, lot.Number, lot.Sku)
+	}
+	if _, ok := t.read().products[lot.Sku]; !ok {
+		return fmt.Errorf("%w: product %s", store.ErrNotFound, lot.Sku)
+	}
+	t.write().lots[key] = lot
+	return nil
+}
+
+func (t *MemoryTransaction) GetWarehouseLots(warehouseName string, sku string) ([]domain.WarehouseLot, error) {
+	state := t.read()
+	var result []domain.WarehouseLot
+	for key, quantity := range state.lotStock {
+		if (warehouseName != "" && key.warehouseName != warehouseName) || (sku != "" && key.lot.sku != sku) {
+			continue
+		}
+		result = append(result, domain.WarehouseLot{WarehouseName: key.warehouseName, Lot: state.lots[key.lot], Quantity: quantity})
+	}
+	slices.SortFunc(result, func(a, b domain.WarehouseLot) int {
+		return cmp.Or(
+			cmp.Compare(a.WarehouseName, b.WarehouseName),
+			cmp.Compare(a.Lot.Sku, b.Lot.Sku),
+			a.Lot.ExpirationDate.Compare(b.Lot.ExpirationDate),
+			cmp.Compare(a.Lot.Number, b.Lot.Number),
+		)
+	})
+	return result, nil
+}
+
+func (t *MemoryTransaction) ChangeLotQuantity(warehouseName string, sku string, lotNumber string, delta int) error {
+	key := lotStockKey{warehouseName, lotKey{sku, lotNumber}}
+	if _, ok := t.read().lots[key.lot]; !ok {
+		return fmt.Errorf("%w: lot %s of %s", store.ErrNotFound, lotNumber, sku)
+	}
+	if _, ok := t.read().warehouses[warehouseName]; !ok {
+		return fmt.Errorf("%w: warehouse %s", store.ErrNotFound, warehouseName)
+	}
+	quantity := t.read().lotStock[key]
+	newQuantity := quantity + delta
+	if newQuantity < 0 {
+		return fmt.Errorf("lot %s of %s has %d in %s, cannot take %d", lotNumber, sku, quantity, warehouseName, -delta)
+	}
+	if newQuantity == 0 {
+		delete(t.write().lotStock, key)
+	} else {
+		t.write().lotStock[key] = newQuantity
+	}
+	return nil
+}
+
+func (s *state) deleteLots(sku string) {
+	for key := range s.lots {
+		if key.sku == sku {
+			delete(s.lots, key)
+		}
+	}
+	for key := range s.lotStock {
+		if key.lot.sku == sku {
+			delete(s.lotStock, key)
+		}
+	}
+}
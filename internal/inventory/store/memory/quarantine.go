@@ -0,0 +1,53 @@
+package memory
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+
+	"github.com/kijevigombooc/inventory-manager/internal/inventory/store"
+	"github.com/kijevigombooc/inventory-manager/internal/inventory/store/domain"
+)
+
+func (t *MemoryTransaction) GetQuarantinedStock(warehouseName string, sku string) ([]domain.QuarantinedStock, error) {
+	var result []domain.QuarantinedStock
+	for key, stock := range t.read().quarantine {
+		if (warehouseName != "" && key.warehouseName != warehouseName) || (sku != "" && key.sku != sku) {
+			continue
+		}
+		result = append(result, stock)
+	}
+	slices.SortFunc(result, func(a, b domain.QuarantinedStock) int {
+		return cmp.Or(
+			cmp.Compare(a.WarehouseName, b.WarehouseName),
+			cmp.Compare(a.Sku, b.Sku),
+			cmp.Compare(a.LotNumber, b.LotNumber),
+		)
+	})
+	return result, nil
+}
+
+func (t *MemoryTransaction) QuarantineStock(stock domain.QuarantinedStock) error {
+	if _, ok := t.read().warehouses[stock.WarehouseName]; !ok {
+		return fmt.Errorf("%w: warehouse %s", store.ErrNotFound, stock.WarehouseName)
+	}
+	if _, ok := t.read().products[stock.Sku]; !ok {
+		return fmt.Errorf("%w: product %s", store.ErrNotFound, stock.Sku)
+	}
+	key := quarantineKey{stock.WarehouseName, stock.Sku, stock.LotNumber}
+	if quarantined, ok := t.read().quarantine[key]; ok {
+		stock.Quantity += quarantined.Quantity
+	}
+	t.write().quarantine[key] = stock
+	return nil
+}
+
+func (t *MemoryTransaction) DeleteQuarantinedStock(warehouseName string) error {
+	state := t.write()
+	for key := range state.quarantine {
+		if key.warehouseName == warehouseName {
+			delete(state.quarantine, key)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,71 @@
+package memory
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+
+	"github.com/kijevigombooc/inventory-manager/internal/inventory/store"
+	"github.com/kijevigombooc/inventory-manager/internal/inventory/store/domain"
+)
+
+func (t *MemoryTransaction) GetSerial(number string) (domain.Serial, error) {
+	serial, ok := t.read().serials[number]
+	if !ok {
+		return domain.Serial{}, fmt.Errorf("%w: serial %s", store.ErrNotFound, number)
+	}
+	return serial, nil
+}
+
+func (t *MemoryTransaction) GetWarehouseSerials(warehouseName string, sku string) ([]domain.Serial, error) {
+	var result []domain.Serial
+	for _, serial := range t.read().serials {
+		if serial.WarehouseName == warehouseName && serial.Sku == sku {
+			result = append(result, serial)
+		}
+	}
+	slices.SortFunc(result, func(a, b domain.Serial) int {
+		return cmp.Or(a.ReceivedAt.Compare(b.ReceivedAt), cmp.Compare(a.Number, b.Number))
+	})
+	return result, nil
+}
+
+func (t *MemoryTransaction) InsertSerial(serial domain.Serial) error {
+	if _, ok := t.read().serials[serial.Number]; ok {
+		return fmt.Errorf("%w: serial %s", store.ErrAlreadyExists, serial.Number)
+	}
+	serial.ReceivedAt = serial.ReceivedAt.UTC()
+	t.write().serials[serial.Number] = serial
+	return nil
+}
+
+func (t *MemoryTransaction) UpdateSerial(serial domain.Serial) error {
+	if _, err := t.GetSerial(serial.Number); err != nil {
+		return err
+	}
+	serial.ReceivedAt = serial.ReceivedAt.UTC()
+	t.write().serials[serial.Number] = serial
+	return nil
+}
+
+func (t *MemoryTransaction) InsertSerialEvent(event domain.SerialEvent) error {
+	state := t.write()
+	state.lastSerialEventID++
+	event.ID = state.lastSerialEventID
+	event.Timestamp = event.Timestamp.UTC()
+	state.serialEvents = append(state.serialEvents, event)
+	return nil
+}
+
+func (t *MemoryTransaction) GetSerialEvents(number string) ([]domain.SerialEvent, error) {
+	var result []domain.SerialEvent
+	for _, event := range t.read().serialEvents {
+		if event.Serial == number {
+			result = append(result, event)
+		}
+	}
+	slices.SortStableFunc(result, func(a, b domain.SerialEvent) int {
+		return a.Timestamp.Compare(b.Timestamp)
+	})
+	return result, nil
+}
@@ -0,0 +1,106 @@
+package memory
+
+import (
+	"maps"
+	"reflect"
+	"slices"
+	"time"
+
+	"github.com/kijevigombooc/inventory-manager/internal/inventory/store/domain"
+)
+
+type stockKey struct {
+	warehouseName string
+	sku           string
+}
+
+type lotKey struct {
+	sku    string
+	number string
+}
+
+type quarantineKey struct {
+	warehouseName string
+	sku           string
+	lotNumber     string
+}
+
+type lotStockKey struct {
+	warehouseName string
+	lot           lotKey
+}
+
+// state is never modified once published, transactions write to a clone of it.
+type state struct {
+	warehouses        map[string]domain.Warehouse
+	brands            map[string]domain.Brand
+	products          map[string]domain.IProduct
+	stock             map[stockKey]int
+	lots              map[lotKey]domain.Lot
+	lotStock          map[lotStockKey]int
+	quarantine        map[quarantineKey]domain.QuarantinedStock
+	serials           map[string]domain.Serial
+	movements         []domain.Movement
+	lastMovementID    int64
+	serialEvents      []domain.SerialEvent
+	lastSerialEventID int64
+	snapshots         []snapshot
+}
+
+type snapshot struct {
+	takenAt        time.Time
+	lastMovementID int64
+	stock          map[stockKey]int
+}
+
+func newState() *state {
+	return &state{
+		warehouses: map[string]domain.Warehouse{},
+		brands:     map[string]domain.Brand{},
+		products:   map[string]domain.IProduct{},
+		stock:      map[stockKey]int{},
+		lots:       map[lotKey]domain.Lot{},
+		lotStock:   map[lotStockKey]int{},
+		quarantine: map[quarantineKey]domain.QuarantinedStock{},
+		serials:    map[string]domain.Serial{},
+	}
+}
+
+// clone copies the maps, products can be shared because they are copied on the way in and out.
+func (s *state) clone() *state {
+	return &state{
+		warehouses: maps.Clone(s.warehouses),
+		brands:     maps.Clone(s.brands),
+		products:   maps.Clone(s.products),
+		stock:      maps.Clone(s.stock),
+		lots:       maps.Clone(s.lots),
+		lotStock:   maps.Clone(s.lotStock),
+		quarantine: maps.Clone(s.quarantine),
+		serials:    maps.Clone(s.serials),
+		// clipped so appends in concurrent transactions never share the backing array
+		movements:         slices.Clip(s.movements),
+		lastMovementID:    s.lastMovementID,
+		serialEvents:      slices.Clip(s.serialEvents),
+		lastSerialEventID: s.lastSerialEventID,
+		snapshots:         slices.Clip(s.snapshots),
+	}
+}
+
+func (s *state) product(sku string) domain.IProduct {
+	product := cloneProduct(s.products[sku])
+	baseProduct := product.GetBaseProduct()
+	baseProduct.Brand = s.brands[baseProduct.Brand.Name]
+	product.SetBaseProduct(baseProduct)
+	return product
+}
+
+// cloneProduct copies the product struct of any type, products hold no pointers so a shallow copy is enough.
+func cloneProduct(product domain.IProduct) domain.IProduct {
+	if product == nil {
+		return nil
+	}
+	value := reflect.ValueOf(product).Elem()
+	clone := reflect.New(value.Type())
+	clone.Elem().Set(value)
+	return clone.Interface().(domain.IProduct)
+}
@@ -0,0 +1,53 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"github.com/kijevigombooc/inventory-manager/internal/inventory/store"
+)
+
+var ErrConflict = errors.New("transaction conflicts with a concurrently committed transaction")
+
+func NewInventoryStore() *inventoryStore {
+	return &inventoryStore{state: newState()}
+}
+
+type inventoryStore struct {
+	mu      sync.RWMutex
+	state   *state
+	version uint64
+}
+
+func (s *inventoryStore) Init() error {
+	return nil
+}
+
+func (s *inventoryStore) IsRetryable(err error) bool {
+	return errors.Is(err, ErrConflict)
+}
+
+func (s *inventoryStore) BeginTransaction(ctx context.Context) (store.Transaction, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return &MemoryTransaction{ctx: ctx, store: s, base: s.state, baseVersion: s.version}, nil
+}
+
+// commit publishes the transaction's copy unless another transaction committed since it began.
+func (s *inventoryStore) commit(t *MemoryTransaction) error {
+	if t.working == nil {
+		return nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.version != t.baseVersion {
+		return ErrConflict
+	}
+	s.state = t.working
+	s.version++
+	return nil
+}
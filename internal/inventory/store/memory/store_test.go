@@ -0,0 +1,14 @@
+package memory
+
+import (
+	"testing"
+
+	"github.com/kijevigombooc/inventory-manager/internal/inventory/store"
+	"github.com/kijevigombooc/inventory-manager/internal/inventory/store/storetest"
+)
+
+func TestMemoryConformance(t *testing.T) {
+	storetest.RunTransactionSuite(t, func(t *testing.T) store.Store {
+		return NewInventoryStore()
+	})
+}
@@ -0,0 +1,428 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/kijevigombooc/inventory-manager/internal/inventory/store"
+	"github.com/kijevigombooc/inventory-manager/internal/inventory/store/domain"
+)
+
+type MemoryTransaction struct {
+	ctx         context.Context
+	store       *inventoryStore
+	base        *state
+	baseVersion uint64
+	working     *state
+	done        bool
+}
+
+func (t *MemoryTransaction) CommitTransaction() error {
+	if t.done {
+		return fmt.Errorf("transaction already finished")
+	}
+	t.done = true
+	if err := t.ctx.Err(); err != nil {
+		return err
+	}
+	return t.store.commit(t)
+}
+
+func (t *MemoryTransaction) RollbackTransaction() error {
+	if t.done {
+		return fmt.Errorf("transaction already finished")
+	}
+	t.done = true
+	t.working = nil
+	return nil
+}
+
+func (t *MemoryTransaction) EndTransaction() {
+	if p := recover(); p != nil {
+		t.done = true
+		panic(p)
+	}
+	t.done = true
+}
+
+func (t *MemoryTransaction) GetWarehouses() ([]domain.Warehouse, error) {
+	return t.sortedWarehouses(""), nil
+}
+
+func (t *MemoryTransaction) GetWarehousesOrderedFirstWithName(warehouse string) ([]domain.Warehouse, error) {
+	return t.sortedWarehouses(warehouse), nil
+}
+
+func (t *MemoryTransaction) GetWarehouse(name string) (domain.Warehouse, error) {
+	warehouse, ok := t.read().warehouses[name]
+	if !ok {
+		return domain.Warehouse{}, fmt.Errorf("%w: warehouse %s", store.ErrNotFound, name)
+	}
+	return warehouse, nil
+}
+
+func (t *MemoryTransaction) InsertWarehouse(entity domain.Warehouse) error {
+	if _, ok := t.read().warehouses[entity.Name]; ok {
+		return fmt.Errorf("%w: warehouse %s", store.ErrAlreadyExists, entity.Name)
+	}
+	t.write().warehouses[entity.Name] = entity
+	return nil
+}
+
+func (t *MemoryTransaction) UpdateWarehouse(name string, entity domain.Warehouse) error {
+	if _, err := t.GetWarehouse(name); err != nil {
+		return err
+	}
+	if _, ok := t.read().warehouses[entity.Name]; ok && entity.Name != name {
+		return fmt.Errorf("%w: warehouse %s", store.ErrAlreadyExists, entity.Name)
+	}
+	state := t.write()
+	delete(state.warehouses, name)
+	state.warehouses[entity.Name] = entity
+	for key, quantity := range state.stock {
+		if key.warehouseName == name && entity.Name != name {
+			delete(state.stock, key)
+			state.stock[stockKey{entity.Name, key.sku}] = quantity
+		}
+	}
+	for key, quantity := range state.lotStock {
+		if key.warehouseName == name && entity.Name != name {
+			delete(state.lotStock, key)
+			state.lotStock[lotStockKey{entity.Name, key.lot}] = quantity
+		}
+	}
+	for key, stock := range state.quarantine {
+		if key.warehouseName == name && entity.Name != name {
+			delete(state.quarantine, key)
+			stock.WarehouseName = entity.Name
+			state.quarantine[quarantineKey{entity.Name, key.sku, key.lotNumber}] = stock
+		}
+	}
+	for number, serial := range state.serials {
+		if serial.WarehouseName == name {
+			serial.WarehouseName = entity.Name
+			state.serials[number] = serial
+		}
+	}
+	return nil
+}
+
+func (t *MemoryTransaction) DeleteWarehouse(name string) error {
+	if _, err := t.GetWarehouse(name); err != nil {
+		return err
+	}
+	usedCapacity, _ := t.GetUsedCapacity(name)
+	if usedCapacity > 0 {
+		return fmt.Errorf("warehouse %s still holds %d products", name, usedCapacity)
+	}
+	state := t.write()
+	delete(state.warehouses, name)
+	for key := range state.stock {
+		if key.warehouseName == name {
+			delete(state.stock, key)
+		}
+	}
+	return nil
+}
+
+func (t *MemoryTransaction) GetProductsByWarehouse(name string) ([]domain.ProductWithQuantity, error) {
+	state := t.read()
+	var products []domain.ProductWithQuantity
+	for key, quantity := range state.stock {
+		if key.warehouseName != name {
+			continue
+		}
+		products = append(products, domain.ProductWithQuantity{
+			Product:  state.product(key.sku),
+			Quantity: quantity,
+		})
+	}
+	sort.Slice(products, func(i, j int) bool {
+		return products[i].Product.GetBaseProduct().SKU < products[j].Product.GetBaseProduct().SKU
+	})
+	return products, nil
+}
+
+func (t *MemoryTransaction) GetUsedCapacity(warehouseName string) (int, error) {
+	usedCapacity := 0
+	for key, quantity := range t.read().stock {
+		if key.warehouseName == warehouseName {
+			usedCapacity += quantity
+		}
+	}
+	return usedCapacity, nil
+}
+
+func (t *MemoryTransaction) InsertProduct(warehouseName string, product domain.IProduct, toInsertQuantity int) error {
+	if _, ok := t.read().warehouses[warehouseName]; !ok {
+		return fmt.Errorf("%w: warehouse %s", store.ErrNotFound, warehouseName)
+	}
+	baseProduct := product.GetBaseProduct()
+	if _, ok := t.read().products[baseProduct.SKU]; !ok {
+		if err := t.putProduct(product); err != nil {
+			return err
+		}
+	}
+	if toInsertQuantity == 0 {
+		return nil
+	}
+	t.write().stock[stockKey{warehouseName, baseProduct.SKU}] += toInsertQuantity
+	return nil
+}
+
+func (t *MemoryTransaction) GetProductTypeBySku(sku string) (domain.ProductType, error) {
+	product, ok := t.read().products[sku]
+	if !ok {
+		return domain.None, nil
+	}
+	return product.GetType(), nil
+}
+
+func (t *MemoryTransaction) GetWarehouseProductsBySkuOrderedFirstWithName(warehouseName string, sku string) ([]domain.WarehouseProduct, error) {
+	var result []domain.WarehouseProduct
+	for key, quantity := range t.read().stock {
+		if key.sku == sku {
+			result = append(result, domain.WarehouseProduct{WarehouseName: key.warehouseName, Sku: key.sku, Quantity: quantity})
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return orderedFirstWithName(warehouseName, result[i].WarehouseName, result[j].WarehouseName)
+	})
+	return result, nil
+}
+
+func (t *MemoryTransaction) RemoveProduct(warehouseName string, sku string, toRemoveQuantity int) (int, error) {
+	key := stockKey{warehouseName, sku}
+	originalQuantity, ok := t.read().stock[key]
+	if !ok {
+		return 0, nil
+	}
+	newQuantity := max(originalQuantity-toRemoveQuantity, 0)
+	if newQuantity == 0 {
+		delete(t.write().stock, key)
+	} else {
+		t.write().stock[key] = newQuantity
+	}
+	return originalQuantity - newQuantity, nil
+}
+
+func (t *MemoryTransaction) GetCatalogProducts() ([]domain.IProduct, error) {
+	var result []domain.IProduct
+	state := t.read()
+	for sku := range state.products {
+		result = append(result, state.product(sku))
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].GetBaseProduct().SKU < result[j].GetBaseProduct().SKU
+	})
+	return result, nil
+}
+
+func (t *MemoryTransaction) GetCatalogProduct(sku string) (domain.IProduct, error) {
+	state := t.read()
+	if _, ok := state.products[sku]; !ok {
+		return nil, fmt.Errorf("%w: product %s", store.ErrNotFound, sku)
+	}
+	return state.product(sku), nil
+}
+
+func (t *MemoryTransaction) InsertCatalogProduct(product domain.IProduct) error {
+	baseProduct := product.GetBaseProduct()
+	if _, ok := t.read().products[baseProduct.SKU]; ok {
+		return fmt.Errorf("%w: product %s", store.ErrAlreadyExists, baseProduct.SKU)
+	}
+	return t.putProduct(product)
+}
+
+func (t *MemoryTransaction) UpdateCatalogProduct(product domain.IProduct) error {
+	baseProduct := product.GetBaseProduct()
+	existing, ok := t.read().products[baseProduct.SKU]
+	if !ok {
+		return fmt.Errorf("%w: product %s", store.ErrNotFound, baseProduct.SKU)
+	}
+	if existing.GetType() != product.GetType() {
+		return fmt.Errorf("product %s is %s, cannot change it to %s", baseProduct.SKU, existing.GetType(), product.GetType())
+	}
+	return t.putProduct(product)
+}
+
+func (t *MemoryTransaction) DeleteCatalogProduct(sku string) error {
+	if _, ok := t.read().products[sku]; !ok {
+		return fmt.Errorf("%w: product %s", store.ErrNotFound, sku)
+	}
+	quantity := 0
+	for key, stock := range t.read().stock {
+		if key.sku == sku {
+			quantity += stock
+		}
+	}
+	if quantity > 0 {
+		return fmt.Errorf("product %s still has %d in stock", sku, quantity)
+	}
+	state := t.write()
+	delete(state.products, sku)
+	state.deleteLots(sku)
+	for key := range state.stock {
+		if key.sku == sku {
+			delete(state.stock, key)
+		}
+	}
+	return nil
+}
+
+func (t *MemoryTransaction) GetCatalogProductsByBrand(brandName string) ([]domain.IProduct, error) {
+	products, _ := t.GetCatalogProducts()
+	var result []domain.IProduct
+	for _, product := range products {
+		if product.GetBaseProduct().Brand.Name == brandName {
+			result = append(result, product)
+		}
+	}
+	return result, nil
+}
+
+func (t *MemoryTransaction) DeleteUnusedProducts() (int, error) {
+	stocked := map[string]bool{}
+	for key := range t.read().stock {
+		stocked[key.sku] = true
+	}
+	var unused []string
+	for sku := range t.read().products {
+		if !stocked[sku] {
+			unused = append(unused, sku)
+		}
+	}
+	if len(unused) == 0 {
+		return 0, nil
+	}
+	state := t.write()
+	for _, sku := range unused {
+		delete(state.products, sku)
+		state.deleteLots(sku)
+	}
+	return len(unused), nil
+}
+
+func (t *MemoryTransaction) DeleteUnusedBrands() (int, error) {
+	used := map[string]bool{}
+	for _, product := range t.read().products {
+		used[product.GetBaseProduct().Brand.Name] = true
+	}
+	var unused []string
+	for name := range t.read().brands {
+		if !used[name] {
+			unused = append(unused, name)
+		}
+	}
+	if len(unused) == 0 {
+		return 0, nil
+	}
+	state := t.write()
+	for _, name := range unused {
+		delete(state.brands, name)
+	}
+	return len(unused), nil
+}
+
+// DeleteOrphanedProductAttributes has nothing to do, the attributes live inside the products.
+func (t *MemoryTransaction) DeleteOrphanedProductAttributes() (int, error) {
+	return 0, nil
+}
+
+func (t *MemoryTransaction) GetBrands() ([]domain.Brand, error) {
+	var result []domain.Brand
+	for _, brand := range t.read().brands {
+		result = append(result, brand)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Name < result[j].Name
+	})
+	return result, nil
+}
+
+func (t *MemoryTransaction) GetBrand(name string) (domain.Brand, error) {
+	brand, ok := t.read().brands[name]
+	if !ok {
+		return domain.Brand{}, fmt.Errorf("%w: brand %s", store.ErrNotFound, name)
+	}
+	return brand, nil
+}
+
+func (t *MemoryTransaction) InsertBrand(brand domain.Brand) error {
+	if _, ok := t.read().brands[brand.Name]; ok {
+		return fmt.Errorf("%w: brand %s", store.ErrAlreadyExists, brand.Name)
+	}
+	t.write().brands[brand.Name] = brand
+	return nil
+}
+
+func (t *MemoryTransaction) UpdateBrand(brand domain.Brand) error {
+	if _, err := t.GetBrand(brand.Name); err != nil {
+		return err
+	}
+	t.write().brands[brand.Name] = brand
+	return nil
+}
+
+func (t *MemoryTransaction) DeleteBrand(name string) error {
+	if _, err := t.GetBrand(name); err != nil {
+		return err
+	}
+	products, _ := t.GetCatalogProductsByBrand(name)
+	if len(products) > 0 {
+		return fmt.Errorf("brand %s still has %d products", name, len(products))
+	}
+	delete(t.write().brands, name)
+	return nil
+}
+
+// putProduct stores the product and, like the SQL store, keeps the quality of a brand that already exists.
+func (t *MemoryTransaction) putProduct(product domain.IProduct) error {
+	clone := cloneProduct(product)
+	if clone == nil {
+		return fmt.Errorf("unknown product type: %s", product.GetType())
+	}
+	baseProduct := product.GetBaseProduct()
+	state := t.write()
+	if _, ok := state.brands[baseProduct.Brand.Name]; !ok {
+		state.brands[baseProduct.Brand.Name] = baseProduct.Brand
+	}
+	state.products[baseProduct.SKU] = clone
+	return nil
+}
+
+func (t *MemoryTransaction) read() *state {
+	if t.working != nil {
+		return t.working
+	}
+	return t.base
+}
+
+func (t *MemoryTransaction) write() *state {
+	if t.done {
+		panic("memory transaction used after it was finished")
+	}
+	if t.working == nil {
+		t.working = t.base.clone()
+	}
+	return t.working
+}
+
+func (t *MemoryTransaction) sortedWarehouses(firstName string) []domain.Warehouse {
+	var result []domain.Warehouse
+	for _, warehouse := range t.read().warehouses {
+		result = append(result, warehouse)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return orderedFirstWithName(firstName, result[i].Name, result[j].Name)
+	})
+	return result
+}
+
+func orderedFirstWithName(firstName string, a string, b string) bool {
+	if (a == firstName) != (b == firstName) {
+		return a == firstName
+	}
+	return a < b
+}
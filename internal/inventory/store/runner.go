@@ -0,0 +1,73 @@
+package store
+
+import (
+	"context"
+	"math/rand/v2"
+	"time"
+)
+
+type RetryPolicy struct {
+	MaxAttempts    int
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+}
+
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts:    5,
+	InitialBackoff: 10 * time.Millisecond,
+	MaxBackoff:     500 * time.Millisecond,
+}
+
+func NewTransactionRunner(store Store, policy RetryPolicy) *TransactionRunner {
+	return &TransactionRunner{store: store, policy: policy}
+}
+
+type TransactionRunner struct {
+	store  Store
+	policy RetryPolicy
+}
+
+// Run calls fn inside a transaction and commits it, starting over in a new transaction
+// while the store reports the failure as retryable and attempts are left.
+func (r *TransactionRunner) Run(ctx context.Context, fn func(trx Transaction) error) error {
+	return r.run(ctx, fn, true)
+}
+
+// RunRolledBack is Run for what-if questions, the transaction is always rolled back.
+func (r *TransactionRunner) RunRolledBack(ctx context.Context, fn func(trx Transaction) error) error {
+	return r.run(ctx, fn, false)
+}
+
+func (r *TransactionRunner) run(ctx context.Context, fn func(trx Transaction) error, commit bool) error {
+	backoff := r.policy.InitialBackoff
+	for attempt := 1; ; attempt++ {
+		err := r.runOnce(ctx, fn, commit)
+		if err == nil || attempt >= r.policy.MaxAttempts || !r.store.IsRetryable(err) {
+			return err
+		}
+		// full jitter keeps competing transactions from retrying in lockstep
+		timer := time.NewTimer(time.Duration(rand.Int64N(int64(backoff) + 1)))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		case <-timer.C:
+		}
+		backoff = min(backoff*2, r.policy.MaxBackoff)
+	}
+}
+
+func (r *TransactionRunner) runOnce(ctx context.Context, fn func(trx Transaction) error, commit bool) error {
+	trx, err := r.store.BeginTransaction(ctx)
+	if err != nil {
+		return err
+	}
+	defer trx.EndTransaction()
+	if err := fn(trx); err != nil {
+		return err
+	}
+	if !commit {
+		return nil
+	}
+	return trx.CommitTransaction()
+}
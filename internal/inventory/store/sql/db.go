@@ -0,0 +1,71 @@
+package sql
+
+import (
+	"database/sql"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	_ "github.com/jackc/pgx/v5/stdlib"
+	_ "github.com/mattn/go-sqlite3"
+)
+
+const InMemoryPath = ":memory:"
+
+const sqliteBusyTimeout = 5 * time.Second
+
+// Open connects to postgres for postgres:// and postgresql:// URLs and treats anything else as a SQLite file path.
+func Open(dsn string) (*sql.DB, *Dialect, error) {
+	if strings.HasPrefix(dsn, "postgres://") || strings.HasPrefix(dsn, "postgresql://") {
+		db, err := OpenPostgresDB(dsn)
+		return db, PostgresDialect, err
+	}
+	db, err := OpenSqliteDB(dsn)
+	return db, SqliteDialect, err
+}
+
+func OpenSqliteDB(path string) (*sql.DB, error) {
+	if path == "" {
+		return nil, fmt.Errorf("database path is empty")
+	}
+	params := url.Values{}
+	params.Set("_foreign_keys", "on")
+	params.Set("_busy_timeout", fmt.Sprint(sqliteBusyTimeout.Milliseconds()))
+	inMemory := path == InMemoryPath
+	if !inMemory {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			return nil, fmt.Errorf("creating database directory: %w", err)
+		}
+		params.Set("_journal_mode", "WAL")
+	}
+	// escaped so that ?, # and % in the path do not cut it short or swallow the parameters
+	dsn := url.URL{Scheme: "file", Opaque: (&url.URL{Path: path}).EscapedPath(), RawQuery: params.Encode()}
+	db, err := sql.Open(SqliteDialect.driverName, dsn.String())
+	if err != nil {
+		return nil, err
+	}
+	if inMemory {
+		// every connection would get its own empty in-memory database
+		db.SetMaxOpenConns(1)
+	}
+	if err := db.Ping(); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("opening database %s: %w", path, err)
+	}
+	return db, nil
+}
+
+func OpenPostgresDB(dsn string) (*sql.DB, error) {
+	db, err := sql.Open(PostgresDialect.driverName, dsn)
+	if err != nil {
+		return nil, err
+	}
+	if err := db.Ping(); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("connecting to postgres: %w", err)
+	}
+	return db, nil
+}
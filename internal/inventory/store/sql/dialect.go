@@ -0,0 +1,91 @@
+package sql
+
+import (
+	"database/sql"
+	"errors"
+	"strconv"
+	"strings"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/kijevigombooc/inventory-manager/internal/inventory/store/sql/migration"
+	"github.com/kijevigombooc/inventory-manager/internal/inventory/store/sql/query"
+	"github.com/mattn/go-sqlite3"
+)
+
+type Dialect struct {
+	Name                 string
+	driverName           string
+	numberedPlaceholders bool
+	isolation            sql.IsolationLevel
+	migrations           []migration.Migration
+	// lockMovements makes snapshots wait for transactions still writing movements, it is empty where writers are serialized
+	lockMovements     string
+	isRetryable       func(err error) bool
+	isUniqueViolation func(err error) bool
+}
+
+var SqliteDialect = &Dialect{
+	Name:       "sqlite",
+	driverName: "sqlite3",
+	migrations: sqliteMigrations,
+	isRetryable: func(err error) bool {
+		var sqliteErr sqlite3.Error
+		return errors.As(err, &sqliteErr) && (sqliteErr.Code == sqlite3.ErrBusy || sqliteErr.Code == sqlite3.ErrLocked)
+	},
+	isUniqueViolation: func(err error) bool {
+		var sqliteErr sqlite3.Error
+		return errors.As(err, &sqliteErr) &&
+			(sqliteErr.ExtendedCode == sqlite3.ErrConstraintPrimaryKey || sqliteErr.ExtendedCode == sqlite3.ErrConstraintUnique)
+	},
+}
+
+var PostgresDialect = &Dialect{
+	Name:                 "postgres",
+	driverName:           "pgx",
+	numberedPlaceholders: true,
+	// capacity checks read before they write, weaker levels would let concurrent inserts overfill a warehouse
+	isolation:     sql.LevelSerializable,
+	migrations:    postgresMigrations,
+	lockMovements: query.LockStockMovements,
+	isRetryable: func(err error) bool {
+		var pgErr *pgconn.PgError
+		// serialization_failure and deadlock_detected
+		return errors.As(err, &pgErr) && (pgErr.Code == "40001" || pgErr.Code == "40P01")
+	},
+	isUniqueViolation: func(err error) bool {
+		var pgErr *pgconn.PgError
+		return errors.As(err, &pgErr) && pgErr.Code == "23505"
+	},
+}
+
+// Rebind numbers the ? placeholders for postgres. Quoted strings and identifiers are copied as they are,
+// a literal ? such as in the jsonb ?| operator is written as ??.
+func (d *Dialect) Rebind(query string) string {
+	if !d.numberedPlaceholders {
+		return query
+	}
+	var builder strings.Builder
+	builder.Grow(len(query) + 8)
+	n := 0
+	var quote byte
+	for i := 0; i < len(query); i++ {
+		c := query[i]
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '\'' || c == '"':
+			quote = c
+		case c == '?' && i+1 < len(query) && query[i+1] == '?':
+			i++
+		case c == '?':
+			n++
+			builder.WriteByte('$')
+			builder.WriteString(strconv.Itoa(n))
+			continue
+		}
+		builder.WriteByte(c)
+	}
+	return builder.String()
+}
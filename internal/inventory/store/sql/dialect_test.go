@@ -0,0 +1,20 @@
+package sql
+
+import "testing"
+
+func TestPostgresRebind(t *testing.T) {
+	for query, expected := range map[string]string{
+		"SELECT a FROM t WHERE b = ? AND c = ?":           "SELECT a FROM t WHERE b = $1 AND c = $2",
+		`SELECT 'what?', "b?" FROM t WHERE c = ?`:         `SELECT 'what?', "b?" FROM t WHERE c = $1`,
+		"SELECT 'it''s ?' FROM t WHERE b = ?":             "SELECT 'it''s ?' FROM t WHERE b = $1",
+		"SELECT a FROM t WHERE tags ??| ? AND b ?? 'x'":   "SELECT a FROM t WHERE tags ?| $1 AND b ? 'x'",
+		"SELECT a FROM t WHERE (? = '' OR b = ?) LIMIT ?": "SELECT a FROM t WHERE ($1 = '' OR b = $2) LIMIT $3",
+	} {
+		if actual := PostgresDialect.Rebind(query); actual != expected {
+			t.Fatalf("Rebind(%q) should be %q, got %q", query, expected, actual)
+		}
+	}
+	if actual := SqliteDialect.Rebind("SELECT ?"); actual != "SELECT ?" {
+		t.Fatalf("SQLite placeholders should be kept, got %q", actual)
+	}
+}
@@ -0,0 +1,96 @@
+package sql
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/kijevigombooc/inventory-manager/internal/inventory/store"
+	"github.com/kijevigombooc/inventory-manager/internal/inventory/store/domain"
+	"github.com/kijevigombooc/inventory-manager/internal/inventory/store/sql/query"
+)
+
+// dateLayout keeps expiration dates sortable as text.
+const dateLayout = "2006-01-02"
+
+func (t *SqlTransaction) GetLot(sku string, lotNumber string) (domain.Lot, error) {
+	lot, err := scanLot(t.queryRow(query.SelectLot, sku, lotNumber))
+	if err == sql.ErrNoRows {
+		return domain.Lot{}, fmt.Errorf("%w: lot %s of %s", store.ErrNotFound, lotNumber, sku)
+	}
+	return lot, err
+}
+
+func (t *SqlTransaction) InsertLot(lot domain.Lot) error {
+	_, err := t.GetLot(lot.Sku, lot.Number)
+	if err := ensureMissing(err, fmt.Sprintf("lot %s of %s", lot.Number, lot.Sku)); err != nil {
+		return err
+	}
+	_, err = t.exec(query.InsertIntoLots, lot.Sku, lot.Number, lot.ExpirationDate.Format(dateLayout), formatTimestamp(lot.ReceivedAt))
+	return err
+}
+
+func (t *SqlTransaction) GetWarehouseLots(warehouseName string, sku string) ([]domain.WarehouseLot, error) {
+	rows, err := t.query(query.SelectWarehouseLots, warehouseName, warehouseName, sku, sku)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var result []domain.WarehouseLot
+	for rows.Next() {
+		var warehouseLot domain.WarehouseLot
+		var expirationDate, receivedAt string
+		if err := rows.Scan(&warehouseLot.WarehouseName, &warehouseLot.Lot.Sku, &warehouseLot.Lot.Number, &expirationDate, &receivedAt, &warehouseLot.Quantity); err != nil {
+			return nil, err
+		}
+		if warehouseLot.Lot.ExpirationDate, warehouseLot.Lot.ReceivedAt, err = parseLotTimes(expirationDate, receivedAt); err != nil {
+			return nil, err
+		}
+		result = append(result, warehouseLot)
+	}
+	return result, rows.Err()
+}
+
+// ChangeLotQuantity removes the row once the lot is gone from the warehouse.
+func (t *SqlTransaction) ChangeLotQuantity(warehouseName string, sku string, lotNumber string, delta int) error {
+	quantity := 0
+	err := t.queryRow(query.SelectWarehouseLotQuantity, warehouseName, sku, lotNumber).Scan(&quantity)
+	exists := err == nil
+	if err != nil && err != sql.ErrNoRows {
+		return err
+	}
+	newQuantity := quantity + delta
+	switch {
+	case newQuantity < 0:
+		return fmt.Errorf("lot %s of %s has %d in %s, cannot take %d", lotNumber, sku, quantity, warehouseName, -delta)
+	case newQuantity == 0:
+		if exists {
+			_, err = t.exec(query.DeleteWarehouseLot, warehouseName, sku, lotNumber)
+		}
+	case exists:
+		_, err = t.exec(query.UpdateWarehouseLotQuantity, newQuantity, warehouseName, sku, lotNumber)
+	default:
+		_, err = t.exec(query.InsertIntoWarehouseLots, warehouseName, sku, lotNumber, newQuantity)
+	}
+	return err
+}
+
+func scanLot(row *sql.Row) (domain.Lot, error) {
+	var lot domain.Lot
+	var expirationDate, receivedAt string
+	if err := row.Scan(&lot.Sku, &lot.Number, &expirationDate, &receivedAt); err != nil {
+		return domain.Lot{}, err
+	}
+	var err error
+	lot.ExpirationDate, lot.ReceivedAt, err = parseLotTimes(expirationDate, receivedAt)
+	return lot, err
+}
+
+func parseLotTimes(expirationDate string, receivedAt string) (time.Time, time.Time, error) {
+	expiration, err := time.Parse(dateLayout, expirationDate)
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+	received, err := parseTimestamp(receivedAt)
+	return expiration, received, err
+}
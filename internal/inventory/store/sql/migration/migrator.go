@@ -0,0 +1,249 @@
+package migration
+
+import (
+	"cmp"
+	"database/sql"
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"time"
+)
+
+var ErrSchemaTooNew = errors.New("database schema is newer than this binary supports")
+
+type Migration struct {
+	// Scope numbers a set of migrations apart from the others, the versions of each scope are kept in their own
+	// table. The store migrations have no scope.
+	Scope   string
+	Version int
+	Name    string
+	Up      []string
+	// UpFunc runs after the Up statements for changes SQL cannot express, it may be nil
+	UpFunc func(tx *sql.Tx, bind func(query string) string) error
+	Down   []string
+}
+
+type Status struct {
+	Migration
+	Applied   bool
+	AppliedAt time.Time
+}
+
+var scopePattern = regexp.MustCompile(`^[a-z0-9_]*$`)
+
+// NewMigrator orders the migrations by scope, the unscoped ones first, and by version within a scope.
+func NewMigrator(db *sql.DB, migrations []Migration, bind func(query string) string) (*Migrator, error) {
+	sorted := slices.Clone(migrations)
+	for i, migration := range sorted {
+		if !scopePattern.MatchString(migration.Scope) {
+			return nil, fmt.Errorf("migration scope %q is not a lowercase identifier", migration.Scope)
+		}
+		for _, other := range sorted[:i] {
+			if other.Scope == migration.Scope && other.Version == migration.Version {
+				return nil, fmt.Errorf("migrations %s and %s both have version %d", other.Name, migration.Name, migration.Version)
+			}
+		}
+	}
+	slices.SortStableFunc(sorted, func(a, b Migration) int {
+		return cmp.Or(cmp.Compare(a.Scope, b.Scope), cmp.Compare(a.Version, b.Version))
+	})
+	return &Migrator{db: db, migrations: sorted, bind: bind}, nil
+}
+
+type Migrator struct {
+	db         *sql.DB
+	migrations []Migration
+	bind       func(query string) string
+}
+
+// LatestVersion and CurrentVersion are the versions of the unscoped migrations.
+func (m *Migrator) LatestVersion() int {
+	return m.latestVersion("")
+}
+
+func (m *Migrator) CurrentVersion() (int, error) {
+	return m.currentVersion("")
+}
+
+func (m *Migrator) latestVersion(scope string) int {
+	latest := 0
+	for _, migration := range m.migrations {
+		if migration.Scope == scope {
+			latest = migration.Version
+		}
+	}
+	return latest
+}
+
+func (m *Migrator) currentVersion(scope string) (int, error) {
+	if err := m.ensureVersionTable(scope); err != nil {
+		return 0, err
+	}
+	var version int
+	if err := m.db.QueryRow(fmt.Sprintf(selectCurrentVersion, versionTable(scope))).Scan(&version); err != nil {
+		return 0, err
+	}
+	return version, nil
+}
+
+func (m *Migrator) Check() error {
+	for _, scope := range m.scopes() {
+		current, err := m.currentVersion(scope)
+		if err != nil {
+			return err
+		}
+		if latest := m.latestVersion(scope); current > latest {
+			return fmt.Errorf("%w: %s is at version %d, latest known version is %d", ErrSchemaTooNew, versionTable(scope), current, latest)
+		}
+	}
+	return nil
+}
+
+func (m *Migrator) Status() ([]Status, error) {
+	applied, err := m.appliedVersions()
+	if err != nil {
+		return nil, err
+	}
+	result := make([]Status, 0, len(m.migrations))
+	for _, migration := range m.migrations {
+		appliedAt, ok := applied[migration.Scope][migration.Version]
+		result = append(result, Status{Migration: migration, Applied: ok, AppliedAt: appliedAt})
+	}
+	return result, nil
+}
+
+// Up applies at most steps pending migrations, all of them if steps is not positive.
+func (m *Migrator) Up(steps int) ([]Migration, error) {
+	if err := m.Check(); err != nil {
+		return nil, err
+	}
+	applied, err := m.appliedVersions()
+	if err != nil {
+		return nil, err
+	}
+	var done []Migration
+	for _, migration := range m.migrations {
+		if steps > 0 && len(done) == steps {
+			break
+		}
+		if _, ok := applied[migration.Scope][migration.Version]; ok {
+			continue
+		}
+		versionQuery := fmt.Sprintf(insertVersion, versionTable(migration.Scope))
+		if err := m.apply(migration.Up, migration.UpFunc, versionQuery, migration.Version, migration.Name, time.Now().UTC()); err != nil {
+			return done, fmt.Errorf("applying migration %s: %w", migration, err)
+		}
+		done = append(done, migration)
+	}
+	return done, nil
+}
+
+func (m *Migrator) Down(steps int) ([]Migration, error) {
+	if err := m.Check(); err != nil {
+		return nil, err
+	}
+	applied, err := m.appliedVersions()
+	if err != nil {
+		return nil, err
+	}
+	var done []Migration
+	for i := len(m.migrations) - 1; i >= 0 && len(done) < steps; i-- {
+		migration := m.migrations[i]
+		if _, ok := applied[migration.Scope][migration.Version]; !ok {
+			continue
+		}
+		versionQuery := fmt.Sprintf(deleteVersion, versionTable(migration.Scope))
+		if err := m.apply(migration.Down, nil, versionQuery, migration.Version); err != nil {
+			return done, fmt.Errorf("rolling back migration %s: %w", migration, err)
+		}
+		done = append(done, migration)
+	}
+	return done, nil
+}
+
+func (m *Migrator) apply(statements []string, fn func(tx *sql.Tx, bind func(query string) string) error, versionQuery string, versionArgs ...any) error {
+	tx, err := m.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	for _, statement := range statements {
+		if _, err := tx.Exec(statement); err != nil {
+			return err
+		}
+	}
+	if fn != nil {
+		if err := fn(tx, m.bind); err != nil {
+			return err
+		}
+	}
+	if _, err := tx.Exec(m.bind(versionQuery), versionArgs...); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// appliedVersions maps the scopes to their applied versions.
+func (m *Migrator) appliedVersions() (map[string]map[int]time.Time, error) {
+	result := map[string]map[int]time.Time{}
+	for _, scope := range m.scopes() {
+		applied, err := m.scopeAppliedVersions(scope)
+		if err != nil {
+			return nil, err
+		}
+		result[scope] = applied
+	}
+	return result, nil
+}
+
+func (m *Migrator) scopeAppliedVersions(scope string) (map[int]time.Time, error) {
+	if err := m.ensureVersionTable(scope); err != nil {
+		return nil, err
+	}
+	rows, err := m.db.Query(fmt.Sprintf(selectAppliedVersions, versionTable(scope)))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	result := map[int]time.Time{}
+	for rows.Next() {
+		var version int
+		var appliedAt time.Time
+		if err := rows.Scan(&version, &appliedAt); err != nil {
+			return nil, err
+		}
+		result[version] = appliedAt
+	}
+	return result, rows.Err()
+}
+
+// scopes lists the scopes in the order of the migrations, the unscoped migrations are always checked.
+func (m *Migrator) scopes() []string {
+	result := []string{""}
+	for _, migration := range m.migrations {
+		if migration.Scope != result[len(result)-1] {
+			result = append(result, migration.Scope)
+		}
+	}
+	return result
+}
+
+func (m *Migrator) ensureVersionTable(scope string) error {
+	_, err := m.db.Exec(fmt.Sprintf(createSchemaMigrationsTable, versionTable(scope)))
+	return err
+}
+
+func versionTable(scope string) string {
+	if scope == "" {
+		return versionTablePrefix
+	}
+	return versionTablePrefix + "_" + scope
+}
+
+func (m Migration) String() string {
+	if m.Scope == "" {
+		return fmt.Sprintf("%d_%s", m.Version, m.Name)
+	}
+	return fmt.Sprintf("%s/%d_%s", m.Scope, m.Version, m.Name)
+}
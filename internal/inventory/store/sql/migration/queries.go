@@ -0,0 +1,16 @@
+package migration
+
+// The version table of a scope is named after it, the verbs take the table name.
+const versionTablePrefix = "schema_migrations"
+
+const createSchemaMigrationsTable = `
+	CREATE TABLE IF NOT EXISTS %s (
+		version INTEGER PRIMARY KEY,
+		name TEXT NOT NULL,
+		applied_at TIMESTAMP NOT NULL
+	)
+`
+const selectCurrentVersion = "SELECT COALESCE(MAX(version), 0) FROM %s"
+const selectAppliedVersions = "SELECT version, applied_at FROM %s ORDER BY version"
+const insertVersion = "INSERT INTO %s (version, name, applied_at) VALUES (?, ?, ?)"
+const deleteVersion = "DELETE FROM %s WHERE version = ?"
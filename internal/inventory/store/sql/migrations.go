@@ -0,0 +1,272 @@
+package sql
+
+import (
+	"database/sql"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/kijevigombooc/inventory-manager/internal/inventory/producttype"
+	"github.com/kijevigombooc/inventory-manager/internal/inventory/store/sql/migration"
+	"github.com/kijevigombooc/inventory-manager/internal/inventory/store/sql/query"
+)
+
+var sqliteMigrations = []migration.Migration{
+	createInventoryTables(query.CreateWarehousesTable),
+	addWarehouseMinBrandQuality,
+	createStockMovements(query.CreateStockMovementsTable),
+	createStockSnapshots(query.CreateStockSnapshotsTable, query.SqliteCurrentTimestamp),
+	sqliteCheckWarehouseProductQuantities,
+	createLots,
+	createQuarantinedStock,
+	typeProductAttributes(query.SqliteLegacyWarrantyMatch),
+	createSerials(query.CreateSerialEventsTable),
+	trackSnapshotMovements,
+}
+
+var postgresMigrations = []migration.Migration{
+	createInventoryTables(query.PostgresCreateWarehousesTable),
+	addWarehouseMinBrandQuality,
+	createStockMovements(query.PostgresCreateStockMovementsTable),
+	createStockSnapshots(query.PostgresCreateStockSnapshotsTable, query.PostgresCurrentTimestamp),
+	postgresCheckWarehouseProductQuantities,
+	createLots,
+	createQuarantinedStock,
+	typeProductAttributes(query.PostgresLegacyWarrantyMatch),
+	createSerials(query.PostgresCreateSerialEventsTable),
+	trackSnapshotMovements,
+}
+
+// createInventoryTables uses IF NOT EXISTS so databases created before migrations were introduced get adopted.
+func createInventoryTables(createWarehousesTable string) migration.Migration {
+	return migration.Migration{
+		Version: 1,
+		Name:    "create_inventory_tables",
+		Up: []string{
+			createWarehousesTable,
+			query.CreateBrandsTable,
+			query.CreateProductsTable,
+			query.CreateWarehouseProductsTable,
+			query.CreateBookProductsTable,
+			query.CreateConsumableProductsTable,
+			query.CreateElectronicsProductsTable,
+		},
+		Down: []string{
+			query.DropElectronicsProductsTable,
+			query.DropConsumableProductsTable,
+			query.DropBookProductsTable,
+			query.DropWarehouseProductsTable,
+			query.DropProductsTable,
+			query.DropBrandsTable,
+			query.DropWarehousesTable,
+		},
+	}
+}
+
+var addWarehouseMinBrandQuality = migration.Migration{
+	Version: 2,
+	Name:    "add_warehouse_min_brand_quality",
+	Up:      []string{query.AddWarehousesMinBrandQualityColumn},
+	Down:    []string{query.DropWarehousesMinBrandQualityColumn},
+}
+
+func createStockMovements(createStockMovementsTable string) migration.Migration {
+	return migration.Migration{
+		Version: 3,
+		Name:    "create_stock_movements",
+		Up: []string{
+			createStockMovementsTable,
+			query.CreateStockMovementsSkuIndex,
+			query.CreateStockMovementsWarehouseIndex,
+		},
+		Down: []string{query.DropStockMovementsTable},
+	}
+}
+
+// createStockSnapshots also backfills the ledger, so stock inserted before it existed can be rebuilt from movements.
+func createStockSnapshots(createStockSnapshotsTable string, currentTimestamp string) migration.Migration {
+	return migration.Migration{
+		Version: 4,
+		Name:    "create_stock_snapshots",
+		Up: []string{
+			createStockSnapshotsTable,
+			query.CreateStockSnapshotQuantitiesTable,
+			fmt.Sprintf(query.InsertOpeningBalances, currentTimestamp),
+		},
+		Down: []string{
+			query.DeleteOpeningBalances,
+			query.DropStockSnapshotQuantitiesTable,
+			query.DropStockSnapshotsTable,
+		},
+	}
+}
+
+// emptied rows are dropped on the way, removals delete them from now on
+var sqliteCheckWarehouseProductQuantities = migration.Migration{
+	Version: 5,
+	Name:    "check_warehouse_product_quantities",
+	Up: []string{
+		query.CreateCheckedWarehouseProductsTable,
+		query.CopyNonEmptyWarehouseProducts,
+		query.DropWarehouseProductsTable,
+		query.RenameRebuiltWarehouseProductsTable,
+	},
+	Down: []string{
+		query.CreateUncheckedWarehouseProductsTable,
+		query.CopyNonEmptyWarehouseProducts,
+		query.DropWarehouseProductsTable,
+		query.RenameRebuiltWarehouseProductsTable,
+	},
+}
+
+var postgresCheckWarehouseProductQuantities = migration.Migration{
+	Version: 5,
+	Name:    "check_warehouse_product_quantities",
+	Up:      []string{query.DeleteEmptyWarehouseProducts, query.AddWarehouseProductsQuantityCheck},
+	Down:    []string{query.DropWarehouseProductsQuantityCheck},
+}
+
+var createLots = migration.Migration{
+	Version: 6,
+	Name:    "create_lots",
+	Up:      []string{query.CreateLotsTable, query.CreateWarehouseLotsTable},
+	Down:    []string{query.DropWarehouseLotsTable, query.DropLotsTable},
+}
+
+var createQuarantinedStock = migration.Migration{
+	Version: 7,
+	Name:    "create_quarantined_stock",
+	Up:      []string{query.CreateQuarantinedStockTable},
+	Down:    []string{query.DropQuarantinedStockTable},
+}
+
+// typeProductAttributes stores expiration dates as sortable RFC 3339 dates and warranties as months and days.
+// Warranties are kept when they were a duration of one unit such as P2Y, other free-form warranties become 0.
+// Rolling back keeps the rewritten expiration dates, the original spelling is not stored anywhere.
+func typeProductAttributes(legacyWarrantyMatch string) migration.Migration {
+	up := []string{
+		query.AddElectronicsWarrantyMonthsColumn,
+		query.AddElectronicsWarrantyDaysColumn,
+	}
+	units := []struct {
+		column     string
+		factor     int
+		designator string
+	}{
+		{"warranty_months", 12, "Y"},
+		{"warranty_months", 1, "M"},
+		{"warranty_days", 7, "W"},
+		{"warranty_days", 1, "D"},
+	}
+	for _, unit := range units {
+		up = append(up, fmt.Sprintf(query.ConvertLegacyWarranty, unit.column, unit.factor, fmt.Sprintf(legacyWarrantyMatch, unit.designator)))
+	}
+	up = append(up, query.DropElectronicsWarrantyColumn)
+	return migration.Migration{
+		Version: 8,
+		Name:    "type_product_attributes",
+		Up:      up,
+		UpFunc:  normalizeExpirationDates,
+		Down: []string{
+			query.AddElectronicsWarrantyColumn,
+			query.RestoreLegacyWarranties,
+			query.DropElectronicsWarrantyMonthsColumn,
+			query.DropElectronicsWarrantyDaysColumn,
+		},
+	}
+}
+
+// legacyDateLayouts are the year first spellings of expiration dates written before they were typed.
+var legacyDateLayouts = []string{"2006-1-2", "2006.1.2", "2006/1/2"}
+
+// normalizeExpirationDates fails listing the SKUs whose expiration date is in no known layout, such as the
+// ambiguous 12/11/2024, they have to be corrected by hand before migrating again.
+func normalizeExpirationDates(tx *sql.Tx, bind func(query string) string) error {
+	rows, err := tx.Query(query.SelectConsumableExpirationDates)
+	if err != nil {
+		return err
+	}
+	normalized := map[string]string{}
+	var unknown []string
+	for rows.Next() {
+		var sku, value string
+		if err := rows.Scan(&sku, &value); err != nil {
+			rows.Close()
+			return err
+		}
+		date, err := parseLegacyDate(strings.TrimSpace(value))
+		if err != nil {
+			unknown = append(unknown, sku)
+		} else if date != value {
+			normalized[sku] = date
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	if len(unknown) > 0 {
+		return fmt.Errorf("expiration dates of %s are not in a known format", strings.Join(unknown, ", "))
+	}
+	for sku, date := range normalized {
+		if _, err := tx.Exec(bind(query.UpdateConsumableExpirationDate), date, sku); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func parseLegacyDate(value string) (string, error) {
+	for _, layout := range legacyDateLayouts {
+		if date, err := time.Parse(layout, value); err == nil {
+			return date.Format(dateLayout), nil
+		}
+	}
+	return "", fmt.Errorf("unknown date %q", value)
+}
+
+func createSerials(createSerialEventsTable string) migration.Migration {
+	return migration.Migration{
+		Version: 9,
+		Name:    "create_serials",
+		Up: []string{
+			query.CreateSerialsTable,
+			query.CreateSerialsWarehouseIndex,
+			createSerialEventsTable,
+			query.CreateSerialEventsSerialIndex,
+		},
+		Down: []string{query.DropSerialEventsTable, query.DropSerialsTable},
+	}
+}
+
+// trackSnapshotMovements drops the existing snapshots, they may have missed movements committed after they were
+// taken and are rebuilt from the ledger by the next snapshots anyway.
+var trackSnapshotMovements = migration.Migration{
+	Version: 10,
+	Name:    "track_snapshot_movements",
+	Up: []string{
+		query.DeleteStockSnapshotQuantities,
+		query.DeleteStockSnapshots,
+		query.AddStockSnapshotsLastMovementIDColumn,
+	},
+	Down: []string{query.DropStockSnapshotsLastMovementIDColumn},
+}
+
+// NewMigrator migrates the store schema together with the tables of the registered product types, each type
+// numbers its migrations in a scope named after it.
+func NewMigrator(db *sql.DB, dialect *Dialect) (*migration.Migrator, error) {
+	migrations := slices.Clone(dialect.migrations)
+	for _, productType := range producttype.All() {
+		for _, m := range productType.Migrations {
+			migrations = append(migrations, migration.Migration{
+				Scope:   strings.ToLower(string(productType.Name)),
+				Version: m.Version,
+				Name:    m.Name,
+				Up:      m.Up,
+				Down:    m.Down,
+			})
+		}
+	}
+	return migration.NewMigrator(db, migrations, dialect.Rebind)
+}
@@ -0,0 +1,179 @@
+package sql
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/kijevigombooc/inventory-manager/internal/inventory/store"
+	"github.com/kijevigombooc/inventory-manager/internal/inventory/store/domain"
+	"github.com/kijevigombooc/inventory-manager/internal/inventory/store/sql/query"
+)
+
+// timestamps are stored as fixed width UTC text, so they sort and compare the same way in both databases
+const timestampLayout = "2006-01-02T15:04:05.000000000Z07:00"
+
+func formatTimestamp(t time.Time) string {
+	return t.UTC().Format(timestampLayout)
+}
+
+func parseTimestamp(value string) (time.Time, error) {
+	return time.Parse(timestampLayout, value)
+}
+
+func (t *SqlTransaction) InsertMovement(movement domain.Movement) error {
+	_, err := t.exec(
+		query.InsertIntoStockMovements,
+		formatTimestamp(movement.Timestamp),
+		movement.WarehouseName,
+		movement.Sku,
+		movement.Delta,
+		movement.Reason,
+		movement.CorrelationID,
+		movement.Actor,
+	)
+	return err
+}
+
+func (t *SqlTransaction) GetMovements(filter domain.MovementFilter) ([]domain.Movement, error) {
+	var conditions []string
+	var args []any
+	if filter.Sku != "" {
+		conditions = append(conditions, "sku = ?")
+		args = append(args, filter.Sku)
+	}
+	if filter.WarehouseName != "" {
+		conditions = append(conditions, "warehouse_name = ?")
+		args = append(args, filter.WarehouseName)
+	}
+	if !filter.From.IsZero() {
+		conditions = append(conditions, "occurred_at >= ?")
+		args = append(args, formatTimestamp(filter.From))
+	}
+	if !filter.To.IsZero() {
+		conditions = append(conditions, "occurred_at < ?")
+		args = append(args, formatTimestamp(filter.To))
+	}
+	movementsQuery := query.SelectStockMovements
+	if len(conditions) > 0 {
+		movementsQuery += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	rows, err := t.query(movementsQuery+query.StockMovementsOrder, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var result []domain.Movement
+	for rows.Next() {
+		var movement domain.Movement
+		var timestamp string
+		if err := rows.Scan(
+			&movement.ID,
+			&timestamp,
+			&movement.WarehouseName,
+			&movement.Sku,
+			&movement.Delta,
+			&movement.Reason,
+			&movement.CorrelationID,
+			&movement.Actor,
+		); err != nil {
+			return nil, err
+		}
+		if movement.Timestamp, err = parseTimestamp(timestamp); err != nil {
+			return nil, err
+		}
+		result = append(result, movement)
+	}
+	return result, rows.Err()
+}
+
+// TakeSnapshot stores the stock as of takenAt, movements at exactly takenAt are part of the snapshot. It remembers
+// the last movement it saw, movements committed later are added to it even when they are stamped before takenAt.
+func (t *SqlTransaction) TakeSnapshot(takenAt time.Time) error {
+	if t.dialect.lockMovements != "" {
+		if _, err := t.exec(t.dialect.lockMovements); err != nil {
+			return err
+		}
+	}
+	var id int64
+	err := t.queryRow(query.SelectSnapshotByTime, formatTimestamp(takenAt)).Scan(&id)
+	if err == nil {
+		return fmt.Errorf("%w: snapshot at %s", store.ErrAlreadyExists, formatTimestamp(takenAt))
+	}
+	if err != sql.ErrNoRows {
+		return err
+	}
+	stock, err := t.GetStockAsOf(takenAt, "", "")
+	if err != nil {
+		return err
+	}
+	var lastMovementID int64
+	if err := t.queryRow(query.SelectLastMovementID).Scan(&lastMovementID); err != nil {
+		return err
+	}
+	if err := t.queryRow(query.InsertIntoStockSnapshots, formatTimestamp(takenAt), lastMovementID).Scan(&id); err != nil {
+		return err
+	}
+	for _, warehouseProduct := range stock {
+		if _, err := t.exec(
+			query.InsertIntoStockSnapshotQuantities,
+			id,
+			warehouseProduct.WarehouseName,
+			warehouseProduct.Sku,
+			warehouseProduct.Quantity,
+		); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GetLatestSnapshotTime returns the zero time when no snapshot was taken yet.
+func (t *SqlTransaction) GetLatestSnapshotTime() (time.Time, error) {
+	var takenAt string
+	err := t.queryRow(query.SelectLatestSnapshotTime).Scan(&takenAt)
+	if err == sql.ErrNoRows {
+		return time.Time{}, nil
+	}
+	if err != nil {
+		return time.Time{}, err
+	}
+	return parseTimestamp(takenAt)
+}
+
+func (t *SqlTransaction) GetStockAsOf(asOf time.Time, warehouseName string, sku string) ([]domain.WarehouseProduct, error) {
+	var snapshotID, lastMovementID int64
+	var snapshotTakenAt string
+	err := t.queryRow(query.SelectLatestSnapshotAsOf, formatTimestamp(asOf)).Scan(&snapshotID, &snapshotTakenAt, &lastMovementID)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, err
+	}
+	var filter string
+	var filterArgs []any
+	if warehouseName != "" {
+		filter += " AND warehouse_name = ?"
+		filterArgs = append(filterArgs, warehouseName)
+	}
+	if sku != "" {
+		filter += " AND sku = ?"
+		filterArgs = append(filterArgs, sku)
+	}
+	args := append([]any{snapshotID}, filterArgs...)
+	args = append(append(args, snapshotTakenAt, formatTimestamp(asOf)), filterArgs...)
+	args = append(append(args, lastMovementID, snapshotTakenAt, formatTimestamp(asOf)), filterArgs...)
+	rows, err := t.query(fmt.Sprintf(query.SelectStockAsOf, filter), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var result []domain.WarehouseProduct
+	for rows.Next() {
+		var warehouseProduct domain.WarehouseProduct
+		if err := rows.Scan(&warehouseProduct.WarehouseName, &warehouseProduct.Sku, &warehouseProduct.Quantity); err != nil {
+			return nil, err
+		}
+		result = append(result, warehouseProduct)
+	}
+	return result, rows.Err()
+}
@@ -0,0 +1,88 @@
+package sql
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/kijevigombooc/inventory-manager/internal/inventory/store"
+	"github.com/kijevigombooc/inventory-manager/internal/inventory/store/storetest"
+)
+
+func TestPostgresConformance(t *testing.T) {
+	serverDsn := startPostgres(t)
+	admin, err := OpenPostgresDB(serverDsn)
+	if err != nil {
+		t.Fatalf("Error opening database: %v", err)
+	}
+	defer admin.Close()
+	databases := 0
+	storetest.RunTransactionSuite(t, func(t *testing.T) store.Store {
+		databases++
+		name := fmt.Sprintf("inventory_test_%d_%d", os.Getpid(), databases)
+		if _, err := admin.Exec("CREATE DATABASE " + name); err != nil {
+			t.Fatalf("Error creating database: %v", err)
+		}
+		dsn, err := url.Parse(serverDsn)
+		if err != nil {
+			t.Fatalf("Error parsing dsn: %v", err)
+		}
+		dsn.Path = "/" + name
+		db, err := OpenPostgresDB(dsn.String())
+		if err != nil {
+			t.Fatalf("Error opening database: %v", err)
+		}
+		t.Cleanup(func() {
+			db.Close()
+			admin.Exec("DROP DATABASE " + name)
+		})
+		return NewInventoryStore(db, PostgresDialect)
+	})
+}
+
+// startPostgres returns INVENTORY_TEST_POSTGRES_DSN when set, otherwise it runs a throwaway
+// server from the initdb and pg_ctl binaries on PATH for the duration of the test.
+func startPostgres(t *testing.T) string {
+	t.Helper()
+	if dsn := os.Getenv("INVENTORY_TEST_POSTGRES_DSN"); dsn != "" {
+		return dsn
+	}
+	initdb, err := exec.LookPath("initdb")
+	if err != nil {
+		t.Skip("initdb not found on PATH, set INVENTORY_TEST_POSTGRES_DSN to test against an existing server")
+	}
+	pgCtl, err := exec.LookPath("pg_ctl")
+	if err != nil {
+		t.Skip("pg_ctl not found on PATH, set INVENTORY_TEST_POSTGRES_DSN to test against an existing server")
+	}
+	if os.Geteuid() == 0 {
+		t.Skip("postgres refuses to run as root, set INVENTORY_TEST_POSTGRES_DSN to test against an existing server")
+	}
+	dir := t.TempDir()
+	dataDir := filepath.Join(dir, "data")
+	if output, err := exec.Command(initdb, "-D", dataDir, "-U", "postgres", "-A", "trust", "--no-sync").CombinedOutput(); err != nil {
+		t.Fatalf("Error running initdb: %v\n%s", err, output)
+	}
+	port := freePort(t)
+	options := fmt.Sprintf("-p %d -k %s -c listen_addresses='' -c fsync=off", port, dir)
+	if output, err := exec.Command(pgCtl, "-D", dataDir, "-o", options, "-l", filepath.Join(dir, "postgres.log"), "-w", "start").CombinedOutput(); err != nil {
+		t.Fatalf("Error starting postgres: %v\n%s", err, output)
+	}
+	t.Cleanup(func() {
+		exec.Command(pgCtl, "-D", dataDir, "-m", "immediate", "-w", "stop").Run()
+	})
+	return fmt.Sprintf("postgres://postgres@/postgres?host=%s&port=%d&sslmode=disable", dir, port)
+}
+
+func freePort(t *testing.T) int {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Error finding a free port: %v", err)
+	}
+	defer listener.Close()
+	return listener.Addr().(*net.TCPAddr).Port
+}
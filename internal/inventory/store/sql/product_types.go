@@ -0,0 +1,72 @@
+package sql
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/kijevigombooc/inventory-manager/internal/inventory/producttype"
+	"github.com/kijevigombooc/inventory-manager/internal/inventory/store/domain"
+	"github.com/kijevigombooc/inventory-manager/internal/inventory/store/sql/query"
+)
+
+func (t *SqlTransaction) insertProductAttributes(product domain.IProduct) error {
+	productType, err := producttype.Lookup(product.GetType())
+	if err != nil || len(productType.Columns) == 0 {
+		return err
+	}
+	placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(productType.Columns)), ", ")
+	statement := fmt.Sprintf(query.InsertOrIgnoreIntoProductAttributes, productType.Table, strings.Join(productType.Columns, ", "), placeholders)
+	_, err = t.exec(statement, append([]any{product.GetBaseProduct().SKU}, productType.Values(product)...)...)
+	return err
+}
+
+func (t *SqlTransaction) updateProductAttributes(product domain.IProduct) error {
+	productType, err := producttype.Lookup(product.GetType())
+	if err != nil || len(productType.Columns) == 0 {
+		return err
+	}
+	var assignments []string
+	for _, name := range productType.Columns {
+		assignments = append(assignments, name+" = ?")
+	}
+	statement := fmt.Sprintf(query.UpdateProductAttributes, productType.Table, strings.Join(assignments, ", "))
+	_, err = t.exec(statement, append(productType.Values(product), product.GetBaseProduct().SKU)...)
+	return err
+}
+
+func (t *SqlTransaction) loadProduct(baseProduct domain.Product) (domain.IProduct, error) {
+	if err := t.queryRow(query.SelectBrandQuality, baseProduct.Brand.Name).Scan(&baseProduct.Brand.Quality); err != nil {
+		return nil, err
+	}
+	productType, err := producttype.Lookup(baseProduct.Type)
+	if err != nil {
+		return nil, err
+	}
+	product := productType.NewEntity()
+	product.SetBaseProduct(baseProduct)
+	if len(productType.Columns) == 0 {
+		return product, nil
+	}
+	row := t.queryRow(fmt.Sprintf(query.SelectProductAttributes, strings.Join(productType.Columns, ", "), productType.Table), baseProduct.SKU)
+	if err := productType.Scan(product, row.Scan); err != nil {
+		return nil, err
+	}
+	return product, nil
+}
+
+// DeleteOrphanedProductAttributes catches type rows that ON DELETE CASCADE missed, for example
+// rows written while foreign keys were off or left behind by a product that changed its type.
+func (t *SqlTransaction) DeleteOrphanedProductAttributes() (int, error) {
+	deleted := 0
+	for _, productType := range producttype.All() {
+		if len(productType.Columns) == 0 {
+			continue
+		}
+		count, err := t.execCount(fmt.Sprintf(query.DeleteOrphanedProductAttributes, productType.Table), productType.Name)
+		if err != nil {
+			return 0, err
+		}
+		deleted += count
+	}
+	return deleted, nil
+}
@@ -0,0 +1,50 @@
+package sql
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/kijevigombooc/inventory-manager/internal/inventory/store/domain"
+	"github.com/kijevigombooc/inventory-manager/internal/inventory/store/sql/query"
+)
+
+func (t *SqlTransaction) GetQuarantinedStock(warehouseName string, sku string) ([]domain.QuarantinedStock, error) {
+	rows, err := t.query(query.SelectQuarantinedStock, warehouseName, warehouseName, sku, sku)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var result []domain.QuarantinedStock
+	for rows.Next() {
+		var stock domain.QuarantinedStock
+		var expirationDate string
+		if err := rows.Scan(&stock.WarehouseName, &stock.Sku, &stock.LotNumber, &expirationDate, &stock.Quantity); err != nil {
+			return nil, err
+		}
+		if stock.ExpirationDate, err = time.Parse(dateLayout, expirationDate); err != nil {
+			return nil, err
+		}
+		result = append(result, stock)
+	}
+	return result, rows.Err()
+}
+
+// QuarantineStock adds to the quarantined quantity of the lot, the warehouse quantity is left unchanged.
+func (t *SqlTransaction) QuarantineStock(stock domain.QuarantinedStock) error {
+	quantity := 0
+	err := t.queryRow(query.SelectQuarantinedQuantity, stock.WarehouseName, stock.Sku, stock.LotNumber).Scan(&quantity)
+	if err == sql.ErrNoRows {
+		_, err = t.exec(query.InsertIntoQuarantinedStock, stock.WarehouseName, stock.Sku, stock.LotNumber, stock.ExpirationDate.Format(dateLayout), stock.Quantity)
+		return err
+	}
+	if err != nil {
+		return err
+	}
+	_, err = t.exec(query.UpdateQuarantinedStock, stock.ExpirationDate.Format(dateLayout), quantity+stock.Quantity, stock.WarehouseName, stock.Sku, stock.LotNumber)
+	return err
+}
+
+func (t *SqlTransaction) DeleteQuarantinedStock(warehouseName string) error {
+	_, err := t.exec(query.DeleteQuarantinedStockByWarehouse, warehouseName)
+	return err
+}
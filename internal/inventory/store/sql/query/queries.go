@@ -7,6 +7,15 @@ const CreateWarehousesTable = `
 		capacity INTEGER NOT NULL
 	)
 `
+
+// names compare bytewise like in SQLite so warehouse ordering does not depend on the server locale
+const PostgresCreateWarehousesTable = `
+	CREATE TABLE IF NOT EXISTS warehouses (
+		name TEXT COLLATE "C" PRIMARY KEY,
+		address TEXT NOT NULL,
+		capacity INTEGER NOT NULL
+	)
+`
 const CreateProductsTable = `
 	CREATE TABLE IF NOT EXISTS products (
 		sku TEXT PRIMARY KEY,
@@ -54,16 +63,325 @@ const CreateElectronicsProductsTable = `
 		FOREIGN KEY (sku) REFERENCES products (sku) ON DELETE CASCADE
 	)
 `
-const SelectWarehouses = "SELECT name, address, capacity FROM warehouses"
-const InsertIntoWarehouses = "INSERT INTO warehouses (name, address, capacity) VALUES (?, ?, ?)"
+
+// movements have no foreign keys so that the history outlives deleted warehouses and products
+const CreateStockMovementsTable = `
+	CREATE TABLE IF NOT EXISTS stock_movements (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		occurred_at TEXT NOT NULL,
+		warehouse_name TEXT NOT NULL,
+		sku TEXT NOT NULL,
+		delta INTEGER NOT NULL,
+		reason TEXT NOT NULL,
+		correlation_id TEXT NOT NULL,
+		actor TEXT NOT NULL
+	)
+`
+const PostgresCreateStockMovementsTable = `
+	CREATE TABLE IF NOT EXISTS stock_movements (
+		id BIGINT GENERATED ALWAYS AS IDENTITY PRIMARY KEY,
+		occurred_at TEXT NOT NULL,
+		warehouse_name TEXT NOT NULL,
+		sku TEXT NOT NULL,
+		delta INTEGER NOT NULL,
+		reason TEXT NOT NULL,
+		correlation_id TEXT NOT NULL,
+		actor TEXT NOT NULL
+	)
+`
+const CreateStockMovementsSkuIndex = "CREATE INDEX IF NOT EXISTS stock_movements_sku ON stock_movements (sku, occurred_at)"
+const CreateStockMovementsWarehouseIndex = "CREATE INDEX IF NOT EXISTS stock_movements_warehouse ON stock_movements (warehouse_name, occurred_at)"
+const DropStockMovementsTable = "DROP TABLE IF EXISTS stock_movements"
+const CreateStockSnapshotsTable = `
+	CREATE TABLE IF NOT EXISTS stock_snapshots (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		taken_at TEXT NOT NULL UNIQUE
+	)
+`
+const PostgresCreateStockSnapshotsTable = `
+	CREATE TABLE IF NOT EXISTS stock_snapshots (
+		id BIGINT GENERATED ALWAYS AS IDENTITY PRIMARY KEY,
+		taken_at TEXT NOT NULL UNIQUE
+	)
+`
+const CreateStockSnapshotQuantitiesTable = `
+	CREATE TABLE IF NOT EXISTS stock_snapshot_quantities (
+		snapshot_id BIGINT NOT NULL,
+		warehouse_name TEXT NOT NULL,
+		sku TEXT NOT NULL,
+		quantity INTEGER NOT NULL,
+		FOREIGN KEY (snapshot_id) REFERENCES stock_snapshots (id) ON DELETE CASCADE,
+		PRIMARY KEY (snapshot_id, warehouse_name, sku)
+	)
+`
+const DropStockSnapshotQuantitiesTable = "DROP TABLE IF EXISTS stock_snapshot_quantities"
+const DeleteStockSnapshotQuantities = "DELETE FROM stock_snapshot_quantities"
+const DeleteStockSnapshots = "DELETE FROM stock_snapshots"
+const AddStockSnapshotsLastMovementIDColumn = "ALTER TABLE stock_snapshots ADD COLUMN last_movement_id BIGINT NOT NULL DEFAULT 0"
+const DropStockSnapshotsLastMovementIDColumn = "ALTER TABLE stock_snapshots DROP COLUMN last_movement_id"
+const LockStockMovements = "LOCK TABLE stock_movements IN SHARE MODE"
+
+// SQLite cannot add a constraint to an existing table, so warehouse_products is rebuilt under a temporary name.
+const CreateCheckedWarehouseProductsTable = `
+	CREATE TABLE warehouse_products_rebuilt (
+		warehouse_name TEXT NOT NULL,
+		sku TEXT NOT NULL,
+		quantity INTEGER NOT NULL CHECK (quantity >= 0),
+		FOREIGN KEY (warehouse_name) REFERENCES warehouses (name),
+		FOREIGN KEY (sku) REFERENCES products (sku),
+		PRIMARY KEY (warehouse_name, sku)
+	)
+`
+const CreateUncheckedWarehouseProductsTable = `
+	CREATE TABLE warehouse_products_rebuilt (
+		warehouse_name TEXT NOT NULL,
+		sku TEXT NOT NULL,
+		quantity INTEGER NOT NULL,
+		FOREIGN KEY (warehouse_name) REFERENCES warehouses (name),
+		FOREIGN KEY (sku) REFERENCES products (sku),
+		PRIMARY KEY (warehouse_name, sku)
+	)
+`
+const CopyNonEmptyWarehouseProducts = `
+	INSERT INTO warehouse_products_rebuilt (warehouse_name, sku, quantity)
+	SELECT warehouse_name, sku, quantity FROM warehouse_products WHERE quantity > 0
+`
+const RenameRebuiltWarehouseProductsTable = "ALTER TABLE warehouse_products_rebuilt RENAME TO warehouse_products"
+const DeleteEmptyWarehouseProducts = "DELETE FROM warehouse_products WHERE quantity <= 0"
+const AddWarehouseProductsQuantityCheck = "ALTER TABLE warehouse_products ADD CONSTRAINT warehouse_products_quantity_check CHECK (quantity >= 0)"
+const CreateLotsTable = `
+	CREATE TABLE IF NOT EXISTS lots (
+		sku TEXT NOT NULL,
+		lot_number TEXT NOT NULL,
+		expiration_date TEXT NOT NULL,
+		received_at TEXT NOT NULL,
+		FOREIGN KEY (sku) REFERENCES products (sku),
+		PRIMARY KEY (sku, lot_number)
+	)
+`
+const CreateWarehouseLotsTable = `
+	CREATE TABLE IF NOT EXISTS warehouse_lots (
+		warehouse_name TEXT NOT NULL,
+		sku TEXT NOT NULL,
+		lot_number TEXT NOT NULL,
+		quantity INTEGER NOT NULL CHECK (quantity > 0),
+		FOREIGN KEY (warehouse_name) REFERENCES warehouses (name),
+		FOREIGN KEY (sku, lot_number) REFERENCES lots (sku, lot_number),
+		PRIMARY KEY (warehouse_name, sku, lot_number)
+	)
+`
+const CreateQuarantinedStockTable = `
+	CREATE TABLE IF NOT EXISTS quarantined_stock (
+		warehouse_name TEXT NOT NULL,
+		sku TEXT NOT NULL,
+		lot_number TEXT NOT NULL,
+		expiration_date TEXT NOT NULL,
+		quantity INTEGER NOT NULL CHECK (quantity > 0),
+		FOREIGN KEY (warehouse_name) REFERENCES warehouses (name),
+		FOREIGN KEY (sku) REFERENCES products (sku),
+		PRIMARY KEY (warehouse_name, sku, lot_number)
+	)
+`
+const DropQuarantinedStockTable = "DROP TABLE IF EXISTS quarantined_stock"
+
+// serials have no foreign keys so that the history of a unit outlives deleted warehouses and products
+const CreateSerialsTable = `
+	CREATE TABLE IF NOT EXISTS serials (
+		serial TEXT PRIMARY KEY,
+		sku TEXT NOT NULL,
+		warehouse_name TEXT NOT NULL,
+		received_at TEXT NOT NULL
+	)
+`
+const CreateSerialsWarehouseIndex = "CREATE INDEX IF NOT EXISTS serials_warehouse ON serials (warehouse_name, sku)"
+const CreateSerialEventsTable = `
+	CREATE TABLE IF NOT EXISTS serial_events (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		serial TEXT NOT NULL,
+		occurred_at TEXT NOT NULL,
+		warehouse_name TEXT NOT NULL,
+		reason TEXT NOT NULL,
+		correlation_id TEXT NOT NULL,
+		actor TEXT NOT NULL
+	)
+`
+const PostgresCreateSerialEventsTable = `
+	CREATE TABLE IF NOT EXISTS serial_events (
+		id BIGINT GENERATED ALWAYS AS IDENTITY PRIMARY KEY,
+		serial TEXT NOT NULL,
+		occurred_at TEXT NOT NULL,
+		warehouse_name TEXT NOT NULL,
+		reason TEXT NOT NULL,
+		correlation_id TEXT NOT NULL,
+		actor TEXT NOT NULL
+	)
+`
+const CreateSerialEventsSerialIndex = "CREATE INDEX IF NOT EXISTS serial_events_serial ON serial_events (serial, occurred_at)"
+const DropSerialEventsTable = "DROP TABLE IF EXISTS serial_events"
+const DropSerialsTable = "DROP TABLE IF EXISTS serials"
+const SelectConsumableExpirationDates = "SELECT sku, expiration_date FROM consumable_products ORDER BY sku"
+const UpdateConsumableExpirationDate = "UPDATE consumable_products SET expiration_date = ? WHERE sku = ?"
+const AddElectronicsWarrantyMonthsColumn = "ALTER TABLE electronics_products ADD COLUMN warranty_months INTEGER NOT NULL DEFAULT 0"
+const AddElectronicsWarrantyDaysColumn = "ALTER TABLE electronics_products ADD COLUMN warranty_days INTEGER NOT NULL DEFAULT 0"
+
+// ConvertLegacyWarranty takes the column, the factor and the dialect specific condition matching a single unit duration.
+const ConvertLegacyWarranty = "UPDATE electronics_products SET %s = CAST(SUBSTR(warranty, 2, LENGTH(warranty) - 2) AS INTEGER) * %d WHERE %s"
+const SqliteLegacyWarrantyMatch = "warranty GLOB 'P[0-9]*%s' AND SUBSTR(warranty, 2, LENGTH(warranty) - 2) NOT GLOB '*[^0-9]*'"
+const PostgresLegacyWarrantyMatch = "warranty ~ '^P[0-9]+%s$'"
+const DropElectronicsWarrantyColumn = "ALTER TABLE electronics_products DROP COLUMN warranty"
+const AddElectronicsWarrantyColumn = "ALTER TABLE electronics_products ADD COLUMN warranty TEXT NOT NULL DEFAULT ''"
+const RestoreLegacyWarranties = "UPDATE electronics_products SET warranty = 'P' || warranty_months || 'M' || warranty_days || 'D'"
+const DropElectronicsWarrantyMonthsColumn = "ALTER TABLE electronics_products DROP COLUMN warranty_months"
+const DropElectronicsWarrantyDaysColumn = "ALTER TABLE electronics_products DROP COLUMN warranty_days"
+const DropWarehouseLotsTable = "DROP TABLE IF EXISTS warehouse_lots"
+const DropLotsTable = "DROP TABLE IF EXISTS lots"
+const DropWarehouseProductsQuantityCheck = "ALTER TABLE warehouse_products DROP CONSTRAINT warehouse_products_quantity_check"
+const DropStockSnapshotsTable = "DROP TABLE IF EXISTS stock_snapshots"
+
+// InsertOpeningBalances records the stock that predates the movement ledger, the placeholder is the
+// dialect specific expression for the current time in the stock_movements timestamp format.
+const InsertOpeningBalances = `
+	INSERT INTO stock_movements (occurred_at, warehouse_name, sku, delta, reason, correlation_id, actor)
+	SELECT %s, wp.warehouse_name, wp.sku, wp.quantity - COALESCE((
+		SELECT SUM(m.delta) FROM stock_movements m WHERE m.warehouse_name = wp.warehouse_name AND m.sku = wp.sku
+	), 0), 'opening_balance', 'migration', ''
+	FROM warehouse_products wp
+	WHERE wp.quantity <> COALESCE((
+		SELECT SUM(m.delta) FROM stock_movements m WHERE m.warehouse_name = wp.warehouse_name AND m.sku = wp.sku
+	), 0)
+`
+const SqliteCurrentTimestamp = "strftime('%Y-%m-%dT%H:%M:%f', 'now') || '000000Z'"
+const PostgresCurrentTimestamp = `to_char(now() AT TIME ZONE 'UTC', 'YYYY-MM-DD"T"HH24:MI:SS.US') || '000Z'`
+const DeleteOpeningBalances = "DELETE FROM stock_movements WHERE reason = 'opening_balance'"
+const AddWarehousesMinBrandQualityColumn = "ALTER TABLE warehouses ADD COLUMN min_brand_quality INTEGER NOT NULL DEFAULT 0"
+const DropWarehousesMinBrandQualityColumn = "ALTER TABLE warehouses DROP COLUMN min_brand_quality"
+const DropWarehousesTable = "DROP TABLE IF EXISTS warehouses"
+const DropProductsTable = "DROP TABLE IF EXISTS products"
+const DropBrandsTable = "DROP TABLE IF EXISTS brands"
+const DropWarehouseProductsTable = "DROP TABLE IF EXISTS warehouse_products"
+const DropBookProductsTable = "DROP TABLE IF EXISTS book_products"
+const DropConsumableProductsTable = "DROP TABLE IF EXISTS consumable_products"
+const DropElectronicsProductsTable = "DROP TABLE IF EXISTS electronics_products"
+
+const SelectWarehouses = "SELECT name, address, capacity, min_brand_quality FROM warehouses"
+const SelectWarehouse = "SELECT name, address, capacity, min_brand_quality FROM warehouses WHERE name = ?"
+const InsertIntoWarehouses = "INSERT INTO warehouses (name, address, capacity, min_brand_quality) VALUES (?, ?, ?, ?)"
+const UpdateWarehouse = "UPDATE warehouses SET address = ?, capacity = ?, min_brand_quality = ? WHERE name = ?"
+const DeleteWarehouse = "DELETE FROM warehouses WHERE name = ?"
+const UpdateWarehouseProductsWarehouseName = "UPDATE warehouse_products SET warehouse_name = ? WHERE warehouse_name = ?"
+const UpdateWarehouseLotsWarehouseName = "UPDATE warehouse_lots SET warehouse_name = ? WHERE warehouse_name = ?"
+const UpdateQuarantinedStockWarehouseName = "UPDATE quarantined_stock SET warehouse_name = ? WHERE warehouse_name = ?"
+const UpdateSerialsWarehouseName = "UPDATE serials SET warehouse_name = ? WHERE warehouse_name = ?"
 const SelectBrandQuality = "SELECT category FROM brands WHERE name = ?"
-const SelectFromBookProducts = "SELECT author FROM book_products WHERE sku = ?"
-const SelectFromConsumableProducts = "SELECT expiration_date FROM consumable_products WHERE sku = ?"
-const SelectFromElectronicsProducts = "SELECT warranty FROM electronics_products WHERE sku = ?"
+const SelectBrands = "SELECT name, category FROM brands ORDER BY name"
+const SelectBrand = "SELECT name, category FROM brands WHERE name = ?"
+const InsertIntoBrands = "INSERT INTO brands (name, category) VALUES (?, ?)"
+const UpdateBrand = "UPDATE brands SET category = ? WHERE name = ?"
+const DeleteBrand = "DELETE FROM brands WHERE name = ?"
+const SelectProductsByBrand = "SELECT sku, name, price, brand, type FROM products WHERE brand = ? ORDER BY sku"
+
+// product attribute statements are completed with the table and columns of a registered product type
+const SelectProductAttributes = "SELECT %s FROM %s WHERE sku = ?"
+const InsertOrIgnoreIntoProductAttributes = "INSERT INTO %s (sku, %s) VALUES (?, %s) ON CONFLICT DO NOTHING"
+const UpdateProductAttributes = "UPDATE %s SET %s WHERE sku = ?"
+const DeleteOrphanedProductAttributes = "DELETE FROM %s WHERE sku NOT IN (SELECT sku FROM products WHERE type = ?)"
+
 const SelectProductTypeBySku = "SELECT type FROM products WHERE sku = ?"
+const SelectProducts = "SELECT sku, name, price, brand, type FROM products ORDER BY sku"
+const SelectProduct = "SELECT sku, name, price, brand, type FROM products WHERE sku = ?"
+const InsertIntoProducts = "INSERT INTO products (sku, name, price, brand, type) VALUES (?, ?, ?, ?, ?)"
+const UpdateProduct = "UPDATE products SET name = ?, price = ?, brand = ? WHERE sku = ?"
+const DeleteProduct = "DELETE FROM products WHERE sku = ?"
+const DeleteEmptyWarehouseProduct = "DELETE FROM warehouse_products WHERE warehouse_name = ? AND sku = ? AND quantity = 0"
+const DeleteLotsWithoutStock = "DELETE FROM lots WHERE sku NOT IN (SELECT sku FROM warehouse_products)"
+const DeleteLotsBySku = "DELETE FROM lots WHERE sku = ?"
+const DeleteProductsWithoutStock = "DELETE FROM products WHERE sku NOT IN (SELECT sku FROM warehouse_products)"
+const DeleteBrandsWithoutProducts = "DELETE FROM brands WHERE name NOT IN (SELECT brand FROM products)"
+const SelectQuantityBySku = "SELECT COALESCE(SUM(quantity), 0) FROM warehouse_products WHERE sku = ?"
+
+const InsertIntoStockMovements = `
+	INSERT INTO stock_movements (occurred_at, warehouse_name, sku, delta, reason, correlation_id, actor)
+	VALUES (?, ?, ?, ?, ?, ?, ?)
+`
+const SelectStockMovements = "SELECT id, occurred_at, warehouse_name, sku, delta, reason, correlation_id, actor FROM stock_movements"
+const StockMovementsOrder = " ORDER BY occurred_at, id"
+
+const SelectLot = "SELECT sku, lot_number, expiration_date, received_at FROM lots WHERE sku = ? AND lot_number = ?"
+const InsertIntoLots = "INSERT INTO lots (sku, lot_number, expiration_date, received_at) VALUES (?, ?, ?, ?)"
+
+// SelectWarehouseLots matches any warehouse or SKU for an empty argument.
+const SelectWarehouseLots = `
+	SELECT wl.warehouse_name, l.sku, l.lot_number, l.expiration_date, l.received_at, wl.quantity
+	FROM warehouse_lots wl
+	JOIN lots l ON l.sku = wl.sku AND l.lot_number = wl.lot_number
+	WHERE (? = '' OR wl.warehouse_name = ?) AND (? = '' OR wl.sku = ?)
+	ORDER BY wl.warehouse_name, l.sku, l.expiration_date, l.lot_number
+`
+const SelectWarehouseLotQuantity = "SELECT quantity FROM warehouse_lots WHERE warehouse_name = ? AND sku = ? AND lot_number = ?"
+const InsertIntoWarehouseLots = "INSERT INTO warehouse_lots (warehouse_name, sku, lot_number, quantity) VALUES (?, ?, ?, ?)"
+const UpdateWarehouseLotQuantity = "UPDATE warehouse_lots SET quantity = ? WHERE warehouse_name = ? AND sku = ? AND lot_number = ?"
+const DeleteWarehouseLot = "DELETE FROM warehouse_lots WHERE warehouse_name = ? AND sku = ? AND lot_number = ?"
+
+// SelectQuarantinedStock matches any warehouse or SKU for an empty argument.
+const SelectQuarantinedStock = `
+	SELECT warehouse_name, sku, lot_number, expiration_date, quantity
+	FROM quarantined_stock
+	WHERE (? = '' OR warehouse_name = ?) AND (? = '' OR sku = ?)
+	ORDER BY warehouse_name, sku, lot_number
+`
+const SelectQuarantinedQuantity = "SELECT quantity FROM quarantined_stock WHERE warehouse_name = ? AND sku = ? AND lot_number = ?"
+const InsertIntoQuarantinedStock = "INSERT INTO quarantined_stock (warehouse_name, sku, lot_number, expiration_date, quantity) VALUES (?, ?, ?, ?, ?)"
+const UpdateQuarantinedStock = "UPDATE quarantined_stock SET expiration_date = ?, quantity = ? WHERE warehouse_name = ? AND sku = ? AND lot_number = ?"
+const DeleteQuarantinedStockByWarehouse = "DELETE FROM quarantined_stock WHERE warehouse_name = ?"
+
+const SelectSerial = "SELECT serial, sku, warehouse_name, received_at FROM serials WHERE serial = ?"
+const SelectWarehouseSerials = `
+	SELECT serial, sku, warehouse_name, received_at
+	FROM serials
+	WHERE warehouse_name = ? AND sku = ?
+	ORDER BY received_at, serial
+`
+const InsertIntoSerials = "INSERT INTO serials (serial, sku, warehouse_name, received_at) VALUES (?, ?, ?, ?)"
+const UpdateSerial = "UPDATE serials SET sku = ?, warehouse_name = ?, received_at = ? WHERE serial = ?"
+const InsertIntoSerialEvents = `
+	INSERT INTO serial_events (serial, occurred_at, warehouse_name, reason, correlation_id, actor)
+	VALUES (?, ?, ?, ?, ?, ?)
+`
+const SelectSerialEvents = `
+	SELECT id, serial, occurred_at, warehouse_name, reason, correlation_id, actor
+	FROM serial_events
+	WHERE serial = ?
+	ORDER BY occurred_at, id
+`
+
+const SelectLatestSnapshotAsOf = `
+	SELECT id, taken_at, last_movement_id FROM stock_snapshots WHERE taken_at <= ? ORDER BY taken_at DESC LIMIT 1
+`
+const SelectLatestSnapshotTime = "SELECT taken_at FROM stock_snapshots ORDER BY taken_at DESC LIMIT 1"
+const SelectSnapshotByTime = "SELECT id FROM stock_snapshots WHERE taken_at = ?"
+const SelectLastMovementID = "SELECT COALESCE(MAX(id), 0) FROM stock_movements"
+const InsertIntoStockSnapshots = "INSERT INTO stock_snapshots (taken_at, last_movement_id) VALUES (?, ?) RETURNING id"
+const InsertIntoStockSnapshotQuantities = "INSERT INTO stock_snapshot_quantities (snapshot_id, warehouse_name, sku, quantity) VALUES (?, ?, ?, ?)"
+
+// SelectStockAsOf adds the movements a snapshot does not hold to its quantities: those after it and those committed
+// after it was taken, an empty snapshot time means no snapshot. The verb takes the warehouse and sku conditions that
+// every branch is filtered by.
+const SelectStockAsOf = `
+	SELECT warehouse_name, sku, SUM(quantity)
+	FROM (
+		SELECT warehouse_name, sku, quantity FROM stock_snapshot_quantities WHERE snapshot_id = ?%[1]s
+		UNION ALL
+		SELECT warehouse_name, sku, delta FROM stock_movements WHERE occurred_at > ? AND occurred_at <= ?%[1]s
+		UNION ALL
+		SELECT warehouse_name, sku, delta FROM stock_movements WHERE id > ? AND occurred_at <= ? AND occurred_at <= ?%[1]s
+	) AS stock
+	GROUP BY warehouse_name, sku
+	HAVING SUM(quantity) <> 0
+	ORDER BY warehouse_name, sku
+`
 
 const SelectWarehousesOrderedFirstWithName = `
-			SELECT name, address, capacity
+			SELECT name, address, capacity, min_brand_quality
 			FROM warehouses
 			ORDER BY CASE WHEN name = ? THEN 0 ELSE 1 END, name
 		`
@@ -71,10 +389,10 @@ const SelectProductsByWarehouse = `
 		SELECT p.sku, p.name, p.price, p.brand, p.type, wp.quantity
 		FROM products p
 		JOIN warehouse_products wp ON p.sku = wp.sku
-		WHERE wp.warehouse_name = ? AND wp.quantity > 0
+		WHERE wp.warehouse_name = ?
 	`
 const SelectUsedCapacitiyByWarehouse = `
-	SELECT IFNULL(SUM(quantity), 0)
+	SELECT COALESCE(SUM(quantity), 0)
 	FROM warehouse_products
 	WHERE warehouse_name = ?
 `
@@ -89,30 +407,20 @@ const SelectWarehouseProductQuantity = `
 		WHERE warehouse_name = ? AND sku = ?
 	`
 const InsertOrIgnoreIntoBrands = `
-	INSERT OR IGNORE INTO brands (name, category)
+	INSERT INTO brands (name, category)
 	VALUES (?, ?)
+	ON CONFLICT DO NOTHING
 `
 const InsertOrIgnoreIntoProducts = `
-	INSERT OR IGNORE INTO products (sku, name, price, brand, type)
+	INSERT INTO products (sku, name, price, brand, type)
 	VALUES (?, ?, ?, ?, ?)
+	ON CONFLICT DO NOTHING
 `
-const InsertOrIgnoreIntoBookProducts = `
-					INSERT OR IGNORE INTO book_products (sku, author)
-					VALUES (?, ?)
-				`
-const InsertOrIgnoreIntoConsumableProducts = `
-					INSERT OR IGNORE INTO consumable_products (sku, expiration_date)
-					VALUES (?, ?)
-				`
-const InsertOrIgnoreIntoElectronicsProducts = `
-					INSERT OR IGNORE INTO electronics_products (sku, warranty)
-					VALUES (?, ?)
-				`
 const InsertOrUpdateIntoWarehouseProducts = `
 				INSERT INTO warehouse_products (warehouse_name, sku, quantity)
 				VALUES (?, ?, ?)
 				ON CONFLICT (warehouse_name, sku)
-				DO UPDATE SET quantity = quantity + ?
+				DO UPDATE SET quantity = warehouse_products.quantity + excluded.quantity
 			`
 
 const UpdateWarehouseProductQuantity = `
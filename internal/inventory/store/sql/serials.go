@@ -0,0 +1,105 @@
+package sql
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/kijevigombooc/inventory-manager/internal/inventory/store"
+	"github.com/kijevigombooc/inventory-manager/internal/inventory/store/domain"
+	"github.com/kijevigombooc/inventory-manager/internal/inventory/store/sql/query"
+)
+
+func (t *SqlTransaction) GetSerial(number string) (domain.Serial, error) {
+	serial, err := scanSerial(t.queryRow(query.SelectSerial, number))
+	if err == sql.ErrNoRows {
+		return domain.Serial{}, fmt.Errorf("%w: serial %s", store.ErrNotFound, number)
+	}
+	return serial, err
+}
+
+func (t *SqlTransaction) GetWarehouseSerials(warehouseName string, sku string) ([]domain.Serial, error) {
+	rows, err := t.query(query.SelectWarehouseSerials, warehouseName, sku)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var result []domain.Serial
+	for rows.Next() {
+		serial, err := scanSerial(rows)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, serial)
+	}
+	return result, rows.Err()
+}
+
+func (t *SqlTransaction) InsertSerial(serial domain.Serial) error {
+	_, err := t.GetSerial(serial.Number)
+	if err := ensureMissing(err, "serial "+serial.Number); err != nil {
+		return err
+	}
+	_, err = t.exec(query.InsertIntoSerials, serial.Number, serial.Sku, serial.WarehouseName, formatTimestamp(serial.ReceivedAt))
+	return err
+}
+
+func (t *SqlTransaction) UpdateSerial(serial domain.Serial) error {
+	if _, err := t.GetSerial(serial.Number); err != nil {
+		return err
+	}
+	_, err := t.exec(query.UpdateSerial, serial.Sku, serial.WarehouseName, formatTimestamp(serial.ReceivedAt), serial.Number)
+	return err
+}
+
+func (t *SqlTransaction) InsertSerialEvent(event domain.SerialEvent) error {
+	_, err := t.exec(
+		query.InsertIntoSerialEvents,
+		event.Serial,
+		formatTimestamp(event.Timestamp),
+		event.WarehouseName,
+		event.Reason,
+		event.CorrelationID,
+		event.Actor,
+	)
+	return err
+}
+
+func (t *SqlTransaction) GetSerialEvents(number string) ([]domain.SerialEvent, error) {
+	rows, err := t.query(query.SelectSerialEvents, number)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var result []domain.SerialEvent
+	for rows.Next() {
+		var event domain.SerialEvent
+		var timestamp string
+		if err := rows.Scan(
+			&event.ID,
+			&event.Serial,
+			&timestamp,
+			&event.WarehouseName,
+			&event.Reason,
+			&event.CorrelationID,
+			&event.Actor,
+		); err != nil {
+			return nil, err
+		}
+		if event.Timestamp, err = parseTimestamp(timestamp); err != nil {
+			return nil, err
+		}
+		result = append(result, event)
+	}
+	return result, rows.Err()
+}
+
+func scanSerial(row interface{ Scan(...any) error }) (domain.Serial, error) {
+	var serial domain.Serial
+	var receivedAt string
+	if err := row.Scan(&serial.Number, &serial.Sku, &serial.WarehouseName, &receivedAt); err != nil {
+		return domain.Serial{}, err
+	}
+	var err error
+	serial.ReceivedAt, err = parseTimestamp(receivedAt)
+	return serial, err
+}
@@ -1,15 +1,15 @@
 package sql
 
 import (
+	"context"
 	"database/sql"
+	"fmt"
 
 	"github.com/kijevigombooc/inventory-manager/internal/inventory/store"
-	"github.com/kijevigombooc/inventory-manager/internal/inventory/store/sql/query"
-	_ "github.com/mattn/go-sqlite3"
 )
 
-func NewInventoryStore(db *sql.DB) *inventoryStore {
-	s := &inventoryStore{db: db}
+func NewInventoryStore(db *sql.DB, dialect *Dialect) *inventoryStore {
+	s := &inventoryStore{db: db, dialect: dialect}
 	if err := s.Init(); err != nil {
 		panic(err)
 	}
@@ -17,42 +17,37 @@ func NewInventoryStore(db *sql.DB) *inventoryStore {
 }
 
 type inventoryStore struct {
-	db *sql.DB
+	db      *sql.DB
+	dialect *Dialect
 }
 
 func (s *inventoryStore) Init() error {
-	// TODO: check if required
-	if _, err := s.db.Exec("PRAGMA foreign_keys=ON"); err != nil {
-		return err
-	}
-	if _, err := s.db.Exec(query.CreateWarehousesTable); err != nil {
-		return err
-	}
-	if _, err := s.db.Exec(query.CreateBrandsTable); err != nil {
-		return err
-	}
-	if _, err := s.db.Exec(query.CreateProductsTable); err != nil {
-		return err
-	}
-	if _, err := s.db.Exec(query.CreateWarehouseProductsTable); err != nil {
-		return err
-	}
-	if _, err := s.db.Exec(query.CreateBookProductsTable); err != nil {
-		return err
-	}
-	if _, err := s.db.Exec(query.CreateConsumableProductsTable); err != nil {
-		return err
-	}
-	if _, err := s.db.Exec(query.CreateElectronicsProductsTable); err != nil {
+	if s.dialect == SqliteDialect {
+		// the pragma is per connection, so it has to come from the DSN that every pooled connection is opened with
+		var foreignKeys bool
+		if err := s.db.QueryRow("PRAGMA foreign_keys").Scan(&foreignKeys); err != nil {
+			return err
+		}
+		if !foreignKeys {
+			return fmt.Errorf("sqlite foreign keys are off, open the database with OpenSqliteDB or _foreign_keys=on")
+		}
+	}
+	migrator, err := NewMigrator(s.db, s.dialect)
+	if err != nil {
 		return err
 	}
-	return nil
+	_, err = migrator.Up(0)
+	return err
+}
+
+func (s *inventoryStore) IsRetryable(err error) bool {
+	return s.dialect.isRetryable(err)
 }
 
-func (s *inventoryStore) BeginTransaction() store.Transaction {
-	tx, err := s.db.Begin()
+func (s *inventoryStore) BeginTransaction(ctx context.Context) (store.Transaction, error) {
+	tx, err := s.db.BeginTx(ctx, &sql.TxOptions{Isolation: s.dialect.isolation})
 	if err != nil {
-		panic(err)
+		return nil, err
 	}
-	return &SqlTransaction{tx: tx, commited: false}
+	return &SqlTransaction{ctx: ctx, tx: tx, dialect: s.dialect, commited: false}, nil
 }
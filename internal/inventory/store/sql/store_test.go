@@ -0,0 +1,353 @@
+package sql
+
+import (
+	"context"
+	"database/sql"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/kijevigombooc/inventory-manager/internal/inventory/handler/dto"
+	"github.com/kijevigombooc/inventory-manager/internal/inventory/producttype"
+	"github.com/kijevigombooc/inventory-manager/internal/inventory/store"
+	"github.com/kijevigombooc/inventory-manager/internal/inventory/store/domain"
+	"github.com/kijevigombooc/inventory-manager/internal/inventory/store/sql/migration"
+	"github.com/kijevigombooc/inventory-manager/internal/inventory/store/storetest"
+)
+
+func TestSqliteInMemoryConformance(t *testing.T) {
+	storetest.RunTransactionSuite(t, func(t *testing.T) store.Store {
+		return newSqliteStore(t, InMemoryPath)
+	})
+}
+
+func TestSqliteFileConformance(t *testing.T) {
+	storetest.RunTransactionSuite(t, func(t *testing.T) store.Store {
+		return newSqliteStore(t, filepath.Join(t.TempDir(), "inventory.db"))
+	})
+}
+
+func TestSqliteFilePathWithUriCharacters(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "stock?#%25 A", "inventory?.db")
+	db, err := OpenSqliteDB(path)
+	if err != nil {
+		t.Fatalf("Error opening database: %v", err)
+	}
+	defer db.Close()
+	NewInventoryStore(db, SqliteDialect)
+	if _, err := os.Stat(path); err != nil {
+		t.Fatalf("Database should be created at %s: %v", path, err)
+	}
+}
+
+func TestSqliteInitExistingDatabase(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "inventory.db")
+	newSqliteStore(t, path)
+	s := newSqliteStore(t, path)
+	if err := s.Init(); err != nil {
+		t.Fatalf("Error initializing existing database: %v", err)
+	}
+}
+
+func TestSqliteInitRequiresForeignKeys(t *testing.T) {
+	db, err := sql.Open(SqliteDialect.driverName, InMemoryPath)
+	if err != nil {
+		t.Fatalf("Error opening database: %v", err)
+	}
+	defer db.Close()
+	s := &inventoryStore{db: db, dialect: SqliteDialect}
+	if err := s.Init(); err == nil {
+		t.Fatalf("Init should fail when foreign keys are off")
+	}
+}
+
+func TestSqliteWarehouseProductQuantityCheck(t *testing.T) {
+	db, err := OpenSqliteDB(InMemoryPath)
+	if err != nil {
+		t.Fatalf("Error opening database: %v", err)
+	}
+	defer db.Close()
+	NewInventoryStore(db, SqliteDialect)
+	for _, statement := range []string{
+		"INSERT INTO warehouses (name, address, capacity) VALUES ('A', 'Address', 10)",
+		"INSERT INTO brands (name, category) VALUES ('Brand', 3)",
+		"INSERT INTO products (sku, name, price, brand, type) VALUES ('BOOK-A', 'Book', 1, 'Brand', 'Book')",
+		"INSERT INTO warehouse_products (warehouse_name, sku, quantity) VALUES ('A', 'BOOK-A', 2)",
+	} {
+		if _, err := db.Exec(statement); err != nil {
+			t.Fatalf("Error seeding database: %v", err)
+		}
+	}
+	migrator := newMigrator(t, db)
+	rollBackTo(t, migrator, 4)
+	if _, err := migrator.Up(0); err != nil {
+		t.Fatalf("Error applying the quantity check: %v", err)
+	}
+	var quantity int
+	if err := db.QueryRow("SELECT quantity FROM warehouse_products WHERE sku = 'BOOK-A'").Scan(&quantity); err != nil || quantity != 2 {
+		t.Fatalf("Stock should survive the table rebuild, got %d, %v", quantity, err)
+	}
+	if _, err := db.Exec("UPDATE warehouse_products SET quantity = -1"); err == nil {
+		t.Fatalf("Negative quantities should be rejected")
+	}
+}
+
+func TestSqliteTypedProductAttributesMigration(t *testing.T) {
+	db, err := OpenSqliteDB(InMemoryPath)
+	if err != nil {
+		t.Fatalf("Error opening database: %v", err)
+	}
+	defer db.Close()
+	NewInventoryStore(db, SqliteDialect)
+	migrator := newMigrator(t, db)
+	rollBackTo(t, migrator, 7)
+	for _, statement := range []string{
+		"INSERT INTO brands (name, category) VALUES ('Brand', 3)",
+		"INSERT INTO products (sku, name, price, brand, type) VALUES ('CONS-A', 'Milk', 1, 'Brand', 'Consumable')",
+		"INSERT INTO consumable_products (sku, expiration_date) VALUES ('CONS-A', '2024.12.12')",
+		"INSERT INTO products (sku, name, price, brand, type) VALUES ('CONS-B', 'Bread', 1, 'Brand', 'Consumable')",
+		"INSERT INTO consumable_products (sku, expiration_date) VALUES ('CONS-B', '2025/3/7')",
+		"INSERT INTO products (sku, name, price, brand, type) VALUES ('ELEC-A', 'Phone', 1, 'Brand', 'Electronics')",
+		"INSERT INTO electronics_products (sku, warranty) VALUES ('ELEC-A', 'P2Y')",
+		"INSERT INTO products (sku, name, price, brand, type) VALUES ('ELEC-B', 'Radio', 1, 'Brand', 'Electronics')",
+		"INSERT INTO electronics_products (sku, warranty) VALUES ('ELEC-B', '2 years')",
+	} {
+		if _, err := db.Exec(statement); err != nil {
+			t.Fatalf("Error seeding database: %v", err)
+		}
+	}
+	if _, err := migrator.Up(0); err != nil {
+		t.Fatalf("Error applying the typed attributes: %v", err)
+	}
+	trx, err := NewInventoryStore(db, SqliteDialect).BeginTransaction(context.Background())
+	if err != nil {
+		t.Fatalf("Error beginning transaction: %v", err)
+	}
+	defer trx.EndTransaction()
+	expected := map[string]any{
+		"CONS-A": time.Date(2024, 12, 12, 0, 0, 0, 0, time.UTC),
+		"CONS-B": time.Date(2025, 3, 7, 0, 0, 0, 0, time.UTC),
+		"ELEC-A": domain.Period{Months: 24},
+		"ELEC-B": domain.Period{},
+	}
+	for sku, value := range expected {
+		product, err := trx.GetCatalogProduct(sku)
+		if err != nil {
+			t.Fatalf("Error getting product: %v", err)
+		}
+		var actual any
+		switch p := product.(type) {
+		case *domain.ConsumableProduct:
+			actual = p.ExpirationDate
+		case *domain.ElectronicsProduct:
+			actual = p.WarrantyPeriod
+		}
+		if actual != value {
+			t.Fatalf("Attribute of %s should be %v, got %v", sku, value, actual)
+		}
+	}
+}
+
+func TestSqliteTypedProductAttributesMigrationUnknownDate(t *testing.T) {
+	db, err := OpenSqliteDB(InMemoryPath)
+	if err != nil {
+		t.Fatalf("Error opening database: %v", err)
+	}
+	defer db.Close()
+	NewInventoryStore(db, SqliteDialect)
+	migrator := newMigrator(t, db)
+	rollBackTo(t, migrator, 7)
+	for _, statement := range []string{
+		"INSERT INTO brands (name, category) VALUES ('Brand', 3)",
+		"INSERT INTO products (sku, name, price, brand, type) VALUES ('CONS-A', 'Milk', 1, 'Brand', 'Consumable')",
+		"INSERT INTO consumable_products (sku, expiration_date) VALUES ('CONS-A', '2024-12-12')",
+		"INSERT INTO products (sku, name, price, brand, type) VALUES ('CONS-B', 'Bread', 1, 'Brand', 'Consumable')",
+		"INSERT INTO consumable_products (sku, expiration_date) VALUES ('CONS-B', '12/11/2024')",
+	} {
+		if _, err := db.Exec(statement); err != nil {
+			t.Fatalf("Error seeding database: %v", err)
+		}
+	}
+	if _, err := migrator.Up(0); err == nil || !strings.Contains(err.Error(), "CONS-B") || strings.Contains(err.Error(), "CONS-A") {
+		t.Fatalf("Migration should fail naming CONS-B, got %v", err)
+	}
+	var date string
+	if err := db.QueryRow("SELECT expiration_date FROM consumable_products WHERE sku = 'CONS-B'").Scan(&date); err != nil || date != "12/11/2024" {
+		t.Fatalf("Failed migration should leave the date alone, got %s, %v", date, err)
+	}
+}
+
+type clothingProduct struct {
+	domain.Product
+	Size  string
+	Color string
+}
+
+type clothingProductDto struct {
+	dto.Product
+	Size  string `json:"size"`
+	Color string `json:"color"`
+}
+
+var registerClothing sync.Once
+
+// clothing is registered like a product type added after the store migrations were written
+func registerClothingProductType() {
+	registerClothing.Do(func() {
+		producttype.Register(producttype.Type{
+			Name:      "Clothing",
+			NewDto:    func() dto.IProduct { return &clothingProductDto{} },
+			NewEntity: func() domain.IProduct { return &clothingProduct{} },
+			ToEntity: func(product dto.IProduct) (domain.IProduct, error) {
+				clothing, err := producttype.As[*clothingProductDto](product)
+				if err != nil {
+					return nil, err
+				}
+				return &clothingProduct{Size: clothing.Size, Color: clothing.Color}, nil
+			},
+			ToDto: func(product domain.IProduct) (dto.IProduct, error) {
+				clothing, err := producttype.As[*clothingProduct](product)
+				if err != nil {
+					return nil, err
+				}
+				return &clothingProductDto{Size: clothing.Size, Color: clothing.Color}, nil
+			},
+			Table:   "clothing_products",
+			Columns: []string{"size", "color"},
+			// the version collides with the store migrations on purpose, each type numbers its own
+			Migrations: []producttype.Migration{{
+				Version: 1,
+				Name:    "create_clothing_products",
+				Up: []string{`
+					CREATE TABLE clothing_products (
+						sku TEXT PRIMARY KEY,
+						size TEXT NOT NULL,
+						color TEXT NOT NULL,
+						FOREIGN KEY (sku) REFERENCES products (sku) ON DELETE CASCADE
+					)
+				`},
+				Down: []string{"DROP TABLE clothing_products"},
+			}},
+			Values: func(product domain.IProduct) []any {
+				clothing := product.(*clothingProduct)
+				return []any{clothing.Size, clothing.Color}
+			},
+			Scan: func(product domain.IProduct, scan func(dest ...any) error) error {
+				clothing := product.(*clothingProduct)
+				return scan(&clothing.Size, &clothing.Color)
+			},
+		})
+	})
+}
+
+func TestSqliteRegisteredProductType(t *testing.T) {
+	registerClothingProductType()
+	s := newSqliteStore(t, InMemoryPath)
+	trx, err := s.BeginTransaction(context.Background())
+	if err != nil {
+		t.Fatalf("Error beginning transaction: %v", err)
+	}
+	defer trx.EndTransaction()
+	product := &clothingProduct{
+		Product: domain.Product{SKU: "CLOTH-A", Name: "Shirt", Price: 20, Brand: domain.Brand{Name: "Brand", Quality: 3}, Type: "Clothing"},
+		Size:    "M",
+		Color:   "blue",
+	}
+	if err := trx.InsertCatalogProduct(product); err != nil {
+		t.Fatalf("Error inserting product: %v", err)
+	}
+	product.Color = "red"
+	if err := trx.UpdateCatalogProduct(product); err != nil {
+		t.Fatalf("Error updating product: %v", err)
+	}
+	stored, err := trx.GetCatalogProduct("CLOTH-A")
+	if err != nil || !reflect.DeepEqual(stored, product) {
+		t.Fatalf("Product should be %v, got %v, %v", product, stored, err)
+	}
+	if deleted, err := trx.DeleteOrphanedProductAttributes(); err != nil || deleted != 0 {
+		t.Fatalf("No attributes should be orphaned, got %d, %v", deleted, err)
+	}
+}
+
+func TestSqliteRegisteredProductTypeMigrations(t *testing.T) {
+	registerClothingProductType()
+	db, err := OpenSqliteDB(InMemoryPath)
+	if err != nil {
+		t.Fatalf("Error opening database: %v", err)
+	}
+	defer db.Close()
+	NewInventoryStore(db, SqliteDialect)
+	migrator := newMigrator(t, db)
+	statuses, err := migrator.Status()
+	if err != nil {
+		t.Fatalf("Error getting migration status: %v", err)
+	}
+	last := statuses[len(statuses)-1]
+	if last.String() != "clothing/1_create_clothing_products" || !last.Applied {
+		t.Fatalf("The clothing migration should be applied last, got %v", last)
+	}
+	if _, err := migrator.Down(1); err != nil {
+		t.Fatalf("Error rolling back: %v", err)
+	}
+	if version, err := migrator.CurrentVersion(); err != nil || version != migrator.LatestVersion() {
+		t.Fatalf("Rolling back the clothing migration should leave the store at %d, got %d, %v", migrator.LatestVersion(), version, err)
+	}
+	if _, err := db.Exec("SELECT sku FROM clothing_products"); err == nil {
+		t.Fatalf("Rolling back should drop clothing_products")
+	}
+	if _, err := migrator.Up(0); err != nil {
+		t.Fatalf("Error applying migrations: %v", err)
+	}
+	if _, err := db.Exec("SELECT sku FROM clothing_products"); err != nil {
+		t.Fatalf("Migrating should create clothing_products: %v", err)
+	}
+}
+
+func newMigrator(t *testing.T, db *sql.DB) *migration.Migrator {
+	migrator, err := NewMigrator(db, SqliteDialect)
+	if err != nil {
+		t.Fatalf("Error creating migrator: %v", err)
+	}
+	return migrator
+}
+
+func TestMigratorErrorDuplicateVersion(t *testing.T) {
+	migrations := []migration.Migration{
+		{Scope: "clothing", Version: 1, Name: "first"},
+		{Version: 1, Name: "store"},
+		{Scope: "clothing", Version: 1, Name: "second"},
+	}
+	if _, err := migration.NewMigrator(nil, migrations, SqliteDialect.Rebind); err == nil {
+		t.Fatalf("Expected an error for a version used twice in a scope")
+	}
+}
+
+// rollBackTo rolls back the product type migrations too, they come after the store migrations.
+func rollBackTo(t *testing.T, migrator *migration.Migrator, version int) {
+	statuses, err := migrator.Status()
+	if err != nil {
+		t.Fatalf("Error getting migration status: %v", err)
+	}
+	steps := 0
+	for _, status := range statuses {
+		if status.Applied && (status.Scope != "" || status.Version > version) {
+			steps++
+		}
+	}
+	if _, err := migrator.Down(steps); err != nil {
+		t.Fatalf("Error rolling back to version %d: %v", version, err)
+	}
+}
+
+func newSqliteStore(t *testing.T, path string) store.Store {
+	db, err := OpenSqliteDB(path)
+	if err != nil {
+		t.Fatalf("Error opening database: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return NewInventoryStore(db, SqliteDialect)
+}
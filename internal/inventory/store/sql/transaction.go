@@ -1,15 +1,21 @@
 package sql
 
 import (
+	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 
+	"github.com/kijevigombooc/inventory-manager/internal/inventory/store"
 	"github.com/kijevigombooc/inventory-manager/internal/inventory/store/domain"
 	"github.com/kijevigombooc/inventory-manager/internal/inventory/store/sql/query"
+	"github.com/kijevigombooc/inventory-manager/internal/utils"
 )
 
 type SqlTransaction struct {
+	ctx      context.Context
 	tx       *sql.Tx
+	dialect  *Dialect
 	commited bool
 }
 
@@ -33,7 +39,7 @@ func (t *SqlTransaction) EndTransaction() {
 }
 
 func (t *SqlTransaction) GetWarehouses() ([]domain.Warehouse, error) {
-	rows, err := t.tx.Query(query.SelectWarehouses)
+	rows, err := t.query(query.SelectWarehouses)
 	if err != nil {
 		return nil, err
 	}
@@ -41,7 +47,7 @@ func (t *SqlTransaction) GetWarehouses() ([]domain.Warehouse, error) {
 	var result []domain.Warehouse
 	for rows.Next() {
 		var we domain.Warehouse
-		if err := rows.Scan(&we.Name, &we.Address, &we.Capacity); err != nil {
+		if err := rows.Scan(&we.Name, &we.Address, &we.Capacity, &we.MinBrandQuality); err != nil {
 			return nil, err
 		}
 		result = append(result, we)
@@ -50,7 +56,7 @@ func (t *SqlTransaction) GetWarehouses() ([]domain.Warehouse, error) {
 }
 
 func (t *SqlTransaction) GetWarehousesOrderedFirstWithName(warehouse string) ([]domain.Warehouse, error) {
-	rows, err := t.tx.Query(query.SelectWarehousesOrderedFirstWithName, warehouse)
+	rows, err := t.query(query.SelectWarehousesOrderedFirstWithName, warehouse)
 	if err != nil {
 		return nil, err
 	}
@@ -58,7 +64,7 @@ func (t *SqlTransaction) GetWarehousesOrderedFirstWithName(warehouse string) ([]
 	var result []domain.Warehouse
 	for rows.Next() {
 		var we domain.Warehouse
-		if err := rows.Scan(&we.Name, &we.Address, &we.Capacity); err != nil {
+		if err := rows.Scan(&we.Name, &we.Address, &we.Capacity, &we.MinBrandQuality); err != nil {
 			return nil, err
 		}
 		result = append(result, we)
@@ -66,27 +72,125 @@ func (t *SqlTransaction) GetWarehousesOrderedFirstWithName(warehouse string) ([]
 	return result, nil
 }
 
+func (t *SqlTransaction) GetWarehouse(name string) (domain.Warehouse, error) {
+	var we domain.Warehouse
+	err := t.queryRow(query.SelectWarehouse, name).Scan(&we.Name, &we.Address, &we.Capacity, &we.MinBrandQuality)
+	if err == sql.ErrNoRows {
+		return domain.Warehouse{}, fmt.Errorf("%w: warehouse %s", store.ErrNotFound, name)
+	}
+	if err != nil {
+		return domain.Warehouse{}, err
+	}
+	return we, nil
+}
+
 func (t *SqlTransaction) InsertWarehouse(entity domain.Warehouse) error {
-	_, err := t.tx.Exec(query.InsertIntoWarehouses, entity.Name, entity.Address, entity.Capacity)
+	_, err := t.exec(query.InsertIntoWarehouses, entity.Name, entity.Address, entity.Capacity, entity.MinBrandQuality)
+	if t.dialect.isUniqueViolation(err) {
+		return fmt.Errorf("%w: warehouse %s", store.ErrAlreadyExists, entity.Name)
+	}
+	return err
+}
+
+// UpdateWarehouse renames by inserting the new row and moving the stock over, so it works without ON UPDATE CASCADE.
+func (t *SqlTransaction) UpdateWarehouse(name string, entity domain.Warehouse) error {
+	if _, err := t.GetWarehouse(name); err != nil {
+		return err
+	}
+	if entity.Name == name {
+		_, err := t.exec(query.UpdateWarehouse, entity.Address, entity.Capacity, entity.MinBrandQuality, name)
+		return err
+	}
+	_, err := t.GetWarehouse(entity.Name)
+	if err := ensureMissing(err, "warehouse "+entity.Name); err != nil {
+		return err
+	}
+	if err := t.InsertWarehouse(entity); err != nil {
+		return err
+	}
+	if _, err := t.exec(query.UpdateWarehouseProductsWarehouseName, entity.Name, name); err != nil {
+		return err
+	}
+	if _, err := t.exec(query.UpdateWarehouseLotsWarehouseName, entity.Name, name); err != nil {
+		return err
+	}
+	if _, err := t.exec(query.UpdateQuarantinedStockWarehouseName, entity.Name, name); err != nil {
+		return err
+	}
+	if _, err := t.exec(query.UpdateSerialsWarehouseName, entity.Name, name); err != nil {
+		return err
+	}
+	_, err = t.exec(query.DeleteWarehouse, name)
+	return err
+}
+
+// ensureMissing turns the lookup of a row about to be inserted into ErrAlreadyExists when the row was found.
+// Inserts look for duplicates first instead of relying on constraints, because a failed statement aborts
+// the whole transaction on postgres.
+func ensureMissing(lookupErr error, what string) error {
+	if lookupErr == nil {
+		return fmt.Errorf("%w: %s", store.ErrAlreadyExists, what)
+	}
+	if errors.Is(lookupErr, store.ErrNotFound) {
+		return nil
+	}
+	return lookupErr
+}
+
+func (t *SqlTransaction) DeleteWarehouse(name string) error {
+	if _, err := t.GetWarehouse(name); err != nil {
+		return err
+	}
+	usedCapacity, err := t.GetUsedCapacity(name)
+	if err != nil {
+		return err
+	}
+	if usedCapacity > 0 {
+		return fmt.Errorf("warehouse %s still holds %d products", name, usedCapacity)
+	}
+	_, err = t.exec(query.DeleteWarehouse, name)
 	return err
 }
 
 func (t *SqlTransaction) GetProductsByWarehouse(name string) ([]domain.ProductWithQuantity, error) {
-	rows, err := t.tx.Query(query.SelectProductsByWarehouse, name)
+	rows, err := t.query(query.SelectProductsByWarehouse, name)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
+	var baseProducts []domain.Product
+	var quantities []int
+	for rows.Next() {
+		var baseProduct domain.Product
+		var quantity int
+		if err := rows.Scan(
+			&baseProduct.SKU,
+			&baseProduct.Name,
+			&baseProduct.Price,
+			&baseProduct.Brand.Name,
+			&baseProduct.Type,
+			&quantity,
+		); err != nil {
+			return nil, err
+		}
+		baseProducts = append(baseProducts, baseProduct)
+		quantities = append(quantities, quantity)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	// the details are loaded after closing the rows, postgres cannot run a second query on a busy connection
+	rows.Close()
 
 	var products []domain.ProductWithQuantity
-	for rows.Next() {
-		product, quantity, err := t.mapCurrentRowsToProduct(rows)
+	for i, baseProduct := range baseProducts {
+		product, err := t.loadProduct(baseProduct)
 		if err != nil {
 			return nil, err
 		}
 		products = append(products, domain.ProductWithQuantity{
 			Product:  product,
-			Quantity: quantity,
+			Quantity: quantities[i],
 		})
 	}
 	return products, nil
@@ -94,21 +198,17 @@ func (t *SqlTransaction) GetProductsByWarehouse(name string) ([]domain.ProductWi
 
 func (t *SqlTransaction) GetUsedCapacity(warehouseName string) (int, error) {
 	var usedCapacity int
-	if err := t.tx.QueryRow(query.SelectUsedCapacitiyByWarehouse, warehouseName).Scan(&usedCapacity); err != nil {
+	if err := t.queryRow(query.SelectUsedCapacitiyByWarehouse, warehouseName).Scan(&usedCapacity); err != nil {
 		return 0, err
 	}
 	return usedCapacity, nil
 }
 func (t *SqlTransaction) InsertProduct(warehouseName string, product domain.IProduct, toInsertQuantity int) error {
 	baseProduct := product.GetBaseProduct()
-	if _, err := t.tx.Exec(
-		query.InsertOrIgnoreIntoBrands,
-		baseProduct.Brand.Name,
-		baseProduct.Brand.Quality,
-	); err != nil {
+	if err := t.insertOrIgnoreBrand(baseProduct.Brand); err != nil {
 		return err
 	}
-	if _, err := t.tx.Exec(
+	if _, err := t.exec(
 		query.InsertOrIgnoreIntoProducts,
 		baseProduct.SKU,
 		baseProduct.Name,
@@ -118,38 +218,17 @@ func (t *SqlTransaction) InsertProduct(warehouseName string, product domain.IPro
 	); err != nil {
 		return err
 	}
-	switch product.GetType() {
-	case domain.Book:
-		if _, err := t.tx.Exec(
-			query.InsertOrIgnoreIntoBookProducts,
-			baseProduct.SKU,
-			product.(*domain.BookProduct).Author,
-		); err != nil {
-			return err
-		}
-	case domain.Consumable:
-		if _, err := t.tx.Exec(
-			query.InsertOrIgnoreIntoConsumableProducts,
-			baseProduct.SKU,
-			product.(*domain.ConsumableProduct).ExpirationDate,
-		); err != nil {
-			return err
-		}
-	case domain.Electronics:
-		if _, err := t.tx.Exec(
-			query.InsertOrIgnoreIntoElectronicsProducts,
-			baseProduct.SKU,
-			product.(*domain.ElectronicsProduct).WarrantyPeriod,
-		); err != nil {
-			return err
-		}
+	if err := t.insertProductAttributes(product); err != nil {
+		return err
 	}
-	if _, err := t.tx.Exec(
+	if toInsertQuantity == 0 {
+		return nil
+	}
+	if _, err := t.exec(
 		query.InsertOrUpdateIntoWarehouseProducts,
 		warehouseName,
 		baseProduct.SKU,
 		toInsertQuantity,
-		toInsertQuantity,
 	); err != nil {
 		return err
 	}
@@ -158,7 +237,7 @@ func (t *SqlTransaction) InsertProduct(warehouseName string, product domain.IPro
 
 func (t *SqlTransaction) GetProductTypeBySku(sku string) (domain.ProductType, error) {
 	var productType domain.ProductType
-	err := t.tx.QueryRow(query.SelectProductTypeBySku, sku).Scan(&productType)
+	err := t.queryRow(query.SelectProductTypeBySku, sku).Scan(&productType)
 	if err == sql.ErrNoRows {
 		return domain.None, nil
 	}
@@ -169,7 +248,7 @@ func (t *SqlTransaction) GetProductTypeBySku(sku string) (domain.ProductType, er
 }
 
 func (t *SqlTransaction) GetWarehouseProductsBySkuOrderedFirstWithName(warehouseName string, sku string) ([]domain.WarehouseProduct, error) {
-	rows, err := t.tx.Query(query.SelectWarehouseProductBySkuOrderedFirstWithName, sku, warehouseName)
+	rows, err := t.query(query.SelectWarehouseProductBySkuOrderedFirstWithName, sku, warehouseName)
 	if err != nil {
 		return nil, err
 	}
@@ -186,15 +265,15 @@ func (t *SqlTransaction) GetWarehouseProductsBySkuOrderedFirstWithName(warehouse
 }
 
 func (t *SqlTransaction) RemoveProduct(warehouseName string, sku string, toRemoveQuantity int) (int, error) {
-	queryResult := t.tx.QueryRow(query.SelectWarehouseProductQuantity, warehouseName, sku)
-	if queryResult.Err() == sql.ErrNoRows {
+	originalQuantity := 0
+	err := t.queryRow(query.SelectWarehouseProductQuantity, warehouseName, sku).Scan(&originalQuantity)
+	if err == sql.ErrNoRows {
 		return 0, nil
 	}
-	originalQuantity := 0
-	if err := queryResult.Scan(&originalQuantity); err != nil {
+	if err != nil {
 		return 0, err
 	}
-	updateResult := t.tx.QueryRow(query.UpdateWarehouseProductQuantity, toRemoveQuantity, toRemoveQuantity, warehouseName, sku)
+	updateResult := t.queryRow(query.UpdateWarehouseProductQuantity, toRemoveQuantity, toRemoveQuantity, warehouseName, sku)
 	if updateResult.Err() == sql.ErrNoRows {
 		return 0, nil
 	}
@@ -202,56 +281,230 @@ func (t *SqlTransaction) RemoveProduct(warehouseName string, sku string, toRemov
 	if err := updateResult.Scan(&newQuantity); err != nil {
 		return 0, err
 	}
-	// TODO: clear row if not needed (if 0 quantity)
+	if newQuantity == 0 {
+		if _, err := t.exec(query.DeleteEmptyWarehouseProduct, warehouseName, sku); err != nil {
+			return 0, err
+		}
+	}
 	removedQuantity := originalQuantity - newQuantity
 	return removedQuantity, nil
 }
 
-func (t *SqlTransaction) mapCurrentRowsToProduct(rows *sql.Rows) (domain.IProduct, int, error) {
-	baseProduct := domain.Product{}
-	quantity := 0
-	if err := rows.Scan(
+func (t *SqlTransaction) GetCatalogProducts() ([]domain.IProduct, error) {
+	return t.queryProducts(query.SelectProducts)
+}
+
+func (t *SqlTransaction) GetCatalogProductsByBrand(brandName string) ([]domain.IProduct, error) {
+	return t.queryProducts(query.SelectProductsByBrand, brandName)
+}
+
+func (t *SqlTransaction) queryProducts(productsQuery string, args ...any) ([]domain.IProduct, error) {
+	rows, err := t.query(productsQuery, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var baseProducts []domain.Product
+	for rows.Next() {
+		baseProduct, err := scanBaseProduct(rows)
+		if err != nil {
+			return nil, err
+		}
+		baseProducts = append(baseProducts, baseProduct)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	rows.Close()
+	return utils.MapErrored(baseProducts, t.loadProduct)
+}
+
+func (t *SqlTransaction) GetCatalogProduct(sku string) (domain.IProduct, error) {
+	baseProduct, err := scanBaseProduct(t.queryRow(query.SelectProduct, sku))
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("%w: product %s", store.ErrNotFound, sku)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return t.loadProduct(baseProduct)
+}
+
+func (t *SqlTransaction) InsertCatalogProduct(product domain.IProduct) error {
+	baseProduct := product.GetBaseProduct()
+	productType, err := t.GetProductTypeBySku(baseProduct.SKU)
+	if err != nil {
+		return err
+	}
+	if productType != domain.None {
+		return fmt.Errorf("%w: product %s", store.ErrAlreadyExists, baseProduct.SKU)
+	}
+	if err := t.insertOrIgnoreBrand(baseProduct.Brand); err != nil {
+		return err
+	}
+	if _, err := t.exec(
+		query.InsertIntoProducts,
+		baseProduct.SKU,
+		baseProduct.Name,
+		baseProduct.Price,
+		baseProduct.Brand.Name,
+		baseProduct.Type,
+	); err != nil {
+		return err
+	}
+	return t.insertProductAttributes(product)
+}
+
+func (t *SqlTransaction) UpdateCatalogProduct(product domain.IProduct) error {
+	baseProduct := product.GetBaseProduct()
+	productType, err := t.GetProductTypeBySku(baseProduct.SKU)
+	if err != nil {
+		return err
+	}
+	if productType == domain.None {
+		return fmt.Errorf("%w: product %s", store.ErrNotFound, baseProduct.SKU)
+	}
+	if productType != product.GetType() {
+		return fmt.Errorf("product %s is %s, cannot change it to %s", baseProduct.SKU, productType, product.GetType())
+	}
+	if err := t.insertOrIgnoreBrand(baseProduct.Brand); err != nil {
+		return err
+	}
+	if _, err := t.exec(query.UpdateProduct, baseProduct.Name, baseProduct.Price, baseProduct.Brand.Name, baseProduct.SKU); err != nil {
+		return err
+	}
+	return t.updateProductAttributes(product)
+}
+
+// DeleteCatalogProduct removes a product without stock, the type specific row goes with it through ON DELETE CASCADE.
+func (t *SqlTransaction) DeleteCatalogProduct(sku string) error {
+	productType, err := t.GetProductTypeBySku(sku)
+	if err != nil {
+		return err
+	}
+	if productType == domain.None {
+		return fmt.Errorf("%w: product %s", store.ErrNotFound, sku)
+	}
+	var quantity int
+	if err := t.queryRow(query.SelectQuantityBySku, sku).Scan(&quantity); err != nil {
+		return err
+	}
+	if quantity > 0 {
+		return fmt.Errorf("product %s still has %d in stock", sku, quantity)
+	}
+	if _, err := t.exec(query.DeleteLotsBySku, sku); err != nil {
+		return err
+	}
+	_, err = t.exec(query.DeleteProduct, sku)
+	return err
+}
+
+func (t *SqlTransaction) DeleteUnusedProducts() (int, error) {
+	if _, err := t.exec(query.DeleteLotsWithoutStock); err != nil {
+		return 0, err
+	}
+	return t.execCount(query.DeleteProductsWithoutStock)
+}
+
+func (t *SqlTransaction) DeleteUnusedBrands() (int, error) {
+	return t.execCount(query.DeleteBrandsWithoutProducts)
+}
+
+func (t *SqlTransaction) GetBrands() ([]domain.Brand, error) {
+	rows, err := t.query(query.SelectBrands)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var result []domain.Brand
+	for rows.Next() {
+		var brand domain.Brand
+		if err := rows.Scan(&brand.Name, &brand.Quality); err != nil {
+			return nil, err
+		}
+		result = append(result, brand)
+	}
+	return result, rows.Err()
+}
+
+func (t *SqlTransaction) GetBrand(name string) (domain.Brand, error) {
+	var brand domain.Brand
+	err := t.queryRow(query.SelectBrand, name).Scan(&brand.Name, &brand.Quality)
+	if err == sql.ErrNoRows {
+		return domain.Brand{}, fmt.Errorf("%w: brand %s", store.ErrNotFound, name)
+	}
+	if err != nil {
+		return domain.Brand{}, err
+	}
+	return brand, nil
+}
+
+func (t *SqlTransaction) InsertBrand(brand domain.Brand) error {
+	_, err := t.GetBrand(brand.Name)
+	if err := ensureMissing(err, "brand "+brand.Name); err != nil {
+		return err
+	}
+	_, err = t.exec(query.InsertIntoBrands, brand.Name, brand.Quality)
+	return err
+}
+
+func (t *SqlTransaction) UpdateBrand(brand domain.Brand) error {
+	if _, err := t.GetBrand(brand.Name); err != nil {
+		return err
+	}
+	_, err := t.exec(query.UpdateBrand, brand.Quality, brand.Name)
+	return err
+}
+
+func (t *SqlTransaction) DeleteBrand(name string) error {
+	if _, err := t.GetBrand(name); err != nil {
+		return err
+	}
+	products, err := t.GetCatalogProductsByBrand(name)
+	if err != nil {
+		return err
+	}
+	if len(products) > 0 {
+		return fmt.Errorf("brand %s still has %d products", name, len(products))
+	}
+	_, err = t.exec(query.DeleteBrand, name)
+	return err
+}
+
+func (t *SqlTransaction) insertOrIgnoreBrand(brand domain.Brand) error {
+	_, err := t.exec(query.InsertOrIgnoreIntoBrands, brand.Name, brand.Quality)
+	return err
+}
+
+func scanBaseProduct(row interface{ Scan(dest ...any) error }) (domain.Product, error) {
+	var baseProduct domain.Product
+	err := row.Scan(
 		&baseProduct.SKU,
 		&baseProduct.Name,
 		&baseProduct.Price,
 		&baseProduct.Brand.Name,
 		&baseProduct.Type,
-		&quantity,
-	); err != nil {
-		return nil, quantity, err
-	}
-	if err := t.tx.QueryRow(query.SelectBrandQuality, baseProduct.Brand.Name).Scan(&baseProduct.Brand.Quality); err != nil {
-		return nil, 0, err
-	}
-	switch baseProduct.Type {
-	case domain.Book:
-		product := domain.BookProduct{
-			Product: baseProduct,
-		}
-		err := t.tx.QueryRow(query.SelectFromBookProducts, product.SKU).Scan(&product.Author)
-		if err != nil {
-			return nil, quantity, err
-		}
-		return &product, quantity, nil
-	case domain.Consumable:
-		product := domain.ConsumableProduct{
-			Product: baseProduct,
-		}
-		err := t.tx.QueryRow(query.SelectFromConsumableProducts, product.SKU).Scan(&product.ExpirationDate)
-		if err != nil {
-			return nil, quantity, err
-		}
-		return &product, quantity, nil
-	case domain.Electronics:
-		product := domain.ElectronicsProduct{
-			Product: baseProduct,
-		}
-		err := t.tx.QueryRow(query.SelectFromElectronicsProducts, product.SKU).Scan(&product.WarrantyPeriod)
-		if err != nil {
-			return nil, quantity, err
-		}
-		return &product, quantity, nil
-	default:
-		return nil, quantity, fmt.Errorf("unknown product type: %s", baseProduct.Type)
+	)
+	return baseProduct, err
+}
+
+func (t *SqlTransaction) query(query string, args ...any) (*sql.Rows, error) {
+	return t.tx.QueryContext(t.ctx, t.dialect.Rebind(query), args...)
+}
+
+func (t *SqlTransaction) queryRow(query string, args ...any) *sql.Row {
+	return t.tx.QueryRowContext(t.ctx, t.dialect.Rebind(query), args...)
+}
+
+func (t *SqlTransaction) exec(query string, args ...any) (sql.Result, error) {
+	return t.tx.ExecContext(t.ctx, t.dialect.Rebind(query), args...)
+}
+
+func (t *SqlTransaction) execCount(query string, args ...any) (int, error) {
+	result, err := t.exec(query, args...)
+	if err != nil {
+		return 0, err
 	}
+	count, err := result.RowsAffected()
+	return int(count), err
 }
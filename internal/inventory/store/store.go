@@ -1,6 +1,9 @@
 package store
 
+import "context"
+
 type Store interface {
 	Init() error
-	BeginTransaction() Transaction
+	BeginTransaction(ctx context.Context) (Transaction, error)
+	IsRetryable(err error) bool
 }
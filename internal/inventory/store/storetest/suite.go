@@ -0,0 +1,1058 @@
+// Package storetest holds the behaviour every store.Store implementation has to share with the others.
+package storetest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/kijevigombooc/inventory-manager/internal/inventory/store"
+	"github.com/kijevigombooc/inventory-manager/internal/inventory/store/domain"
+)
+
+// RunTransactionSuite runs the conformance tests, newStore has to return an empty, initialized store on every call.
+func RunTransactionSuite(t *testing.T, newStore func(t *testing.T) store.Store) {
+	tests := []struct {
+		name string
+		run  func(t *testing.T, s store.Store)
+	}{
+		{"WarehousesOrderedFirstWithName", testWarehousesOrderedFirstWithName},
+		{"GetWarehouse", testGetWarehouse},
+		{"InsertWarehouseDuplicate", testInsertWarehouseDuplicate},
+		{"UpdateWarehouse", testUpdateWarehouse},
+		{"RenameWarehouseMovesStock", testRenameWarehouseMovesStock},
+		{"DeleteWarehouse", testDeleteWarehouse},
+		{"UsedCapacity", testUsedCapacity},
+		{"InsertProductAccumulates", testInsertProductAccumulates},
+		{"ProductsByWarehouse", testProductsByWarehouse},
+		{"ProductTypeBySku", testProductTypeBySku},
+		{"WarehouseProductsBySkuOrderedFirstWithName", testWarehouseProductsBySkuOrderedFirstWithName},
+		{"RemoveProductClampsToZero", testRemoveProductClampsToZero},
+		{"RemoveProductDeletesEmptyRows", testRemoveProductDeletesEmptyRows},
+		{"CatalogProducts", testCatalogProducts},
+		{"UpdateCatalogProduct", testUpdateCatalogProduct},
+		{"DeleteCatalogProduct", testDeleteCatalogProduct},
+		{"Brands", testBrands},
+		{"DeleteBrand", testDeleteBrand},
+		{"DeleteUnused", testDeleteUnused},
+		{"Lots", testLots},
+		{"QuarantinedStock", testQuarantinedStock},
+		{"Serials", testSerials},
+		{"Movements", testMovements},
+		{"StockAsOf", testStockAsOf},
+		{"CommitVisibility", testCommitVisibility},
+		{"RollbackVisibility", testRollbackVisibility},
+		{"ConcurrentTransactions", testConcurrentTransactions},
+		{"ConcurrentTransactionsWithRunner", testConcurrentTransactionsWithRunner},
+		{"CanceledContext", testCanceledContext},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			test.run(t, newStore(t))
+		})
+	}
+}
+
+func Book(sku string) *domain.BookProduct {
+	return &domain.BookProduct{
+		Product: domain.Product{SKU: sku, Name: "Book " + sku, Price: 100, Brand: domain.Brand{Name: "Book Brand", Quality: 4}, Type: "Book"},
+		Author:  "Author",
+	}
+}
+
+func Consumable(sku string) *domain.ConsumableProduct {
+	return &domain.ConsumableProduct{
+		Product:        domain.Product{SKU: sku, Name: "Consumable " + sku, Price: 200, Brand: domain.Brand{Name: "Consumable Brand", Quality: 3}, Type: "Consumable"},
+		ExpirationDate: time.Date(2024, 12, 12, 0, 0, 0, 0, time.UTC),
+	}
+}
+
+func Electronics(sku string) *domain.ElectronicsProduct {
+	return &domain.ElectronicsProduct{
+		Product:        domain.Product{SKU: sku, Name: "Electronics " + sku, Price: 300, Brand: domain.Brand{Name: "Electronics Brand", Quality: 5}, Type: "Electronics"},
+		WarrantyPeriod: domain.Period{Months: 24},
+	}
+}
+
+func testWarehousesOrderedFirstWithName(t *testing.T, s store.Store) {
+	withTransaction(t, s, func(trx store.Transaction) {
+		insertWarehouses(t, trx, 5, "B", "D", "A", "C")
+		assertWarehouseOrder(t, trx, "C", "C", "A", "B", "D")
+		assertWarehouseOrder(t, trx, "A", "A", "B", "C", "D")
+		assertWarehouseOrder(t, trx, "missing", "A", "B", "C", "D")
+	})
+}
+
+func testInsertWarehouseDuplicate(t *testing.T, s store.Store) {
+	withTransaction(t, s, func(trx store.Transaction) {
+		insertWarehouses(t, trx, 5, "A")
+	})
+	trx := beginTransaction(t, s)
+	defer trx.EndTransaction()
+	if err := trx.InsertWarehouse(domain.Warehouse{Name: "A", Address: "Other", Capacity: 1}); !errors.Is(err, store.ErrAlreadyExists) {
+		t.Fatalf("Inserting a warehouse with an existing name should fail with %v, got %v", store.ErrAlreadyExists, err)
+	}
+}
+
+func testGetWarehouse(t *testing.T, s store.Store) {
+	withTransaction(t, s, func(trx store.Transaction) {
+		insertWarehouses(t, trx, 5, "A")
+		warehouse, err := trx.GetWarehouse("A")
+		if err != nil {
+			t.Fatalf("Error getting warehouse: %v", err)
+		}
+		expected := domain.Warehouse{Name: "A", Address: "Address A", Capacity: 5}
+		if warehouse != expected {
+			t.Fatalf("Warehouse should be %v, got %v", expected, warehouse)
+		}
+		if _, err := trx.GetWarehouse("missing"); !errors.Is(err, store.ErrNotFound) {
+			t.Fatalf("Getting a missing warehouse should fail with %v, got %v", store.ErrNotFound, err)
+		}
+	})
+}
+
+func testUpdateWarehouse(t *testing.T, s store.Store) {
+	withTransaction(t, s, func(trx store.Transaction) {
+		insertWarehouses(t, trx, 5, "A")
+		updated := domain.Warehouse{Name: "A", Address: "New address", Capacity: 7, MinBrandQuality: 3}
+		if err := trx.UpdateWarehouse("A", updated); err != nil {
+			t.Fatalf("Error updating warehouse: %v", err)
+		}
+		if warehouse, err := trx.GetWarehouse("A"); err != nil || warehouse != updated {
+			t.Fatalf("Warehouse should be %v, got %v (%v)", updated, warehouse, err)
+		}
+		if err := trx.UpdateWarehouse("missing", domain.Warehouse{Name: "missing"}); !errors.Is(err, store.ErrNotFound) {
+			t.Fatalf("Updating a missing warehouse should fail with %v, got %v", store.ErrNotFound, err)
+		}
+	})
+}
+
+func testRenameWarehouseMovesStock(t *testing.T, s store.Store) {
+	withTransaction(t, s, func(trx store.Transaction) {
+		insertWarehouses(t, trx, 20, "A", "B")
+		insertProduct(t, trx, "A", Book("BOOK-A"), 3)
+		insertProduct(t, trx, "A", Consumable("CONS-A"), 2)
+		insertProduct(t, trx, "B", Book("BOOK-A"), 1)
+		if err := trx.UpdateWarehouse("A", domain.Warehouse{Name: "B", Address: "Address", Capacity: 20}); !errors.Is(err, store.ErrAlreadyExists) {
+			t.Fatalf("Renaming onto an existing warehouse should fail with %v, got %v", store.ErrAlreadyExists, err)
+		}
+	})
+	withTransaction(t, s, func(trx store.Transaction) {
+		if err := trx.UpdateWarehouse("A", domain.Warehouse{Name: "C", Address: "Address C", Capacity: 10}); err != nil {
+			t.Fatalf("Error renaming warehouse: %v", err)
+		}
+	})
+	withTransaction(t, s, func(trx store.Transaction) {
+		assertWarehouseOrder(t, trx, "", "B", "C")
+		assertUsedCapacity(t, trx, "C", 5)
+		assertUsedCapacity(t, trx, "A", 0)
+		assertStock(t, trx, "BOOK-A", "C", map[string]int{"B": 1, "C": 3})
+		if warehouse, err := trx.GetWarehouse("C"); err != nil || warehouse.Capacity != 10 {
+			t.Fatalf("Renamed warehouse should have capacity 10, got %v (%v)", warehouse, err)
+		}
+	})
+}
+
+func testDeleteWarehouse(t *testing.T, s store.Store) {
+	withTransaction(t, s, func(trx store.Transaction) {
+		insertWarehouses(t, trx, 20, "A", "B")
+		insertProduct(t, trx, "A", Book("BOOK-A"), 3)
+	})
+	func() {
+		trx := beginTransaction(t, s)
+		defer trx.EndTransaction()
+		if err := trx.DeleteWarehouse("A"); err == nil {
+			t.Fatalf("Deleting a warehouse that holds stock should fail")
+		}
+	}()
+	withTransaction(t, s, func(trx store.Transaction) {
+		assertRemoved(t, trx, "A", "BOOK-A", 3, 3)
+		if err := trx.DeleteWarehouse("A"); err != nil {
+			t.Fatalf("Error deleting emptied warehouse: %v", err)
+		}
+		if err := trx.DeleteWarehouse("missing"); !errors.Is(err, store.ErrNotFound) {
+			t.Fatalf("Deleting a missing warehouse should fail with %v, got %v", store.ErrNotFound, err)
+		}
+	})
+	withTransaction(t, s, func(trx store.Transaction) {
+		assertWarehouseOrder(t, trx, "", "B")
+		assertStock(t, trx, "BOOK-A", "B", map[string]int{})
+	})
+}
+
+func testUsedCapacity(t *testing.T, s store.Store) {
+	withTransaction(t, s, func(trx store.Transaction) {
+		insertWarehouses(t, trx, 20, "A", "B")
+		assertUsedCapacity(t, trx, "A", 0)
+		insertProduct(t, trx, "A", Book("BOOK-A"), 3)
+		insertProduct(t, trx, "A", Consumable("CONS-A"), 4)
+		insertProduct(t, trx, "A", Electronics("ETRX-A"), 5)
+		insertProduct(t, trx, "B", Book("BOOK-A"), 7)
+		assertUsedCapacity(t, trx, "A", 12)
+		assertUsedCapacity(t, trx, "B", 7)
+		assertUsedCapacity(t, trx, "missing", 0)
+	})
+}
+
+func testInsertProductAccumulates(t *testing.T, s store.Store) {
+	withTransaction(t, s, func(trx store.Transaction) {
+		insertWarehouses(t, trx, 20, "A")
+		insertProduct(t, trx, "A", Book("BOOK-A"), 2)
+		insertProduct(t, trx, "A", Book("BOOK-A"), 3)
+		assertStock(t, trx, "BOOK-A", "A", map[string]int{"A": 5})
+	})
+}
+
+func testProductsByWarehouse(t *testing.T, s store.Store) {
+	products := []domain.IProduct{Book("BOOK-A"), Consumable("CONS-A"), Electronics("ETRX-A")}
+	withTransaction(t, s, func(trx store.Transaction) {
+		insertWarehouses(t, trx, 20, "A", "B")
+		for i, product := range products {
+			insertProduct(t, trx, "A", product, i+1)
+		}
+		insertProduct(t, trx, "B", Book("BOOK-B"), 1)
+		if _, err := trx.RemoveProduct("B", "BOOK-B", 1); err != nil {
+			t.Fatalf("Error removing product: %v", err)
+		}
+	})
+	withTransaction(t, s, func(trx store.Transaction) {
+		result, err := trx.GetProductsByWarehouse("A")
+		if err != nil {
+			t.Fatalf("Error listing products: %v", err)
+		}
+		sort.Slice(result, func(i, j int) bool {
+			return result[i].Product.GetBaseProduct().SKU < result[j].Product.GetBaseProduct().SKU
+		})
+		if len(result) != len(products) {
+			t.Fatalf("Warehouse should have %d products, got %d", len(products), len(result))
+		}
+		for i, product := range products {
+			if !reflect.DeepEqual(result[i].Product, product) {
+				t.Fatalf("Products should be the same: %v, %v", result[i].Product, product)
+			}
+			if result[i].Quantity != i+1 {
+				t.Fatalf("Product %s quantity should be %d, got %d", product.GetBaseProduct().SKU, i+1, result[i].Quantity)
+			}
+		}
+		empty, err := trx.GetProductsByWarehouse("B")
+		if err != nil {
+			t.Fatalf("Error listing products: %v", err)
+		}
+		if len(empty) != 0 {
+			t.Fatalf("Products with zero quantity should not be listed, got %v", empty)
+		}
+	})
+}
+
+func testProductTypeBySku(t *testing.T, s store.Store) {
+	withTransaction(t, s, func(trx store.Transaction) {
+		insertWarehouses(t, trx, 20, "A")
+		assertProductType(t, trx, "BOOK-A", domain.None)
+		insertProduct(t, trx, "A", Book("BOOK-A"), 1)
+		insertProduct(t, trx, "A", Consumable("CONS-A"), 1)
+		insertProduct(t, trx, "A", Electronics("ETRX-A"), 1)
+		assertProductType(t, trx, "BOOK-A", "Book")
+		assertProductType(t, trx, "CONS-A", "Consumable")
+		assertProductType(t, trx, "ETRX-A", "Electronics")
+		assertProductType(t, trx, "missing", domain.None)
+	})
+}
+
+func testWarehouseProductsBySkuOrderedFirstWithName(t *testing.T, s store.Store) {
+	withTransaction(t, s, func(trx store.Transaction) {
+		insertWarehouses(t, trx, 20, "A", "B", "C", "D")
+		insertProduct(t, trx, "D", Book("BOOK-A"), 1)
+		insertProduct(t, trx, "B", Book("BOOK-A"), 2)
+		insertProduct(t, trx, "C", Book("BOOK-A"), 3)
+		insertProduct(t, trx, "A", Book("BOOK-B"), 4)
+		result, err := trx.GetWarehouseProductsBySkuOrderedFirstWithName("C", "BOOK-A")
+		if err != nil {
+			t.Fatalf("Error listing warehouse products: %v", err)
+		}
+		expected := []domain.WarehouseProduct{
+			{WarehouseName: "C", Sku: "BOOK-A", Quantity: 3},
+			{WarehouseName: "B", Sku: "BOOK-A", Quantity: 2},
+			{WarehouseName: "D", Sku: "BOOK-A", Quantity: 1},
+		}
+		if !reflect.DeepEqual(result, expected) {
+			t.Fatalf("Warehouse products should be %v, got %v", expected, result)
+		}
+	})
+}
+
+func testRemoveProductClampsToZero(t *testing.T, s store.Store) {
+	withTransaction(t, s, func(trx store.Transaction) {
+		insertWarehouses(t, trx, 20, "A")
+		insertProduct(t, trx, "A", Book("BOOK-A"), 3)
+		assertRemoved(t, trx, "A", "BOOK-A", 2, 2)
+		assertRemoved(t, trx, "A", "BOOK-A", 5, 1)
+		assertRemoved(t, trx, "A", "BOOK-A", 1, 0)
+		assertRemoved(t, trx, "A", "missing", 1, 0)
+		assertUsedCapacity(t, trx, "A", 0)
+	})
+}
+
+func testRemoveProductDeletesEmptyRows(t *testing.T, s store.Store) {
+	withTransaction(t, s, func(trx store.Transaction) {
+		insertWarehouses(t, trx, 20, "A", "B")
+		insertProduct(t, trx, "A", Book("BOOK-A"), 3)
+		insertProduct(t, trx, "B", Book("BOOK-A"), 0)
+		assertRemoved(t, trx, "A", "BOOK-A", 3, 3)
+		warehouseProducts, err := trx.GetWarehouseProductsBySkuOrderedFirstWithName("A", "BOOK-A")
+		if err != nil {
+			t.Fatalf("Error listing warehouse products: %v", err)
+		}
+		if len(warehouseProducts) != 0 {
+			t.Fatalf("Emptied and zero quantity rows should not exist, got %v", warehouseProducts)
+		}
+	})
+}
+
+func testDeleteUnused(t *testing.T, s store.Store) {
+	withTransaction(t, s, func(trx store.Transaction) {
+		insertWarehouses(t, trx, 20, "A")
+		insertProduct(t, trx, "A", Book("BOOK-A"), 1)
+		if err := trx.InsertCatalogProduct(Consumable("CONS-A")); err != nil {
+			t.Fatalf("Error inserting catalog product: %v", err)
+		}
+		if err := trx.InsertBrand(domain.Brand{Name: "Empty", Quality: 2}); err != nil {
+			t.Fatalf("Error inserting brand: %v", err)
+		}
+	})
+	withTransaction(t, s, func(trx store.Transaction) {
+		if deleted, err := trx.DeleteUnusedProducts(); err != nil || deleted != 1 {
+			t.Fatalf("Only the product without stock should be deleted, got %d, %v", deleted, err)
+		}
+		if _, err := trx.DeleteOrphanedProductAttributes(); err != nil {
+			t.Fatalf("Error deleting orphaned product attributes: %v", err)
+		}
+		if deleted, err := trx.DeleteUnusedBrands(); err != nil || deleted != 2 {
+			t.Fatalf("The empty brand and the brand of the deleted product should be deleted, got %d, %v", deleted, err)
+		}
+	})
+	withTransaction(t, s, func(trx store.Transaction) {
+		products, err := trx.GetCatalogProducts()
+		if err != nil {
+			t.Fatalf("Error listing catalog products: %v", err)
+		}
+		if len(products) != 1 || products[0].GetBaseProduct().SKU != "BOOK-A" {
+			t.Fatalf("Only the stocked product should be left, got %v", products)
+		}
+		brands, err := trx.GetBrands()
+		if err != nil {
+			t.Fatalf("Error listing brands: %v", err)
+		}
+		if len(brands) != 1 || brands[0].Name != "Book Brand" {
+			t.Fatalf("Only the brand of the stocked product should be left, got %v", brands)
+		}
+	})
+}
+
+func testCatalogProducts(t *testing.T, s store.Store) {
+	products := []domain.IProduct{Book("BOOK-A"), Consumable("CONS-A"), Electronics("ETRX-A")}
+	withTransaction(t, s, func(trx store.Transaction) {
+		for i := len(products) - 1; i >= 0; i-- {
+			if err := trx.InsertCatalogProduct(products[i]); err != nil {
+				t.Fatalf("Error inserting catalog product: %v", err)
+			}
+		}
+		if err := trx.InsertCatalogProduct(Book("BOOK-A")); !errors.Is(err, store.ErrAlreadyExists) {
+			t.Fatalf("Inserting an existing product should fail with %v, got %v", store.ErrAlreadyExists, err)
+		}
+	})
+	withTransaction(t, s, func(trx store.Transaction) {
+		result, err := trx.GetCatalogProducts()
+		if err != nil {
+			t.Fatalf("Error listing catalog products: %v", err)
+		}
+		if !reflect.DeepEqual(result, products) {
+			t.Fatalf("Catalog should be %v ordered by SKU, got %v", products, result)
+		}
+		product, err := trx.GetCatalogProduct("CONS-A")
+		if err != nil {
+			t.Fatalf("Error getting catalog product: %v", err)
+		}
+		if !reflect.DeepEqual(product, products[1]) {
+			t.Fatalf("Products should be the same: %v, %v", product, products[1])
+		}
+		if _, err := trx.GetCatalogProduct("missing"); !errors.Is(err, store.ErrNotFound) {
+			t.Fatalf("Getting a missing product should fail with %v, got %v", store.ErrNotFound, err)
+		}
+	})
+}
+
+func testUpdateCatalogProduct(t *testing.T, s store.Store) {
+	withTransaction(t, s, func(trx store.Transaction) {
+		insertWarehouses(t, trx, 20, "A")
+		insertProduct(t, trx, "A", Book("BOOK-A"), 2)
+	})
+	updated := Book("BOOK-A")
+	updated.Name = "New name"
+	updated.Price = 150
+	updated.Author = "New author"
+	updated.Brand = domain.Brand{Name: "Other Brand", Quality: 2}
+	withTransaction(t, s, func(trx store.Transaction) {
+		if err := trx.UpdateCatalogProduct(updated); err != nil {
+			t.Fatalf("Error updating catalog product: %v", err)
+		}
+		if err := trx.UpdateCatalogProduct(Book("missing")); !errors.Is(err, store.ErrNotFound) {
+			t.Fatalf("Updating a missing product should fail with %v, got %v", store.ErrNotFound, err)
+		}
+	})
+	withTransaction(t, s, func(trx store.Transaction) {
+		product, err := trx.GetCatalogProduct("BOOK-A")
+		if err != nil {
+			t.Fatalf("Error getting catalog product: %v", err)
+		}
+		if !reflect.DeepEqual(product, updated) {
+			t.Fatalf("Product should be %v, got %v", updated, product)
+		}
+		stock, err := trx.GetProductsByWarehouse("A")
+		if err != nil {
+			t.Fatalf("Error listing products: %v", err)
+		}
+		if len(stock) != 1 || !reflect.DeepEqual(stock[0].Product, updated) || stock[0].Quantity != 2 {
+			t.Fatalf("Stock should show the updated product, got %v", stock)
+		}
+	})
+}
+
+func testDeleteCatalogProduct(t *testing.T, s store.Store) {
+	withTransaction(t, s, func(trx store.Transaction) {
+		insertWarehouses(t, trx, 20, "A")
+		insertProduct(t, trx, "A", Book("BOOK-A"), 2)
+		insertProduct(t, trx, "A", Consumable("CONS-A"), 1)
+		assertRemoved(t, trx, "A", "CONS-A", 1, 1)
+	})
+	withTransaction(t, s, func(trx store.Transaction) {
+		if err := trx.DeleteCatalogProduct("BOOK-A"); err == nil {
+			t.Fatalf("Deleting a product in stock should fail")
+		}
+		if err := trx.DeleteCatalogProduct("CONS-A"); err != nil {
+			t.Fatalf("Error deleting catalog product: %v", err)
+		}
+		if err := trx.DeleteCatalogProduct("missing"); !errors.Is(err, store.ErrNotFound) {
+			t.Fatalf("Deleting a missing product should fail with %v, got %v", store.ErrNotFound, err)
+		}
+	})
+	withTransaction(t, s, func(trx store.Transaction) {
+		assertProductType(t, trx, "CONS-A", domain.None)
+		assertStock(t, trx, "CONS-A", "A", map[string]int{})
+		insertProduct(t, trx, "A", Consumable("CONS-A"), 1)
+		assertProductType(t, trx, "CONS-A", "Consumable")
+	})
+}
+
+func testBrands(t *testing.T, s store.Store) {
+	withTransaction(t, s, func(trx store.Transaction) {
+		if err := trx.InsertBrand(domain.Brand{Name: "B", Quality: 2}); err != nil {
+			t.Fatalf("Error inserting brand: %v", err)
+		}
+		if err := trx.InsertBrand(domain.Brand{Name: "B", Quality: 3}); !errors.Is(err, store.ErrAlreadyExists) {
+			t.Fatalf("Inserting an existing brand should fail with %v, got %v", store.ErrAlreadyExists, err)
+		}
+		if err := trx.InsertCatalogProduct(Book("BOOK-A")); err != nil {
+			t.Fatalf("Error inserting catalog product: %v", err)
+		}
+	})
+	withTransaction(t, s, func(trx store.Transaction) {
+		if err := trx.UpdateBrand(domain.Brand{Name: "Book Brand", Quality: 1}); err != nil {
+			t.Fatalf("Error updating brand: %v", err)
+		}
+		if err := trx.UpdateBrand(domain.Brand{Name: "missing", Quality: 1}); !errors.Is(err, store.ErrNotFound) {
+			t.Fatalf("Updating a missing brand should fail with %v, got %v", store.ErrNotFound, err)
+		}
+	})
+	withTransaction(t, s, func(trx store.Transaction) {
+		brands, err := trx.GetBrands()
+		if err != nil {
+			t.Fatalf("Error listing brands: %v", err)
+		}
+		expected := []domain.Brand{{Name: "B", Quality: 2}, {Name: "Book Brand", Quality: 1}}
+		if !reflect.DeepEqual(brands, expected) {
+			t.Fatalf("Brands should be %v, got %v", expected, brands)
+		}
+		if _, err := trx.GetBrand("missing"); !errors.Is(err, store.ErrNotFound) {
+			t.Fatalf("Getting a missing brand should fail with %v, got %v", store.ErrNotFound, err)
+		}
+		products, err := trx.GetCatalogProductsByBrand("Book Brand")
+		if err != nil {
+			t.Fatalf("Error listing products by brand: %v", err)
+		}
+		if len(products) != 1 || products[0].GetBaseProduct().Brand.Quality != 1 {
+			t.Fatalf("Brand should have one product with the updated quality, got %v", products)
+		}
+	})
+}
+
+func testDeleteBrand(t *testing.T, s store.Store) {
+	withTransaction(t, s, func(trx store.Transaction) {
+		if err := trx.InsertBrand(domain.Brand{Name: "Empty", Quality: 2}); err != nil {
+			t.Fatalf("Error inserting brand: %v", err)
+		}
+		if err := trx.InsertCatalogProduct(Book("BOOK-A")); err != nil {
+			t.Fatalf("Error inserting catalog product: %v", err)
+		}
+	})
+	withTransaction(t, s, func(trx store.Transaction) {
+		if err := trx.DeleteBrand("Book Brand"); err == nil {
+			t.Fatalf("Deleting a brand with products should fail")
+		}
+		if err := trx.DeleteBrand("Empty"); err != nil {
+			t.Fatalf("Error deleting brand: %v", err)
+		}
+		if err := trx.DeleteBrand("missing"); !errors.Is(err, store.ErrNotFound) {
+			t.Fatalf("Deleting a missing brand should fail with %v, got %v", store.ErrNotFound, err)
+		}
+	})
+	withTransaction(t, s, func(trx store.Transaction) {
+		if _, err := trx.GetBrand("Empty"); !errors.Is(err, store.ErrNotFound) {
+			t.Fatalf("Deleted brand should be gone, got %v", err)
+		}
+	})
+}
+
+func testLots(t *testing.T, s store.Store) {
+	receivedAt := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	late := domain.Lot{Sku: "CONS-A", Number: "L1", ExpirationDate: time.Date(2025, 1, 10, 0, 0, 0, 0, time.UTC), ReceivedAt: receivedAt}
+	early := domain.Lot{Sku: "CONS-A", Number: "L2", ExpirationDate: time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC), ReceivedAt: receivedAt.Add(time.Hour)}
+	withTransaction(t, s, func(trx store.Transaction) {
+		insertWarehouses(t, trx, 20, "A", "B")
+		insertProduct(t, trx, "A", Consumable("CONS-A"), 5)
+		for _, lot := range []domain.Lot{late, early} {
+			if err := trx.InsertLot(lot); err != nil {
+				t.Fatalf("Error inserting lot: %v", err)
+			}
+		}
+		if err := trx.InsertLot(late); !errors.Is(err, store.ErrAlreadyExists) {
+			t.Fatalf("Inserting an existing lot should fail with %v, got %v", store.ErrAlreadyExists, err)
+		}
+		if err := trx.ChangeLotQuantity("A", "CONS-A", "L1", 2); err != nil {
+			t.Fatalf("Error changing lot quantity: %v", err)
+		}
+		if err := trx.ChangeLotQuantity("A", "CONS-A", "L2", 1); err != nil {
+			t.Fatalf("Error changing lot quantity: %v", err)
+		}
+	})
+	withTransaction(t, s, func(trx store.Transaction) {
+		if lot, err := trx.GetLot("CONS-A", "L1"); err != nil || lot != late {
+			t.Fatalf("Lot should be %v, got %v, %v", late, lot, err)
+		}
+		if _, err := trx.GetLot("CONS-A", "missing"); !errors.Is(err, store.ErrNotFound) {
+			t.Fatalf("Getting a missing lot should fail with %v, got %v", store.ErrNotFound, err)
+		}
+		expected := []domain.WarehouseLot{{WarehouseName: "A", Lot: early, Quantity: 1}, {WarehouseName: "A", Lot: late, Quantity: 2}}
+		if lots, err := trx.GetWarehouseLots("A", ""); err != nil || !reflect.DeepEqual(lots, expected) {
+			t.Fatalf("Lots should be %v ordered by expiration, got %v, %v", expected, lots, err)
+		}
+		if err := trx.ChangeLotQuantity("A", "CONS-A", "L1", -3); err == nil {
+			t.Fatalf("Taking more than the lot holds should fail")
+		}
+	})
+	withTransaction(t, s, func(trx store.Transaction) {
+		if err := trx.ChangeLotQuantity("A", "CONS-A", "L1", -2); err != nil {
+			t.Fatalf("Error changing lot quantity: %v", err)
+		}
+		if err := trx.UpdateWarehouse("A", domain.Warehouse{Name: "C", Address: "Address C", Capacity: 20}); err != nil {
+			t.Fatalf("Error renaming warehouse: %v", err)
+		}
+	})
+	withTransaction(t, s, func(trx store.Transaction) {
+		expected := []domain.WarehouseLot{{WarehouseName: "C", Lot: early, Quantity: 1}}
+		if lots, err := trx.GetWarehouseLots("", "CONS-A"); err != nil || !reflect.DeepEqual(lots, expected) {
+			t.Fatalf("Emptied lots should be gone and the rest renamed with the warehouse, expected %v, got %v, %v", expected, lots, err)
+		}
+	})
+}
+
+func testQuarantinedStock(t *testing.T, s store.Store) {
+	expired := time.Date(2024, 1, 10, 0, 0, 0, 0, time.UTC)
+	withTransaction(t, s, func(trx store.Transaction) {
+		insertWarehouses(t, trx, 20, "A", "B")
+		insertProduct(t, trx, "A", Consumable("CONS-A"), 5)
+		insertProduct(t, trx, "B", Consumable("CONS-A"), 5)
+		for _, stock := range []domain.QuarantinedStock{
+			{WarehouseName: "A", Sku: "CONS-A", LotNumber: "L1", ExpirationDate: expired, Quantity: 1},
+			{WarehouseName: "A", Sku: "CONS-A", ExpirationDate: expired, Quantity: 2},
+			{WarehouseName: "A", Sku: "CONS-A", LotNumber: "L1", ExpirationDate: expired, Quantity: 2},
+			{WarehouseName: "B", Sku: "CONS-A", ExpirationDate: expired, Quantity: 1},
+		} {
+			if err := trx.QuarantineStock(stock); err != nil {
+				t.Fatalf("Error quarantining stock: %v", err)
+			}
+		}
+	})
+	withTransaction(t, s, func(trx store.Transaction) {
+		expected := []domain.QuarantinedStock{
+			{WarehouseName: "A", Sku: "CONS-A", ExpirationDate: expired, Quantity: 2},
+			{WarehouseName: "A", Sku: "CONS-A", LotNumber: "L1", ExpirationDate: expired, Quantity: 3},
+		}
+		if stock, err := trx.GetQuarantinedStock("A", "CONS-A"); err != nil || !reflect.DeepEqual(stock, expected) {
+			t.Fatalf("Quarantined stock should be %v, got %v, %v", expected, stock, err)
+		}
+		if err := trx.UpdateWarehouse("A", domain.Warehouse{Name: "C", Address: "Address C", Capacity: 20}); err != nil {
+			t.Fatalf("Error renaming warehouse: %v", err)
+		}
+		if err := trx.DeleteQuarantinedStock("B"); err != nil {
+			t.Fatalf("Error deleting quarantined stock: %v", err)
+		}
+	})
+	withTransaction(t, s, func(trx store.Transaction) {
+		stock, err := trx.GetQuarantinedStock("", "")
+		if err != nil {
+			t.Fatalf("Error getting quarantined stock: %v", err)
+		}
+		if len(stock) != 2 || stock[0].WarehouseName != "C" || stock[1].WarehouseName != "C" {
+			t.Fatalf("Only the renamed warehouse should hold quarantined stock, got %v", stock)
+		}
+	})
+}
+
+func testSerials(t *testing.T, s store.Store) {
+	received := time.Date(2024, 1, 10, 12, 0, 0, 0, time.UTC)
+	withTransaction(t, s, func(trx store.Transaction) {
+		insertWarehouses(t, trx, 20, "A", "B")
+		for _, serial := range []domain.Serial{
+			{Number: "SN-2", Sku: "ELEC-A", WarehouseName: "A", ReceivedAt: received},
+			{Number: "SN-1", Sku: "ELEC-A", WarehouseName: "A", ReceivedAt: received.Add(time.Hour)},
+			{Number: "SN-3", Sku: "ELEC-A", WarehouseName: "B", ReceivedAt: received},
+		} {
+			if err := trx.InsertSerial(serial); err != nil {
+				t.Fatalf("Error inserting serial: %v", err)
+			}
+		}
+		if err := trx.InsertSerial(domain.Serial{Number: "SN-1", Sku: "ELEC-B", WarehouseName: "B", ReceivedAt: received}); !errors.Is(err, store.ErrAlreadyExists) {
+			t.Fatalf("Inserting an existing serial should fail with %v, got %v", store.ErrAlreadyExists, err)
+		}
+		for _, event := range []domain.SerialEvent{
+			{Serial: "SN-1", Timestamp: received.Add(time.Hour), WarehouseName: "A", Reason: domain.MovementInsert, CorrelationID: "1"},
+			{Serial: "SN-1", Timestamp: received.Add(2 * time.Hour), WarehouseName: "A", Reason: domain.MovementRemove, CorrelationID: "2", Actor: "bob"},
+			{Serial: "SN-2", Timestamp: received, WarehouseName: "A", Reason: domain.MovementInsert, CorrelationID: "1"},
+		} {
+			if err := trx.InsertSerialEvent(event); err != nil {
+				t.Fatalf("Error inserting serial event: %v", err)
+			}
+		}
+		if err := trx.UpdateSerial(domain.Serial{Number: "SN-1", Sku: "ELEC-A", ReceivedAt: received.Add(time.Hour)}); err != nil {
+			t.Fatalf("Error updating serial: %v", err)
+		}
+		if err := trx.UpdateSerial(domain.Serial{Number: "missing", Sku: "ELEC-A"}); !errors.Is(err, store.ErrNotFound) {
+			t.Fatalf("Updating a missing serial should fail with %v, got %v", store.ErrNotFound, err)
+		}
+	})
+	withTransaction(t, s, func(trx store.Transaction) {
+		if err := trx.UpdateWarehouse("B", domain.Warehouse{Name: "C", Address: "Address C", Capacity: 20}); err != nil {
+			t.Fatalf("Error renaming warehouse: %v", err)
+		}
+		serials, err := trx.GetWarehouseSerials("A", "ELEC-A")
+		if err != nil || len(serials) != 1 || serials[0].Number != "SN-2" || !serials[0].ReceivedAt.Equal(received) {
+			t.Fatalf("Only SN-2 should be left in A, got %v, %v", serials, err)
+		}
+		serial, err := trx.GetSerial("SN-3")
+		if err != nil || serial.WarehouseName != "C" {
+			t.Fatalf("SN-3 should have moved with the renamed warehouse, got %v, %v", serial, err)
+		}
+		if serial, err := trx.GetSerial("SN-1"); err != nil || serial.WarehouseName != "" {
+			t.Fatalf("SN-1 should be out of stock, got %v, %v", serial, err)
+		}
+		if _, err := trx.GetSerial("missing"); !errors.Is(err, store.ErrNotFound) {
+			t.Fatalf("Getting a missing serial should fail with %v, got %v", store.ErrNotFound, err)
+		}
+		events, err := trx.GetSerialEvents("SN-1")
+		if err != nil || len(events) != 2 || events[0].Reason != domain.MovementInsert || events[1].Reason != domain.MovementRemove || events[1].Actor != "bob" {
+			t.Fatalf("SN-1 should have been inserted and removed, got %v, %v", events, err)
+		}
+	})
+}
+
+func testMovements(t *testing.T, s store.Store) {
+	start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	movements := []domain.Movement{
+		{Timestamp: start, WarehouseName: "A", Sku: "BOOK-A", Delta: 5, Reason: domain.MovementInsert, CorrelationID: "1", Actor: "alice"},
+		{Timestamp: start.Add(time.Second), WarehouseName: "B", Sku: "BOOK-A", Delta: 2, Reason: domain.MovementInsertOverflow, CorrelationID: "1", Actor: "alice"},
+		{Timestamp: start.Add(time.Minute), WarehouseName: "A", Sku: "CONS-A", Delta: -1, Reason: domain.MovementRemove, CorrelationID: "2"},
+		{Timestamp: start.Add(time.Hour).In(time.FixedZone("CET", 3600)), WarehouseName: "A", Sku: "BOOK-A", Delta: -3, Reason: domain.MovementTransferOut, CorrelationID: "3", Actor: "bob"},
+	}
+	withTransaction(t, s, func(trx store.Transaction) {
+		for _, movement := range movements[:3] {
+			if err := trx.InsertMovement(movement); err != nil {
+				t.Fatalf("Error inserting movement: %v", err)
+			}
+		}
+	})
+	trx := beginTransaction(t, s)
+	if err := trx.InsertMovement(domain.Movement{Timestamp: start, WarehouseName: "A", Sku: "BOOK-A", Delta: 1, Reason: domain.MovementInsert}); err != nil {
+		t.Fatalf("Error inserting movement: %v", err)
+	}
+	if err := trx.RollbackTransaction(); err != nil {
+		t.Fatalf("Error rolling back transaction: %v", err)
+	}
+	trx.EndTransaction()
+	withTransaction(t, s, func(trx store.Transaction) {
+		if err := trx.InsertMovement(movements[3]); err != nil {
+			t.Fatalf("Error inserting movement: %v", err)
+		}
+	})
+	withTransaction(t, s, func(trx store.Transaction) {
+		all, err := trx.GetMovements(domain.MovementFilter{})
+		if err != nil {
+			t.Fatalf("Error listing movements: %v", err)
+		}
+		if len(all) != len(movements) {
+			t.Fatalf("There should be %d movements, got %v", len(movements), all)
+		}
+		for i, movement := range all {
+			if i > 0 && movement.ID <= all[i-1].ID {
+				t.Fatalf("Movement ids should increase, got %v", all)
+			}
+			movement.ID = 0
+			expected := movements[i]
+			expected.Timestamp = expected.Timestamp.UTC()
+			if !reflect.DeepEqual(movement, expected) {
+				t.Fatalf("Movement should be %v, got %v", expected, movement)
+			}
+		}
+		assertMovementDeltas(t, trx, domain.MovementFilter{Sku: "BOOK-A"}, 5, 2, -3)
+		assertMovementDeltas(t, trx, domain.MovementFilter{WarehouseName: "A"}, 5, -1, -3)
+		assertMovementDeltas(t, trx, domain.MovementFilter{Sku: "BOOK-A", WarehouseName: "A"}, 5, -3)
+		assertMovementDeltas(t, trx, domain.MovementFilter{From: start.Add(time.Second), To: start.Add(time.Hour)}, 2, -1)
+		assertMovementDeltas(t, trx, domain.MovementFilter{Sku: "missing"})
+	})
+}
+
+func testStockAsOf(t *testing.T, s store.Store) {
+	start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	at := func(minutes int) time.Time { return start.Add(time.Duration(minutes) * time.Minute) }
+	insertMovements := func(movements ...domain.Movement) {
+		withTransaction(t, s, func(trx store.Transaction) {
+			for _, movement := range movements {
+				if err := trx.InsertMovement(movement); err != nil {
+					t.Fatalf("Error inserting movement: %v", err)
+				}
+			}
+		})
+	}
+	insertMovements(
+		domain.Movement{Timestamp: at(0), WarehouseName: "A", Sku: "BOOK-A", Delta: 5, Reason: domain.MovementInsert},
+		domain.Movement{Timestamp: at(1), WarehouseName: "B", Sku: "BOOK-A", Delta: 2, Reason: domain.MovementInsert},
+		domain.Movement{Timestamp: at(2), WarehouseName: "A", Sku: "BOOK-A", Delta: -3, Reason: domain.MovementRemove},
+	)
+	withTransaction(t, s, func(trx store.Transaction) {
+		if err := trx.TakeSnapshot(at(1)); err != nil {
+			t.Fatalf("Error taking snapshot: %v", err)
+		}
+		if err := trx.TakeSnapshot(at(1)); !errors.Is(err, store.ErrAlreadyExists) {
+			t.Fatalf("Taking a second snapshot at the same time should fail with %v, got %v", store.ErrAlreadyExists, err)
+		}
+	})
+	insertMovements(
+		domain.Movement{Timestamp: at(3), WarehouseName: "B", Sku: "BOOK-A", Delta: -2, Reason: domain.MovementRemove},
+		domain.Movement{Timestamp: at(3), WarehouseName: "B", Sku: "CONS-A", Delta: 1, Reason: domain.MovementInsert},
+	)
+	withTransaction(t, s, func(trx store.Transaction) {
+		if err := trx.TakeSnapshot(at(3)); err != nil {
+			t.Fatalf("Error taking snapshot: %v", err)
+		}
+	})
+	withTransaction(t, s, func(trx store.Transaction) {
+		assertStockAsOf(t, trx, at(-1), map[string]int{})
+		assertStockAsOf(t, trx, at(0), map[string]int{"A/BOOK-A": 5})
+		assertStockAsOf(t, trx, at(1), map[string]int{"A/BOOK-A": 5, "B/BOOK-A": 2})
+		assertStockAsOf(t, trx, at(2), map[string]int{"A/BOOK-A": 2, "B/BOOK-A": 2})
+		assertStockAsOf(t, trx, at(3), map[string]int{"A/BOOK-A": 2, "B/CONS-A": 1})
+		assertStockAsOf(t, trx, at(60), map[string]int{"A/BOOK-A": 2, "B/CONS-A": 1})
+	})
+	// committed after the snapshot at 3 although it is stamped before it, like a slow transaction
+	insertMovements(domain.Movement{Timestamp: at(2), WarehouseName: "A", Sku: "CONS-A", Delta: 4, Reason: domain.MovementInsert})
+	withTransaction(t, s, func(trx store.Transaction) {
+		assertStockAsOf(t, trx, at(3), map[string]int{"A/BOOK-A": 2, "A/CONS-A": 4, "B/CONS-A": 1})
+		assertStockAsOf(t, trx, at(60), map[string]int{"A/BOOK-A": 2, "A/CONS-A": 4, "B/CONS-A": 1})
+		assertFilteredStockAsOf(t, trx, at(2), "", "BOOK-A", map[string]int{"A/BOOK-A": 2, "B/BOOK-A": 2})
+		assertFilteredStockAsOf(t, trx, at(3), "A", "", map[string]int{"A/BOOK-A": 2, "A/CONS-A": 4})
+		assertFilteredStockAsOf(t, trx, at(60), "B", "CONS-A", map[string]int{"B/CONS-A": 1})
+		assertFilteredStockAsOf(t, trx, at(60), "B", "BOOK-A", map[string]int{})
+		if takenAt, err := trx.GetLatestSnapshotTime(); err != nil || !takenAt.Equal(at(3)) {
+			t.Fatalf("Latest snapshot should be taken at %s, got %s, %v", at(3), takenAt, err)
+		}
+	})
+}
+
+func testCommitVisibility(t *testing.T, s store.Store) {
+	withTransaction(t, s, func(trx store.Transaction) {
+		insertWarehouses(t, trx, 20, "A")
+		insertProduct(t, trx, "A", Book("BOOK-A"), 3)
+	})
+	withTransaction(t, s, func(trx store.Transaction) {
+		assertWarehouseOrder(t, trx, "", "A")
+		assertUsedCapacity(t, trx, "A", 3)
+	})
+}
+
+func testRollbackVisibility(t *testing.T, s store.Store) {
+	withTransaction(t, s, func(trx store.Transaction) {
+		insertWarehouses(t, trx, 20, "A")
+	})
+	func() {
+		trx := beginTransaction(t, s)
+		defer trx.EndTransaction()
+		insertWarehouses(t, trx, 20, "B")
+		insertProduct(t, trx, "A", Book("BOOK-A"), 3)
+		if err := trx.RollbackTransaction(); err != nil {
+			t.Fatalf("Error rolling back transaction: %v", err)
+		}
+	}()
+	func() {
+		trx := beginTransaction(t, s)
+		defer trx.EndTransaction()
+		insertWarehouses(t, trx, 20, "C")
+	}()
+	withTransaction(t, s, func(trx store.Transaction) {
+		assertWarehouseOrder(t, trx, "", "A")
+		assertUsedCapacity(t, trx, "A", 0)
+		assertProductType(t, trx, "BOOK-A", domain.None)
+	})
+}
+
+// testConcurrentTransactions checks that transactions which read the used capacity before inserting never overfill a
+// warehouse: a backend may fail some of them with retryable errors, but only the committed ones may leave a trace.
+func testConcurrentTransactions(t *testing.T, s store.Store) {
+	const capacity = 5
+	const workers = 10
+	withTransaction(t, s, func(trx store.Transaction) {
+		insertWarehouses(t, trx, capacity, "A")
+	})
+	errFull := fmt.Errorf("warehouse is full")
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	committed := 0
+	var unexpected []error
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			err := func() error {
+				trx, err := s.BeginTransaction(context.Background())
+				if err != nil {
+					return err
+				}
+				defer trx.EndTransaction()
+				used, err := trx.GetUsedCapacity("A")
+				if err != nil {
+					return err
+				}
+				if used >= capacity {
+					return errFull
+				}
+				if err := trx.InsertProduct("A", Book("BOOK-A"), 1); err != nil {
+					return err
+				}
+				return trx.CommitTransaction()
+			}()
+			mu.Lock()
+			defer mu.Unlock()
+			if err == nil {
+				committed++
+			} else if err != errFull && !s.IsRetryable(err) {
+				unexpected = append(unexpected, err)
+			}
+		}()
+	}
+	wg.Wait()
+	if len(unexpected) > 0 {
+		t.Fatalf("Concurrent transactions should only fail with retryable errors, got %v", unexpected)
+	}
+	if committed == 0 || committed > capacity {
+		t.Fatalf("Between 1 and %d transactions should have committed, got %d", capacity, committed)
+	}
+	withTransaction(t, s, func(trx store.Transaction) {
+		assertUsedCapacity(t, trx, "A", committed)
+	})
+}
+
+func testConcurrentTransactionsWithRunner(t *testing.T, s store.Store) {
+	const workers = 10
+	withTransaction(t, s, func(trx store.Transaction) {
+		insertWarehouses(t, trx, workers, "A")
+	})
+	runner := store.NewTransactionRunner(s, store.RetryPolicy{MaxAttempts: 100, InitialBackoff: time.Millisecond, MaxBackoff: 20 * time.Millisecond})
+	errs := make(chan error, workers)
+	for i := 0; i < workers; i++ {
+		go func() {
+			errs <- runner.Run(context.Background(), func(trx store.Transaction) error {
+				used, err := trx.GetUsedCapacity("A")
+				if err != nil {
+					return err
+				}
+				if used >= workers {
+					return fmt.Errorf("warehouse is full")
+				}
+				return trx.InsertProduct("A", Book("BOOK-A"), 1)
+			})
+		}()
+	}
+	for i := 0; i < workers; i++ {
+		if err := <-errs; err != nil {
+			t.Fatalf("Every retried transaction should commit, got %v", err)
+		}
+	}
+	withTransaction(t, s, func(trx store.Transaction) {
+		assertUsedCapacity(t, trx, "A", workers)
+	})
+}
+
+func testCanceledContext(t *testing.T, s store.Store) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if trx, err := s.BeginTransaction(ctx); err == nil {
+		trx.EndTransaction()
+		t.Fatalf("Beginning a transaction with a canceled context should fail")
+	}
+}
+
+func withTransaction(t *testing.T, s store.Store, body func(trx store.Transaction)) {
+	t.Helper()
+	trx := beginTransaction(t, s)
+	defer trx.EndTransaction()
+	body(trx)
+	if err := trx.CommitTransaction(); err != nil {
+		t.Fatalf("Error committing transaction: %v", err)
+	}
+}
+
+func beginTransaction(t *testing.T, s store.Store) store.Transaction {
+	t.Helper()
+	trx, err := s.BeginTransaction(context.Background())
+	if err != nil {
+		t.Fatalf("Error beginning transaction: %v", err)
+	}
+	return trx
+}
+
+func insertWarehouses(t *testing.T, trx store.Transaction, capacity int, names ...string) {
+	t.Helper()
+	for _, name := range names {
+		if err := trx.InsertWarehouse(domain.Warehouse{Name: name, Address: "Address " + name, Capacity: capacity}); err != nil {
+			t.Fatalf("Error inserting warehouse %s: %v", name, err)
+		}
+	}
+}
+
+func insertProduct(t *testing.T, trx store.Transaction, warehouseName string, product domain.IProduct, quantity int) {
+	t.Helper()
+	if err := trx.InsertProduct(warehouseName, product, quantity); err != nil {
+		t.Fatalf("Error inserting product %s: %v", product.GetBaseProduct().SKU, err)
+	}
+}
+
+func assertWarehouseOrder(t *testing.T, trx store.Transaction, firstName string, expected ...string) {
+	t.Helper()
+	var warehouses []domain.Warehouse
+	var err error
+	if firstName == "" {
+		warehouses, err = trx.GetWarehouses()
+		sort.Slice(warehouses, func(i, j int) bool { return warehouses[i].Name < warehouses[j].Name })
+	} else {
+		warehouses, err = trx.GetWarehousesOrderedFirstWithName(firstName)
+	}
+	if err != nil {
+		t.Fatalf("Error listing warehouses: %v", err)
+	}
+	names := []string{}
+	for _, warehouse := range warehouses {
+		names = append(names, warehouse.Name)
+	}
+	if !reflect.DeepEqual(names, expected) {
+		t.Fatalf("Warehouses should be %v, got %v", expected, names)
+	}
+}
+
+func assertUsedCapacity(t *testing.T, trx store.Transaction, warehouseName string, expected int) {
+	t.Helper()
+	used, err := trx.GetUsedCapacity(warehouseName)
+	if err != nil {
+		t.Fatalf("Error getting used capacity: %v", err)
+	}
+	if used != expected {
+		t.Fatalf("Used capacity of %s should be %d, got %d", warehouseName, expected, used)
+	}
+}
+
+func assertStock(t *testing.T, trx store.Transaction, sku string, firstName string, expected map[string]int) {
+	t.Helper()
+	warehouseProducts, err := trx.GetWarehouseProductsBySkuOrderedFirstWithName(firstName, sku)
+	if err != nil {
+		t.Fatalf("Error listing warehouse products: %v", err)
+	}
+	stock := map[string]int{}
+	for _, warehouseProduct := range warehouseProducts {
+		stock[warehouseProduct.WarehouseName] = warehouseProduct.Quantity
+	}
+	if !reflect.DeepEqual(stock, expected) {
+		t.Fatalf("Stock of %s should be %v, got %v", sku, expected, stock)
+	}
+}
+
+func assertProductType(t *testing.T, trx store.Transaction, sku string, expected domain.ProductType) {
+	t.Helper()
+	productType, err := trx.GetProductTypeBySku(sku)
+	if err != nil {
+		t.Fatalf("Error getting product type: %v", err)
+	}
+	if productType != expected {
+		t.Fatalf("Product type of %s should be %s, got %s", sku, expected, productType)
+	}
+}
+
+func assertMovementDeltas(t *testing.T, trx store.Transaction, filter domain.MovementFilter, expected ...int) {
+	t.Helper()
+	movements, err := trx.GetMovements(filter)
+	if err != nil {
+		t.Fatalf("Error listing movements: %v", err)
+	}
+	deltas := []int{}
+	for _, movement := range movements {
+		deltas = append(deltas, movement.Delta)
+	}
+	if expected == nil {
+		expected = []int{}
+	}
+	if !reflect.DeepEqual(deltas, expected) {
+		t.Fatalf("Movements matching %+v should have deltas %v, got %v", filter, expected, deltas)
+	}
+}
+
+func assertStockAsOf(t *testing.T, trx store.Transaction, asOf time.Time, expected map[string]int) {
+	t.Helper()
+	assertFilteredStockAsOf(t, trx, asOf, "", "", expected)
+}
+
+func assertFilteredStockAsOf(t *testing.T, trx store.Transaction, asOf time.Time, warehouseName string, sku string, expected map[string]int) {
+	t.Helper()
+	warehouseProducts, err := trx.GetStockAsOf(asOf, warehouseName, sku)
+	if err != nil {
+		t.Fatalf("Error getting stock as of %s: %v", asOf, err)
+	}
+	stock := map[string]int{}
+	for _, warehouseProduct := range warehouseProducts {
+		stock[warehouseProduct.WarehouseName+"/"+warehouseProduct.Sku] = warehouseProduct.Quantity
+	}
+	if !reflect.DeepEqual(stock, expected) {
+		t.Fatalf("Stock as of %s should be %v, got %v", asOf, expected, stock)
+	}
+}
+
+func assertRemoved(t *testing.T, trx store.Transaction, warehouseName string, sku string, toRemove int, expected int) {
+	t.Helper()
+	removed, err := trx.RemoveProduct(warehouseName, sku, toRemove)
+	if err != nil {
+		t.Fatalf("Error removing product: %v", err)
+	}
+	if removed != expected {
+		t.Fatalf("Removing %d of %s should remove %d, got %d", toRemove, sku, expected, removed)
+	}
+}
@@ -1,6 +1,8 @@
 package store
 
 import (
+	"time"
+
 	"github.com/kijevigombooc/inventory-manager/internal/inventory/store/domain"
 )
 
@@ -10,11 +12,46 @@ type Transaction interface {
 	EndTransaction()
 	GetWarehouses() ([]domain.Warehouse, error)
 	GetWarehousesOrderedFirstWithName(warehouse string) ([]domain.Warehouse, error)
+	GetWarehouse(name string) (domain.Warehouse, error)
 	InsertWarehouse(entity domain.Warehouse) error
+	UpdateWarehouse(name string, entity domain.Warehouse) error
+	DeleteWarehouse(name string) error
 	GetProductsByWarehouse(name string) ([]domain.ProductWithQuantity, error)
 	GetUsedCapacity(warehouseName string) (int, error)
 	InsertProduct(warehouseName string, product domain.IProduct, toInsertQuantity int) error
 	GetProductTypeBySku(sku string) (domain.ProductType, error)
 	GetWarehouseProductsBySkuOrderedFirstWithName(warehouseName string, sku string) ([]domain.WarehouseProduct, error)
 	RemoveProduct(warehouseName string, sku string, toRemoveQuantity int) (int, error)
+	GetCatalogProducts() ([]domain.IProduct, error)
+	GetCatalogProduct(sku string) (domain.IProduct, error)
+	InsertCatalogProduct(product domain.IProduct) error
+	UpdateCatalogProduct(product domain.IProduct) error
+	DeleteCatalogProduct(sku string) error
+	GetCatalogProductsByBrand(brandName string) ([]domain.IProduct, error)
+	GetBrands() ([]domain.Brand, error)
+	GetBrand(name string) (domain.Brand, error)
+	InsertBrand(brand domain.Brand) error
+	UpdateBrand(brand domain.Brand) error
+	DeleteBrand(name string) error
+	DeleteUnusedProducts() (int, error)
+	DeleteUnusedBrands() (int, error)
+	DeleteOrphanedProductAttributes() (int, error)
+	InsertMovement(movement domain.Movement) error
+	GetMovements(filter domain.MovementFilter) ([]domain.Movement, error)
+	GetLot(sku string, lotNumber string) (domain.Lot, error)
+	InsertLot(lot domain.Lot) error
+	GetWarehouseLots(warehouseName string, sku string) ([]domain.WarehouseLot, error)
+	ChangeLotQuantity(warehouseName string, sku string, lotNumber string, delta int) error
+	GetQuarantinedStock(warehouseName string, sku string) ([]domain.QuarantinedStock, error)
+	QuarantineStock(stock domain.QuarantinedStock) error
+	DeleteQuarantinedStock(warehouseName string) error
+	GetSerial(number string) (domain.Serial, error)
+	GetWarehouseSerials(warehouseName string, sku string) ([]domain.Serial, error)
+	InsertSerial(serial domain.Serial) error
+	UpdateSerial(serial domain.Serial) error
+	InsertSerialEvent(event domain.SerialEvent) error
+	GetSerialEvents(number string) ([]domain.SerialEvent, error)
+	TakeSnapshot(takenAt time.Time) error
+	GetLatestSnapshotTime() (time.Time, error)
+	GetStockAsOf(asOf time.Time, warehouseName string, sku string) ([]domain.WarehouseProduct, error)
 }